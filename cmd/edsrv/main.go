@@ -8,6 +8,6 @@ import (
 
 func main() {
 	if err := cmd.NewRoot().Cmd().Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -0,0 +1,238 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// EventEdit edit request.
+	EventEdit = "edit"
+	// EventRejected request rejected before reaching the editor.
+	EventRejected = "rejected"
+)
+
+// ErrInvalidRecord the audit log line is not a valid record.
+var ErrInvalidRecord = errors.New("invalid audit record")
+
+// Record represents a audit log entry, the payloads are only recorded as
+// hashes, never the content.
+type Record struct {
+	Time         time.Time `json:"time"`                   // request start
+	Event        string    `json:"event"`                  // edit or rejected
+	Origin       string    `json:"origin,omitempty"`       // request origin
+	URL          string    `json:"url,omitempty"`          // page URL
+	UserAgent    string    `json:"userAgent,omitempty"`    // request user agent
+	Remote       string    `json:"remote,omitempty"`       // client address
+	Profile      string    `json:"profile,omitempty"`      // settings profile
+	File         string    `json:"file,omitempty"`         // temporary file path
+	OriginalHash string    `json:"originalHash,omitempty"` // request payload hash
+	EditedHash   string    `json:"editedHash,omitempty"`   // response payload hash
+	DurationMs   int64     `json:"durationMs"`             // request duration
+	Status       int       `json:"status"`                 // response status code
+	Result       string    `json:"result,omitempty"`       // edit result
+	Reason       string    `json:"reason,omitempty"`       // rejection reason
+}
+
+// Hash returns the payload SHA-256 hash, empty for empty payloads.
+func Hash(payload []byte) string {
+	if len(payload) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// Filter selects the records by time and origin, zero values match all.
+type Filter struct {
+	Since  time.Time // records on and after
+	Origin string    // origin glob pattern
+}
+
+// Match asserts the record matches the filter.
+func (f *Filter) Match(r *Record) bool {
+	if !f.Since.IsZero() && r.Time.Before(f.Since) {
+		return false
+	}
+	if f.Origin != "" {
+		if ok, _ := path.Match(f.Origin, r.Origin); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Log append-only audit log, one JSON record per line. The file is opened for
+// appending on each record, so it's never rewritten.
+type Log struct {
+	logger *slog.Logger // shared logger instance
+	name   string       // audit log file path
+	m      sync.Mutex   // serializes the records
+}
+
+// Name shows the audit log file path.
+func (l *Log) Name() string {
+	return l.name
+}
+
+// Write appends the record as a single line.
+func (l *Log) Write(r *Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	l.m.Lock()
+	defer l.m.Unlock()
+	if err = os.MkdirAll(filepath.Dir(l.name), 0o700); err != nil {
+		return err
+	}
+	fh, err := os.OpenFile(l.name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = fh.Write(append(line, '\n')); err != nil {
+		_ = fh.Close()
+		return err
+	}
+	return fh.Close()
+}
+
+// scan reads the records from r and calls fn for the records matching the
+// filter. Invalid lines, i.e. truncated by a crash while appending, are skipped
+// with a warning.
+func (l *Log) scan(r io.Reader, filter *Filter, fn func(*Record) error) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64<<10), 1<<20)
+	for line := 1; s.Scan(); line++ {
+		if len(bytes.TrimSpace(s.Bytes())) == 0 {
+			continue
+		}
+		rec := &Record{}
+		if err := json.Unmarshal(s.Bytes(), rec); err != nil {
+			err = fmt.Errorf("%w: line %d: %w", ErrInvalidRecord, line, err)
+			l.logger.Warn("skipping audit record", "err", err.Error())
+			continue
+		}
+		if !filter.Match(rec) {
+			continue
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+// Query calls fn for each record matching the filter, oldest first. A missing
+// audit log has no records.
+func (l *Log) Query(filter *Filter, fn func(*Record) error) error {
+	fh, err := os.Open(l.name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	return l.scan(fh, filter, fn)
+}
+
+// Tail returns the last records matching the filter, oldest first.
+func (l *Log) Tail(filter *Filter, n int) ([]*Record, error) {
+	records := []*Record{}
+	err := l.Query(filter, func(r *Record) error {
+		records = append(records, r)
+		if len(records) > n {
+			records = records[1:]
+		}
+		return nil
+	})
+	return records, err
+}
+
+// Follow calls fn for the records matching the filter appended after the
+// informed offset, polling the file until done is closed.
+func (l *Log) Follow(
+	done <-chan struct{},
+	offset int64,
+	interval time.Duration,
+	filter *Filter,
+	fn func(*Record) error,
+) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		fh, err := os.Open(l.name)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err == nil {
+			offset, err = l.readFrom(fh, offset, filter, fn)
+			_ = fh.Close()
+			if err != nil {
+				return err
+			}
+		}
+		select {
+		case <-done:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// readFrom reads the complete lines after the offset, returns the new offset.
+func (l *Log) readFrom(
+	fh *os.File,
+	offset int64,
+	filter *Filter,
+	fn func(*Record) error,
+) (int64, error) {
+	info, err := fh.Stat()
+	if err != nil {
+		return offset, err
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	data := make([]byte, info.Size()-offset)
+	if _, err = fh.ReadAt(data, offset); err != nil && !errors.Is(err, io.EOF) {
+		return offset, err
+	}
+	// only complete lines, the last one may be still written
+	end := bytes.LastIndexByte(data, '\n') + 1
+	if err = l.scan(bytes.NewReader(data[:end]), filter, fn); err != nil {
+		return offset, err
+	}
+	return offset + int64(end), nil
+}
+
+// Size returns the audit log size, the offset to follow new records.
+func (l *Log) Size() (int64, error) {
+	info, err := os.Stat(l.name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// NewLog instantiates the audit log on the informed file.
+func NewLog(logger *slog.Logger, name string) *Log {
+	return &Log{logger: logger, name: name}
+}
@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLog(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	l := NewLog(logger, filepath.Join(t.TempDir(), "state", "audit.jsonl"))
+
+	records, err := l.Tail(&Filter{}, 10)
+	if err != nil || len(records) != 0 {
+		t.Fatalf("Log.Tail() on missing log = %v, %v", records, err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	for i, origin := range []string{
+		"chrome-extension://abc",
+		"moz-extension://def",
+		"chrome-extension://abc",
+	} {
+		err = l.Write(&Record{
+			Time:         now.Add(time.Duration(i) * time.Hour),
+			Event:        EventEdit,
+			Origin:       origin,
+			OriginalHash: Hash([]byte("secret")),
+		})
+		if err != nil {
+			t.Fatalf("Log.Write() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(l.Name())
+	if err != nil {
+		t.Fatalf("unable to read audit log: %v", err)
+	}
+	if string(data) == "" || strings.Contains(string(data), "secret") {
+		t.Errorf("audit log = %q, expected records without content", data)
+	}
+
+	records, err = l.Tail(&Filter{Origin: "chrome-extension://*"}, 1)
+	if err != nil {
+		t.Fatalf("Log.Tail() error = %v", err)
+	}
+	if len(records) != 1 || !records[0].Time.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("Log.Tail() = %v, expected the last chrome record", records)
+	}
+
+	count := 0
+	err = l.Query(&Filter{Since: now.Add(time.Hour)}, func(*Record) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Log.Query() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Log.Query() = %d records, expected 2", count)
+	}
+
+	done := make(chan struct{})
+	followed := 0
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = l.Write(&Record{Time: now, Event: EventRejected, Reason: "test"})
+		time.Sleep(50 * time.Millisecond)
+		close(done)
+	}()
+	offset, err := l.Size()
+	if err != nil {
+		t.Fatalf("Log.Size() error = %v", err)
+	}
+	err = l.Follow(done, offset, 10*time.Millisecond, &Filter{}, func(r *Record) error {
+		if r.Event != EventRejected {
+			t.Errorf("Log.Follow() event = %q, expected %q", r.Event, EventRejected)
+		}
+		followed++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Log.Follow() error = %v", err)
+	}
+	if followed != 1 {
+		t.Errorf("Log.Follow() = %d records, expected 1", followed)
+	}
+}
+
+func TestLog_InvalidRecord(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	l := NewLog(logger, filepath.Join(t.TempDir(), "audit.jsonl"))
+
+	// a crash while appending leaves a truncated line behind
+	if err := l.Write(&Record{Event: EventEdit, Origin: "first"}); err != nil {
+		t.Fatalf("Log.Write() error = %v", err)
+	}
+	fh, err := os.OpenFile(l.Name(), os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatalf("unable to open audit log: %v", err)
+	}
+	if _, err = fh.WriteString(`{"event":"edit","orig` + "\n"); err != nil {
+		t.Fatalf("unable to write audit log: %v", err)
+	}
+	_ = fh.Close()
+	if err = l.Write(&Record{Event: EventEdit, Origin: "last"}); err != nil {
+		t.Fatalf("Log.Write() error = %v", err)
+	}
+
+	records, err := l.Tail(&Filter{}, 10)
+	if err != nil {
+		t.Fatalf("Log.Tail() error = %v", err)
+	}
+	if len(records) != 2 || records[0].Origin != "first" || records[1].Origin != "last" {
+		t.Errorf("Log.Tail() = %v, expected the valid records", records)
+	}
+}
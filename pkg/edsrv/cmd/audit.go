@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/audit"
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+
+	"github.com/spf13/cobra"
+)
+
+// Audit represents the "audit" subcommand, which inspects the audit log.
+type Audit struct {
+	logger *slog.Logger   // shared logger instance
+	cmd    *cobra.Command // cobra instance
+	cfg    *config.Config // flags for configuration
+	log    *audit.Log     // audit log
+
+	since  string // records on and after, duration or timestamp
+	origin string // origin glob pattern
+	lines  int    // tail number of records
+	follow bool   // tail follows the new records
+}
+
+var auditDesc = fmt.Sprintf(`# %s audit
+
+Inspects the audit log, recorded by "start --%s". The log is append-only, one
+JSON record per line, with the payload hashes instead of the content.
+
+The records are filtered by "--since", a duration ("24h") or a timestamp
+("2006-01-02" or RFC3339), and by "--origin", a glob pattern for the request
+origin ("moz-extension://*").
+
+`, AppName, config.AuditFlag)
+
+// followInterval interval to poll the audit log for new records.
+const followInterval = time.Second
+
+// Cmd exposes the cobra command instance.
+func (a *Audit) Cmd() *cobra.Command {
+	return a.cmd
+}
+
+// persistentPreRunE instantiates the audit log.
+func (a *Audit) persistentPreRunE(_ *cobra.Command, _ []string) error {
+	a.log = audit.NewLog(a.logger, a.cfg.AuditFile())
+	return nil
+}
+
+// parseSince parses the "since" flag, a duration before now or a timestamp.
+func parseSince(since string, now time.Time) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(since); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, since, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid --since %q, expected a duration "+
+		"or timestamp", config.ErrInvalidConfig, since)
+}
+
+// filter instantiates the records filter from the flags.
+func (a *Audit) filter() (*audit.Filter, error) {
+	since, err := parseSince(a.since, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &audit.Filter{Since: since, Origin: a.origin}, nil
+}
+
+// tail prints the last records, and the new records when following.
+func (a *Audit) tail(cmd *cobra.Command, _ []string) error {
+	filter, err := a.filter()
+	if err != nil {
+		return err
+	}
+	// the offset is taken first, records written meanwhile are printed again
+	offset, err := a.log.Size()
+	if err != nil {
+		return err
+	}
+	records, err := a.log.Tail(filter, a.lines)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	for _, r := range records {
+		if err = enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	if !a.follow {
+		return nil
+	}
+	ctx, stop := signal.NotifyContext(context.Background(),
+		os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return a.log.Follow(ctx.Done(), offset, followInterval, filter,
+		func(r *audit.Record) error {
+			return enc.Encode(r)
+		})
+}
+
+// query prints the records matching the filter, oldest first.
+func (a *Audit) query(cmd *cobra.Command, _ []string) error {
+	filter, err := a.filter()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	return a.log.Query(filter, func(r *audit.Record) error {
+		return enc.Encode(r)
+	})
+}
+
+// NewAudit instantiates the "audit" subcommand, its subcommands and flags.
+func NewAudit(logger *slog.Logger, cfg *config.Config) *Audit {
+	a := &Audit{
+		logger: logger,
+		cmd: &cobra.Command{
+			Use:          "audit",
+			Short:        "Inspects the audit log",
+			Long:         auditDesc,
+			SilenceUsage: true,
+		},
+		cfg: cfg,
+	}
+	a.cmd.PersistentPreRunE = a.persistentPreRunE
+	a.cfg.AddStateDirFlag(a.cmd.PersistentFlags())
+	a.cmd.PersistentFlags().StringVar(&a.since, "since", "",
+		"records on and after, duration or timestamp")
+	a.cmd.PersistentFlags().StringVar(&a.origin, "origin", "",
+		"request origin glob pattern")
+
+	tail := &cobra.Command{
+		Use:          "tail",
+		Short:        "Prints the last records, optionally following new ones",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         a.tail,
+	}
+	tail.Flags().IntVarP(&a.lines, "lines", "n", 10, "number of records")
+	tail.Flags().BoolVarP(&a.follow, "follow", "f", false,
+		"prints the new records as they are written")
+	a.cmd.AddCommand(tail)
+
+	a.cmd.AddCommand(&cobra.Command{
+		Use:          "query",
+		Short:        "Prints the records matching the filters, oldest first",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         a.query,
+	})
+	return a
+}
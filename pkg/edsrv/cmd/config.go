@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Config represents the "config" subcommand, which shows, initializes and
+// validates the configuration.
+type Config struct {
+	logger *slog.Logger   // shared logger instance
+	cmd    *cobra.Command // cobra instance
+	cfg    *config.Config // flags for configuration
+
+	output string // show output format, json or text
+	force  bool   // init overwrites the existing file
+}
+
+// ErrConfigExists the configuration file already exists.
+var ErrConfigExists = errors.New("configuration file already exists")
+
+var configDesc = fmt.Sprintf(`# %s config
+
+Shows the effective configuration, the configuration file "settings" merged
+with the command-line flags, and where each value came from: "default", "file",
+"env" (the environment variables the default is based on) or "flag".
+
+Initializes a commented starter configuration file, and validates the
+configuration file and flags without starting the edit-server.
+
+`, AppName)
+
+// configShow the "config show" output.
+type configShow struct {
+	ConfigFile string         `json:"configFile"` // configuration file path
+	Values     []config.Value `json:"values"`     // flag values and sources
+	Profiles   []string       `json:"profiles"`   // profile names
+	Rules      int            `json:"rules"`      // number of site rules
+}
+
+// Cmd exposes the cobra command instance.
+func (c *Config) Cmd() *cobra.Command {
+	return c.cmd
+}
+
+// loadSettings loads the configuration file, informing its settings on the
+// command flags not informed on the command-line.
+func loadSettings(cfg *config.Config, cmd *cobra.Command) error {
+	if err := cfg.LoadConfigFile(); err != nil {
+		return err
+	}
+	return cfg.ApplySettings(cmd.Flags())
+}
+
+// flags returns the configuration flags, the global flags followed by the
+// "start" subcommand ones, without the subcommand specific flags.
+func (c *Config) flags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("config", pflag.ContinueOnError)
+	fs.AddFlagSet(c.cmd.Root().PersistentFlags())
+	fs.AddFlagSet(c.cmd.PersistentFlags())
+	return fs
+}
+
+// show prints the effective configuration values and their source.
+func (c *Config) show(cmd *cobra.Command, _ []string) error {
+	if err := validateOutput(c.output); err != nil {
+		return err
+	}
+	if err := loadSettings(c.cfg, cmd); err != nil {
+		return err
+	}
+	s := &configShow{
+		ConfigFile: c.cfg.ConfigFile,
+		Values:     c.cfg.Values(c.flags()),
+		Profiles:   []string{},
+		Rules:      len(c.cfg.Rules),
+	}
+	for name := range c.cfg.Profiles {
+		s.Profiles = append(s.Profiles, name)
+	}
+	sort.Strings(s.Profiles)
+
+	if c.output == outputJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVALUE\tSOURCE")
+	for _, v := range s.Values {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", v.Name, v.Value, v.Source)
+	}
+	fmt.Fprintf(w, "\nConfiguration File:\t%s\n", s.ConfigFile)
+	profiles := "-"
+	if len(s.Profiles) > 0 {
+		profiles = strings.Join(s.Profiles, ", ")
+	}
+	fmt.Fprintf(w, "Profiles:\t%s\n", profiles)
+	fmt.Fprintf(w, "Rules:\t%d\n", s.Rules)
+	return w.Flush()
+}
+
+// init writes the starter configuration file, only readable by the current
+// user, the existing file is only overwritten with "--force".
+func (c *Config) init(cmd *cobra.Command, _ []string) error {
+	name := c.cfg.ConfigFile
+	if name == "" {
+		return fmt.Errorf("%w: flag %q is not informed",
+			config.ErrInvalidConfig, config.ConfigFlag)
+	}
+	if _, err := os.Stat(name); err == nil && !c.force {
+		return fmt.Errorf("%w: %q, use --force to overwrite", ErrConfigExists, name)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(name, config.StarterFile(), 0o600); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(cmd.OutOrStdout(), "Configuration file written to %q\n", name)
+	return err
+}
+
+// unjoin returns the errors joined by errors.Join, recursively.
+func unjoin(err error) []error {
+	if err == nil {
+		return nil
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	errs := []error{}
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, unjoin(e)...)
+	}
+	return errs
+}
+
+// validate runs all the validations, printing the errors found. Returns error
+// when the configuration is invalid.
+func (c *Config) validate(cmd *cobra.Command, _ []string) error {
+	errs := unjoin(errors.Join(loadSettings(c.cfg, cmd), c.cfg.ValidateAll()))
+	if len(errs) == 0 {
+		_, err := fmt.Fprintln(cmd.OutOrStdout(), "Configuration is valid.")
+		return err
+	}
+	for _, err := range errs {
+		fmt.Fprintf(cmd.OutOrStdout(), "error: %s\n", err)
+	}
+	return fmt.Errorf("%w: %d errors found", config.ErrInvalidConfig, len(errs))
+}
+
+// NewConfig instantiates the "config" subcommand and its flags.
+func NewConfig(logger *slog.Logger, cfg *config.Config) *Config {
+	c := &Config{
+		logger: logger,
+		cmd: &cobra.Command{
+			Use:          "config",
+			Short:        "Shows, initializes and validates the configuration",
+			Long:         configDesc,
+			SilenceUsage: true,
+		},
+		cfg: cfg,
+	}
+	c.cfg.AddStartFlags(c.cmd.PersistentFlags())
+
+	show := &cobra.Command{
+		Use:          "show",
+		Short:        "Prints the effective configuration and each value source",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         c.show,
+	}
+	show.Flags().StringVarP(&c.output, "output", "o", outputText,
+		"output format, json or text")
+	c.cmd.AddCommand(show)
+
+	initCmd := &cobra.Command{
+		Use:          "init",
+		Short:        "Writes a commented starter configuration file",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         c.init,
+	}
+	initCmd.Flags().BoolVar(&c.force, "force", false,
+		"overwrites the existing configuration file")
+	c.cmd.AddCommand(initCmd)
+
+	c.cmd.AddCommand(&cobra.Command{
+		Use:          "validate",
+		Short:        "Validates the configuration file and flags",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         c.validate,
+	})
+	return c
+}
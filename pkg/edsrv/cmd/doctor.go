@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/doctor"
+	"github.com/otaviof/edsrv/pkg/edsrv/editor"
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
+
+	"github.com/spf13/cobra"
+)
+
+// Doctor represents the "doctor" subcommand, which diagnoses the local
+// environment.
+type Doctor struct {
+	logger *slog.Logger   // shared logger instance
+	cmd    *cobra.Command // cobra instance
+	cfg    *config.Config // flags for configuration
+
+	output   string // output format, json or text
+	skipEdit bool   // skips the test edit
+}
+
+// ErrDoctorFailed the diagnostics found problems preventing editing.
+var ErrDoctorFailed = errors.New("doctor found problems")
+
+var doctorDesc = fmt.Sprintf(`# %s doctor
+
+Checks the local environment and prints a pass, warn or fail report, with the
+fix for each problem found. Informs the same flags used for "%s start" to check
+the same setup.
+
+The editor is opened on a throwaway file, to check it blocks until the file is
+closed, use "--skip-edit" to skip this check.
+
+`, AppName, AppName)
+
+// Cmd exposes the cobra command instance.
+func (d *Doctor) Cmd() *cobra.Command {
+	return d.cmd
+}
+
+// preRunE validates the output format, and informs the configuration file
+// settings on the flags. The configuration file problems are reported by its
+// check instead.
+func (d *Doctor) preRunE(cmd *cobra.Command, _ []string) error {
+	_ = loadSettings(d.cfg, cmd)
+	return validateOutput(d.output)
+}
+
+// editorChecks returns the editor checks, the test edit only runs when the
+// editor resolves. The private directory for the test edit is removed by the
+// returned function.
+func (d *Doctor) editorChecks(logger *slog.Logger) (doctor.Report, func()) {
+	if d.cfg.Nvim {
+		return doctor.Run(doctor.NvimReachable(d.cfg.NvimListen)), func() {}
+	}
+	tmpDir, err := file.NewPrivateDir(d.cfg.TmpDir)
+	if err != nil {
+		// the temporary directory problem is shown by its own check
+		tmpDir = d.cfg.TmpDir
+	}
+	cleanup := func() {
+		if tmpDir != d.cfg.TmpDir {
+			_ = os.RemoveAll(tmpDir)
+		}
+	}
+	launcher := editor.NewLauncher(
+		d.cfg.Terminal, d.cfg.Tmux, d.cfg.TmuxTarget, tmpDir,
+	)
+	ed := editor.NewEditor(
+		logger, launcher, d.cfg.EditorCandidates(), tmpDir, d.cfg.Shred,
+	)
+	r := doctor.Run(doctor.EditorResolves(ed))
+	if r.Count(doctor.StatusPass) == 0 || d.skipEdit {
+		return r, cleanup
+	}
+	return append(r, doctor.Run(doctor.EditorBlocks(
+		logger, ed, d.cfg.Storage, doctor.BlockThreshold,
+	))...), cleanup
+}
+
+// runE runs the checks and prints the report, returns error when any check
+// fails.
+func (d *Doctor) runE(cmd *cobra.Command, _ []string) error {
+	logger := d.cfg.LoggerWith(d.logger, config.AddrFlag, config.ConfigFlag)
+
+	r := doctor.Run(doctor.ConfigFile(d.cfg), doctor.TmpDir(d.cfg.TmpDir))
+	editorReport, cleanup := d.editorChecks(logger)
+	defer cleanup()
+	r = append(r, editorReport...)
+	r = append(r, doctor.Run(
+		doctor.Port(d.cfg.Addr),
+		doctor.Server(logger, d.cfg.Addr),
+		doctor.NativeMessaging(doctor.ManifestDirs()),
+	)...)
+
+	var err error
+	if d.output == outputJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		err = enc.Encode(r)
+	} else {
+		err = r.Write(cmd.OutOrStdout())
+	}
+	if err != nil {
+		return err
+	}
+	if n := r.Count(doctor.StatusFail); n > 0 {
+		return fmt.Errorf("%w: %d checks failed", ErrDoctorFailed, n)
+	}
+	return nil
+}
+
+// NewDoctor instantiates the "doctor" subcommand and its flags.
+func NewDoctor(logger *slog.Logger, cfg *config.Config) *Doctor {
+	d := &Doctor{
+		logger: logger,
+		cmd: &cobra.Command{
+			Use:          "doctor",
+			Short:        "Diagnoses the local environment",
+			Long:         doctorDesc,
+			Args:         cobra.NoArgs,
+			SilenceUsage: true,
+		},
+		cfg: cfg,
+	}
+	d.cmd.PreRunE = d.preRunE
+	d.cmd.RunE = d.runE
+	d.cfg.AddStartFlags(d.cmd.PersistentFlags())
+	d.cmd.Flags().StringVarP(&d.output, "output", "o", outputText,
+		"output format, json or text")
+	d.cmd.Flags().BoolVar(&d.skipEdit, "skip-edit", false,
+		"skips the test edit, which opens the editor")
+	return d
+}
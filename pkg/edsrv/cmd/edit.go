@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/service"
+
+	"github.com/spf13/cobra"
+	"github.com/valyala/fasthttp"
+)
+
+// Edit represents the "edit" subcommand, which edits a file or the standard
+// input using the running edit-server.
+type Edit struct {
+	logger *slog.Logger   // shared logger instance
+	cmd    *cobra.Command // cobra instance
+	cfg    *config.Config // flags for configuration
+
+	url     string // page URL metadata, selects the site rules
+	ext     string // file name extension hint
+	profile string // settings profile name
+}
+
+const (
+	// ExitUnchanged exit code for unchanged edits.
+	ExitUnchanged = 2
+	// ExitAborted exit code for aborted edits.
+	ExitAborted = 3
+)
+
+var (
+	// ErrEditUnchanged the editor didn't change the text.
+	ErrEditUnchanged = errors.New("edit unchanged")
+	// ErrEditAborted the user aborted the edit.
+	ErrEditAborted = errors.New("edit aborted")
+	// ErrEditFailed the edit-server failed to edit the text.
+	ErrEditFailed = errors.New("edit failed")
+)
+
+// exitError carries the process exit code for the error.
+type exitError struct {
+	code int   // process exit code
+	err  error // original error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// ExitCode returns the process exit code for the error returned by the
+// subcommands, one for errors without a specific exit code.
+func ExitCode(err error) int {
+	var e *exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return 1
+}
+
+var editDesc = fmt.Sprintf(`# %s edit
+
+Edits the file informed, or the standard input when "-" or omitted, using the
+running edit-server. Waits for the editor to finish, the edited file is written
+in place, and the edited standard input is written on the standard output.
+
+The metadata flags select the settings applied by the edit-server, the site
+rules matching "--url", the profile named by "--profile", and the temporary file
+extension "--ext", informed by the file name when omitted.
+
+The exit code reflects the edit result:
+
+  0  changed
+  1  failed
+  %d  unchanged, the standard input is written back as is
+  %d  aborted
+
+`, AppName, ExitUnchanged, ExitAborted)
+
+// Cmd exposes the cobra command instance.
+func (e *Edit) Cmd() *cobra.Command {
+	return e.cmd
+}
+
+// preRunE loads the configuration file and validates the application address.
+func (e *Edit) preRunE(cmd *cobra.Command, _ []string) error {
+	if err := loadSettings(e.cfg, cmd); err != nil {
+		return err
+	}
+	return e.cfg.ValidateAddrFlag()
+}
+
+// request sends the payload to the edit endpoint, waiting for the editor, and
+// returns the edited text. The title names the file, empty for standard input.
+func (e *Edit) request(
+	logger *slog.Logger,
+	title string,
+	payload []byte,
+) ([]byte, error) {
+	req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(res)
+	req.SetRequestURI("http://" + e.cfg.Addr + service.RootPath)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	for header, value := range map[string]string{
+		service.URLHeader:     e.url,
+		service.TitleHeader:   title,
+		service.ExtHeader:     e.ext,
+		service.ProfileHeader: e.profile,
+	} {
+		if value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+	req.SetBody(payload)
+
+	logger.Info("waiting for the editor...")
+	// the editor takes as long as the user needs, thus without timeout
+	c := &fasthttp.HostClient{Addr: e.cfg.Addr}
+	if err := c.Do(req, res); err != nil {
+		return nil, err
+	}
+	result := string(res.Header.Peek(service.ResultHeader))
+	logger.Info("edit finished", "status", res.StatusCode(), "result", result)
+	message := strings.TrimSpace(string(res.Body()))
+	switch {
+	case result == service.ResultChanged || res.StatusCode() == http.StatusOK:
+		return append([]byte{}, res.Body()...), nil
+	case result == service.ResultUnchanged ||
+		res.StatusCode() == http.StatusNoContent:
+		return nil, &exitError{code: ExitUnchanged, err: ErrEditUnchanged}
+	case result == service.ResultAborted:
+		return nil, &exitError{
+			code: ExitAborted,
+			err:  fmt.Errorf("%w: %s", ErrEditAborted, message),
+		}
+	default:
+		return nil, fmt.Errorf("%w: %d %s", ErrEditFailed, res.StatusCode(), message)
+	}
+}
+
+// writeInPlace replaces the file content, writing a sibling file renamed over
+// the original, keeping its permissions. Symbolic links are followed.
+func writeInPlace(name string, payload []byte) error {
+	name, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	fh, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := fh.Name()
+	if _, err = fh.Write(payload); err != nil {
+		_ = fh.Close()
+		return errors.Join(err, os.Remove(tmp))
+	}
+	if err = fh.Chmod(info.Mode().Perm()); err != nil {
+		_ = fh.Close()
+		return errors.Join(err, os.Remove(tmp))
+	}
+	if err = fh.Close(); err != nil {
+		return errors.Join(err, os.Remove(tmp))
+	}
+	if err = os.Rename(tmp, name); err != nil {
+		return errors.Join(err, os.Remove(tmp))
+	}
+	return nil
+}
+
+// runE reads the file or the standard input, edits it, and writes the edited
+// text in place or on the standard output.
+func (e *Edit) runE(cmd *cobra.Command, args []string) error {
+	name := "-"
+	if len(args) > 0 {
+		name = args[0]
+	}
+	logger := e.cfg.LoggerWith(e.logger, config.AddrFlag).With("file", name)
+
+	var payload []byte
+	var err error
+	title := ""
+	if name == "-" {
+		payload, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		payload, err = os.ReadFile(name)
+		title = filepath.Base(name)
+		if e.ext == "" {
+			e.ext = filepath.Ext(name)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	edited, err := e.request(logger, title, payload)
+	if name == "-" {
+		// unchanged text is written back as is, keeping the pipelines intact
+		if errors.Is(err, ErrEditUnchanged) {
+			edited = payload
+		} else if err != nil {
+			return err
+		}
+		if _, werr := cmd.OutOrStdout().Write(edited); werr != nil {
+			return werr
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info("writing the edited text in place")
+	return writeInPlace(name, edited)
+}
+
+// NewEdit instantiates the "edit" subcommand and its flags.
+func NewEdit(logger *slog.Logger, cfg *config.Config) *Edit {
+	e := &Edit{
+		logger: logger,
+		cmd: &cobra.Command{
+			Use:          "edit [file|-]",
+			Short:        "Edits a file or the standard input on the edit-server",
+			Long:         editDesc,
+			Args:         cobra.MaximumNArgs(1),
+			SilenceUsage: true,
+		},
+		cfg: cfg,
+	}
+	e.cmd.PreRunE = e.preRunE
+	e.cmd.RunE = e.runE
+	e.cfg.AddAddrFlag(e.cmd.PersistentFlags())
+	f := e.cmd.Flags()
+	f.StringVar(&e.url, "url", "", "page URL metadata, selects the site rules")
+	f.StringVar(&e.ext, "ext", "",
+		"temporary file extension, i.e. \"md\", from the file name by default")
+	f.StringVar(&e.profile, "profile", "", "settings profile name")
+	return e
+}
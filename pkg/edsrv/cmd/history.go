@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/diff"
+	"github.com/otaviof/edsrv/pkg/edsrv/history"
+
+	"github.com/spf13/cobra"
+)
+
+// History represents the "history" subcommand, which inspects and restores the
+// recorded edits.
+type History struct {
+	logger *slog.Logger   // shared logger instance
+	cmd    *cobra.Command // cobra instance
+	cfg    *config.Config // flags for configuration
+	store  *history.Store // history store
+
+	output   string // restore output file
+	original bool   // restore the original text
+}
+
+var historyDesc = fmt.Sprintf(`# %s history
+
+Inspects and restores the edits recorded on the state directory, when the
+edit-server runs with "--%s".
+
+`, AppName, config.HistoryFlag)
+
+// Cmd exposes the cobra command instance.
+func (h *History) Cmd() *cobra.Command {
+	return h.cmd
+}
+
+// persistentPreRunE instantiates the history store, unlocking the encryption key
+// when configured.
+func (h *History) persistentPreRunE(_ *cobra.Command, _ []string) error {
+	if err := h.cfg.ValidateKeyFlags(); err != nil {
+		return err
+	}
+	c, err := openCipher(h.cfg)
+	if err != nil {
+		return err
+	}
+	h.store = history.NewStore(h.cfg.HistoryDir(), h.cfg.HistoryRetention(), c)
+	return nil
+}
+
+// list prints the recorded entries, newest first.
+func (h *History) list(cmd *cobra.Command, _ []string) error {
+	entries, err := h.store.List()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tFINISHED\tSTATUS\tRESULT\tURL\tTITLE")
+	for _, e := range entries {
+		if e.Err != nil {
+			h.logger.Warn("unable to read entry", "history", e.ID, "err", e.Err.Error())
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", e.ID,
+			e.Finished.Local().Format(time.DateTime), e.ExitStatus, e.Result,
+			e.URL, e.Title)
+	}
+	return w.Flush()
+}
+
+// show prints the entry metadata followed by the edited text.
+func (h *History) show(cmd *cobra.Command, args []string) error {
+	s := h.store
+	e, err := s.Get(args[0])
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 1, ' ', 0)
+	for _, kv := range [][2]string{
+		{"ID", e.ID},
+		{"URL", e.URL},
+		{"Title", e.Title},
+		{"Field", e.FieldID},
+		{"Editor", e.Editor},
+		{"Exit status", fmt.Sprint(e.ExitStatus)},
+		{"Result", e.Result},
+		{"Error", e.Error},
+		{"Started", e.Started.Local().Format(time.RFC3339)},
+		{"Finished", e.Finished.Local().Format(time.RFC3339)},
+	} {
+		if kv[1] != "" {
+			fmt.Fprintf(w, "%s:\t%s\n", kv[0], kv[1])
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	edited, err := s.Read(e.ID, history.Edited)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout())
+	_, err = cmd.OutOrStdout().Write(edited)
+	return err
+}
+
+// diff prints the unified diff between the original and edited texts.
+func (h *History) diff(cmd *cobra.Command, args []string) error {
+	s := h.store
+	original, err := s.Read(args[0], history.Original)
+	if err != nil {
+		return err
+	}
+	edited, err := s.Read(args[0], history.Edited)
+	if err != nil {
+		return err
+	}
+	_, err = cmd.OutOrStdout().Write(diff.Unified(
+		args[0]+"/"+history.Original, args[0]+"/"+history.Edited, original, edited,
+	))
+	return err
+}
+
+// restore writes the edited, or original, text to stdout or the output file.
+func (h *History) restore(cmd *cobra.Command, args []string) error {
+	name := history.Edited
+	if h.original {
+		name = history.Original
+	}
+	payload, err := h.store.Read(args[0], name)
+	if err != nil {
+		return err
+	}
+	if h.output == "" || h.output == "-" {
+		_, err = cmd.OutOrStdout().Write(payload)
+		return err
+	}
+	return os.WriteFile(h.output, payload, 0o600)
+}
+
+// prune removes the entries exceeding the retention limits.
+func (h *History) prune(_ *cobra.Command, _ []string) error {
+	logger := h.cfg.LoggerWith(h.logger, config.HistoryMaxAgeFlag,
+		config.HistoryMaxCountFlag, config.HistoryMaxSizeFlag)
+	removed, err := h.store.Prune()
+	if err != nil {
+		return err
+	}
+	logger.Info("history pruned", "removed", len(removed))
+	return nil
+}
+
+// NewHistory instantiates the "history" subcommand, its subcommands and flags.
+func NewHistory(logger *slog.Logger, cfg *config.Config) *History {
+	h := &History{
+		logger: logger,
+		cmd: &cobra.Command{
+			Use:          "history",
+			Short:        "Inspects and restores the recorded edits",
+			Long:         historyDesc,
+			SilenceUsage: true,
+		},
+		cfg: cfg,
+	}
+	h.cmd.PersistentPreRunE = h.persistentPreRunE
+	h.cfg.AddStateDirFlag(h.cmd.PersistentFlags())
+	h.cfg.AddKeyFlags(h.cmd.PersistentFlags())
+
+	h.cmd.AddCommand(&cobra.Command{
+		Use:          "list",
+		Short:        "Lists the recorded edits, newest first",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         h.list,
+	})
+	h.cmd.AddCommand(&cobra.Command{
+		Use:          "show ID",
+		Short:        "Shows the recorded edit details and text",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         h.show,
+	})
+	h.cmd.AddCommand(&cobra.Command{
+		Use:          "diff ID",
+		Short:        "Shows the difference between the original and edited text",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         h.diff,
+	})
+
+	restore := &cobra.Command{
+		Use:          "restore ID",
+		Short:        "Writes the edited text to stdout or a file",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         h.restore,
+	}
+	restore.Flags().StringVarP(&h.output, "output", "o", "",
+		"output file, stdout by default")
+	restore.Flags().BoolVar(&h.original, "original", false,
+		"restores the original text instead")
+	h.cmd.AddCommand(restore)
+
+	prune := &cobra.Command{
+		Use:          "prune",
+		Short:        "Removes the edits exceeding the retention limits",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         h.prune,
+	}
+	h.cfg.AddHistoryRetentionFlags(prune.Flags())
+	h.cmd.AddCommand(prune)
+	return h
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/history"
+
+	"github.com/spf13/cobra"
+)
+
+// Recover represents the "recover" subcommand, which handles the edits that
+// couldn't be delivered to the client.
+type Recover struct {
+	logger *slog.Logger   // shared logger instance
+	cmd    *cobra.Command // cobra instance
+	cfg    *config.Config // flags for configuration
+	store  *history.Store // recovery area store
+
+	output string // get output file
+}
+
+var recoverDesc = fmt.Sprintf(`# %s recover
+
+Handles the edits which couldn't be delivered, because the client went away
+while the editor was running (timeout, closed tab, etc).
+
+`, AppName)
+
+// Cmd exposes the cobra command instance.
+func (r *Recover) Cmd() *cobra.Command {
+	return r.cmd
+}
+
+// persistentPreRunE instantiates the recovery area store, unlocking the encryption key
+// when configured.
+func (r *Recover) persistentPreRunE(_ *cobra.Command, _ []string) error {
+	if err := r.cfg.ValidateKeyFlags(); err != nil {
+		return err
+	}
+	c, err := openCipher(r.cfg)
+	if err != nil {
+		return err
+	}
+	r.store = history.NewStore(r.cfg.RecoverDir(), r.cfg.RecoverRetention(), c)
+	return nil
+}
+
+// list prints the undelivered edits, newest first.
+func (r *Recover) list(cmd *cobra.Command, _ []string) error {
+	entries, err := r.store.List()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tFINISHED\tURL\tTITLE")
+	for _, e := range entries {
+		if e.Err != nil {
+			r.logger.Warn("unable to read entry", "recover", e.ID, "err", e.Err.Error())
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.ID,
+			e.Finished.Local().Format(time.DateTime), e.URL, e.Title)
+	}
+	return w.Flush()
+}
+
+// get writes the undelivered text to stdout or the output file.
+func (r *Recover) get(cmd *cobra.Command, args []string) error {
+	payload, err := r.store.Read(args[0], history.Edited)
+	if err != nil {
+		return err
+	}
+	if r.output == "" || r.output == "-" {
+		_, err = cmd.OutOrStdout().Write(payload)
+		return err
+	}
+	return os.WriteFile(r.output, payload, 0o600)
+}
+
+// discard removes the undelivered edits.
+func (r *Recover) discard(_ *cobra.Command, args []string) error {
+	s := r.store
+	for _, id := range args {
+		if err := s.Remove(id); err != nil {
+			return err
+		}
+		r.logger.Info("undelivered edit discarded", "recover", id)
+	}
+	return nil
+}
+
+// NewRecover instantiates the "recover" subcommand, its subcommands and flags.
+func NewRecover(logger *slog.Logger, cfg *config.Config) *Recover {
+	r := &Recover{
+		logger: logger,
+		cmd: &cobra.Command{
+			Use:          "recover",
+			Short:        "Handles the edits which couldn't be delivered",
+			Long:         recoverDesc,
+			SilenceUsage: true,
+		},
+		cfg: cfg,
+	}
+	r.cmd.PersistentPreRunE = r.persistentPreRunE
+	r.cfg.AddStateDirFlag(r.cmd.PersistentFlags())
+	r.cfg.AddKeyFlags(r.cmd.PersistentFlags())
+
+	r.cmd.AddCommand(&cobra.Command{
+		Use:          "list",
+		Short:        "Lists the undelivered edits, newest first",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         r.list,
+	})
+
+	get := &cobra.Command{
+		Use:          "get ID",
+		Short:        "Writes the undelivered text to stdout or a file",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         r.get,
+	}
+	get.Flags().StringVarP(&r.output, "output", "o", "",
+		"output file, stdout by default")
+	r.cmd.AddCommand(get)
+
+	r.cmd.AddCommand(&cobra.Command{
+		Use:          "discard ID...",
+		Short:        "Discards the undelivered edits",
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE:         r.discard,
+	})
+	return r
+}
@@ -6,14 +6,16 @@ import (
 	"os"
 
 	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/logging"
 
 	"github.com/spf13/cobra"
 )
 
 // Root represents the primary application command.
 type Root struct {
-	cmd *cobra.Command // cobra instance
-	cfg *config.Config // shared configuration
+	cmd  *cobra.Command // cobra instance
+	cfg  *config.Config // shared configuration
+	sink *logging.Sink  // shared logging output
 }
 
 // AppName application name.
@@ -26,13 +28,47 @@ using a external text editor on a regular webpage.
 
 `, AppName)
 
+// stdoutLogsAnnotation subcommand annotation to log on standard output, the
+// other subcommands log on standard error keeping the standard output for their
+// data.
+const stdoutLogsAnnotation = "edsrv/stdout-logs"
+
+// persistentPreRunE configures the logging output, shared by all subcommands.
+func (r *Root) persistentPreRunE(cmd *cobra.Command, _ []string) error {
+	if err := r.cfg.ValidateLogFlags(); err != nil {
+		return err
+	}
+	opts := r.cfg.LogOptions(AppName)
+	_, stdout := cmd.Annotations[stdoutLogsAnnotation]
+	opts.Stderr = !stdout
+	h, closer, err := logging.NewHandler(opts)
+	if err != nil {
+		return err
+	}
+	return r.sink.Set(h, closer)
+}
+
+// persistentPostRunE closes the logging output.
+func (r *Root) persistentPostRunE(_ *cobra.Command, _ []string) error {
+	return r.sink.Close()
+}
+
 // Cmd shares the cobra.Command instance decorated with subcommands.
 func (r *Root) Cmd() *cobra.Command {
-	logOpts := &slog.HandlerOptions{Level: r.cfg.LogLevel}
-	logger := slog.New(slog.NewTextHandler(os.Stdout, logOpts))
+	logger := slog.New(r.sink.Handler())
 
 	r.cmd.AddCommand(NewStart(logger, r.cfg).Cmd())
 	r.cmd.AddCommand(NewStatus(logger, r.cfg).Cmd())
+	r.cmd.AddCommand(NewEdit(logger, r.cfg).Cmd())
+	r.cmd.AddCommand(NewTemplates(logger, r.cfg).Cmd())
+	r.cmd.AddCommand(NewHistory(logger, r.cfg).Cmd())
+	r.cmd.AddCommand(NewRecover(logger, r.cfg).Cmd())
+	r.cmd.AddCommand(NewAudit(logger, r.cfg).Cmd())
+	r.cmd.AddCommand(NewStore(logger, r.cfg).Cmd())
+	r.cmd.AddCommand(NewDoctor(logger, r.cfg).Cmd())
+	r.cmd.AddCommand(NewConfig(logger, r.cfg).Cmd())
+	r.cmd.AddCommand(NewService(logger, r.cfg).Cmd())
+	r.cmd.AddCommand(NewVersion(logger, r.cfg).Cmd())
 
 	return r.cmd
 }
@@ -48,6 +84,15 @@ func NewRoot() *Root {
 		},
 		cfg: config.NewConfig(),
 	}
+	// the logging output is configured once the flags are parsed, the loggers
+	// instantiated before write on the shared sink
+	r.sink = logging.NewSink(logging.NewFormatHandler(
+		os.Stdout, r.cfg.LogFormat, r.cfg.LogLevel))
+	cobra.EnableTraverseRunHooks = true
+	r.cmd.PersistentPreRunE = r.persistentPreRunE
+	r.cmd.PersistentPostRunE = r.persistentPostRunE
 	r.cfg.AddLogLevelFlag(r.cmd.PersistentFlags())
+	r.cfg.AddLogFlags(r.cmd.PersistentFlags())
+	r.cfg.AddConfigFlag(r.cmd.PersistentFlags())
 	return r
 }
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/daemon"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Service represents the "service" subcommand, which installs edsrv as a user
+// service on systemd or launchd.
+type Service struct {
+	logger *slog.Logger   // shared logger instance
+	cmd    *cobra.Command // cobra instance
+	cfg    *config.Config // flags for configuration
+
+	platform string // service manager platform, linux or darwin
+	socket   bool   // systemd socket activation
+	print    bool   // prints the files instead of installing
+}
+
+// ErrServiceNotInstalled the service definition files are not found.
+var ErrServiceNotInstalled = errors.New("service is not installed")
+
+var serviceDesc = fmt.Sprintf(`# %s service
+
+Installs %s as a user service, a systemd user unit on Linux, optionally started
+by a socket unit on the first connection ("--socket"), or a launchd agent on
+macOS.
+
+The service runs "%s start" with the flags informed, and the values based on
+environment variables ($EDITOR, $TMPDIR, etc), the configuration file is read by
+the service itself. Use "--print" to print the files instead of installing, and
+"--platform" to render the files for another platform.
+
+`, AppName, AppName, AppName)
+
+// Cmd exposes the cobra command instance.
+func (s *Service) Cmd() *cobra.Command {
+	return s.cmd
+}
+
+// layout returns the service definitions layout and the installed files.
+func (s *Service) layout() (*daemon.Layout, []string, error) {
+	l, err := daemon.DefaultLayout(s.platform)
+	if err != nil {
+		return nil, nil, err
+	}
+	installed, err := daemon.Installed(s.platform, l)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, installed, nil
+}
+
+// args returns the "start" subcommand arguments for the service, the flags
+// informed on the command-line and the values based on environment variables.
+func (s *Service) args() []string {
+	fs := pflag.NewFlagSet("service", pflag.ContinueOnError)
+	fs.AddFlagSet(s.cmd.Root().PersistentFlags())
+	fs.AddFlagSet(s.cmd.PersistentFlags())
+
+	args := []string{"start"}
+	fs.VisitAll(func(f *pflag.Flag) {
+		switch s.cfg.Source(f) {
+		case config.SourceFlag, config.SourceEnv:
+		default:
+			return
+		}
+		values := []string{f.Value.String()}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			values = sv.GetSlice()
+		}
+		for _, v := range values {
+			if v != "" {
+				args = append(args, fmt.Sprintf("--%s=%s", f.Name, v))
+			}
+		}
+	})
+	return args
+}
+
+// definition returns the service definition for the current configuration.
+func (s *Service) definition() (*daemon.Definition, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return nil, err
+	}
+	d := &daemon.Definition{
+		Executable: executable,
+		Args:       s.args(),
+		Env:        []daemon.EnvVar{},
+		Addr:       s.cfg.Addr,
+		Socket:     s.socket,
+	}
+	// the editor is searched on the current PATH
+	if path := os.Getenv("PATH"); path != "" {
+		d.Env = append(d.Env, daemon.EnvVar{Name: "PATH", Value: path})
+	}
+	// systemd user services run without TMPDIR, the runtime directory is private
+	if s.platform == daemon.PlatformLinux && s.cfg.TmpDir == "" {
+		d.Env = append(d.Env,
+			daemon.EnvVar{Name: "TMPDIR", Value: daemon.SystemdRuntimeDir})
+	}
+	return d, nil
+}
+
+// run runs the service manager commands, printing their output.
+func run(cmd *cobra.Command, commands [][]string) error {
+	for _, command := range commands {
+		fmt.Fprintf(cmd.ErrOrStderr(), "$ %s\n", strings.Join(command, " "))
+		c := exec.Command(command[0], command[1:]...)
+		c.Stdout = cmd.OutOrStdout()
+		c.Stderr = cmd.ErrOrStderr()
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("%s: %w", command[0], err)
+		}
+	}
+	return nil
+}
+
+// install renders the service definition files, and either prints them or
+// installs and starts the service.
+func (s *Service) install(cmd *cobra.Command, _ []string) error {
+	if err := s.cfg.ValidateAddrFlag(); err != nil {
+		return err
+	}
+	l, err := daemon.DefaultLayout(s.platform)
+	if err != nil {
+		return err
+	}
+	d, err := s.definition()
+	if err != nil {
+		return err
+	}
+	files, err := daemon.Render(s.platform, l, d)
+	if err != nil {
+		return err
+	}
+	if s.print {
+		for _, f := range files {
+			fmt.Fprintf(cmd.OutOrStdout(), "# %s\n%s\n", f.Path, f.Content)
+		}
+		return nil
+	}
+
+	logger := s.cfg.LoggerWith(s.logger, config.AddrFlag).With("platform", s.platform)
+	if err = daemon.Install(files); err != nil {
+		return err
+	}
+	paths := []string{}
+	for _, f := range files {
+		logger.Info("service definition written", "path", f.Path)
+		paths = append(paths, f.Path)
+	}
+	commands, err := daemon.ManagerCommands(s.platform, paths)
+	if err != nil {
+		return err
+	}
+	return run(cmd, commands.Install)
+}
+
+// uninstall stops the service and removes the service definition files.
+func (s *Service) uninstall(cmd *cobra.Command, _ []string) error {
+	_, installed, err := s.layout()
+	if err != nil {
+		return err
+	}
+	if len(installed) == 0 {
+		return ErrServiceNotInstalled
+	}
+	commands, err := daemon.ManagerCommands(s.platform, installed)
+	if err != nil {
+		return err
+	}
+	logger := s.logger.With("platform", s.platform)
+	// the files are removed even when the service is not running
+	if err = run(cmd, commands.Uninstall); err != nil {
+		logger.Warn("unable to stop the service", "err", err.Error())
+	}
+	for _, p := range installed {
+		if err = os.Remove(p); err != nil {
+			return err
+		}
+		logger.Info("service definition removed", "path", p)
+	}
+	return run(cmd, commands.Cleanup)
+}
+
+// status prints the installed service definition files and the service manager
+// status.
+func (s *Service) status(cmd *cobra.Command, _ []string) error {
+	_, installed, err := s.layout()
+	if err != nil {
+		return err
+	}
+	if len(installed) == 0 {
+		return ErrServiceNotInstalled
+	}
+	for _, p := range installed {
+		fmt.Fprintf(cmd.OutOrStdout(), "Installed: %s\n", p)
+	}
+	commands, err := daemon.ManagerCommands(s.platform, installed)
+	if err != nil {
+		return err
+	}
+	return run(cmd, commands.Status)
+}
+
+// NewService instantiates the "service" subcommand and its flags.
+func NewService(logger *slog.Logger, cfg *config.Config) *Service {
+	s := &Service{
+		logger: logger,
+		cmd: &cobra.Command{
+			Use:          "service",
+			Short:        "Installs the edit-server as a user service",
+			Long:         serviceDesc,
+			SilenceUsage: true,
+		},
+		cfg: cfg,
+	}
+	s.cfg.AddStartFlags(s.cmd.PersistentFlags())
+
+	install := &cobra.Command{
+		Use:          "install",
+		Short:        "Installs and starts the service",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         s.install,
+	}
+	install.Flags().BoolVar(&s.socket, "socket", false,
+		"starts the service on the first connection, systemd socket unit")
+	install.Flags().BoolVar(&s.print, "print", false,
+		"prints the service definition files instead of installing")
+	s.cmd.AddCommand(install)
+
+	s.cmd.AddCommand(&cobra.Command{
+		Use:          "uninstall",
+		Short:        "Stops the service and removes its definition files",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         s.uninstall,
+	})
+	s.cmd.AddCommand(&cobra.Command{
+		Use:          "status",
+		Short:        "Shows the installed files and the service status",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         s.status,
+	})
+	// the platform is not a "start" flag, thus not informed to the service
+	for _, sub := range s.cmd.Commands() {
+		sub.Flags().StringVar(&s.platform, "platform", runtime.GOOS,
+			fmt.Sprintf("service manager platform, %q (systemd) or %q (launchd)",
+				daemon.PlatformLinux, daemon.PlatformDarwin))
+	}
+	return s
+}
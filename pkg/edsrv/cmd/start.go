@@ -1,15 +1,21 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/daemon"
 	"github.com/otaviof/edsrv/pkg/edsrv/editor"
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
 	"github.com/otaviof/edsrv/pkg/edsrv/service"
+	"github.com/otaviof/edsrv/pkg/edsrv/tracing"
 
 	"github.com/spf13/cobra"
-	"github.com/valyala/fasthttp"
 )
 
 // Start represents the "start" subcommand, which starts the application API
@@ -31,22 +37,136 @@ func (s *Start) Cmd() *cobra.Command {
 	return s.cmd
 }
 
-// preRunE Validates the informed configuration.
-func (s *Start) preRunE(_ *cobra.Command, _ []string) error {
+// preRunE loads the configuration file and validates the informed configuration.
+func (s *Start) preRunE(cmd *cobra.Command, _ []string) error {
+	if err := loadSettings(s.cfg, cmd); err != nil {
+		return err
+	}
 	return s.cfg.ValidateStartFlags()
 }
 
 // runE runs the API backend using the configuration informed via flags.
 func (s *Start) runE(_ *cobra.Command, _ []string) error {
 	logger := s.cfg.LoggerWith(
-		s.logger, config.AddrFlag, config.EditorFlag, config.TmpDirFlag,
+		s.logger,
+		config.AddrFlag,
+		config.MetricsAddrFlag,
+		config.OTLPEndpointFlag,
+		config.MaxBodySizeFlag,
+		config.MaxHeaderSizeFlag,
+		config.ReadTimeoutFlag,
+		config.WriteTimeoutFlag,
+		config.IdleTimeoutFlag,
+		config.EditorFlag,
+		config.TmpDirFlag,
+		config.ShredFlag,
+		config.TerminalFlag,
+		config.TmuxFlag,
+		config.TmuxTargetFlag,
+		config.LineEndingFlag,
+		config.NFCFlag,
+		config.ConfigFlag,
+		config.TrailingNewlineFlag,
+		config.TrimTrailingWhitespaceFlag,
+		config.PreFilterFlag,
+		config.PostFilterFlag,
+		config.FilterTimeoutFlag,
+		config.TemplatesDirFlag,
+		config.StateDirFlag,
+		config.HistoryFlag,
+		config.AuditFlag,
+		config.RecoverHookFlag,
+		config.RecoverEndpointFlag,
+		config.AllowedOriginFlag,
+		config.KeyFileFlag,
+		config.KeyCommandFlag,
 	)
 
-	ed := editor.NewEditor(logger, s.cfg.Editor, s.cfg.TmpDir)
-	srv := service.NewService(logger, ed)
+	// temporary files are kept on a private subdirectory, removed on shutdown
+	tmpDir, err := file.NewPrivateDir(s.cfg.TmpDir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	logger = logger.With("private-dir", tmpDir)
 
-	logger.Debug("starting edit-server...")
-	return fasthttp.ListenAndServe(s.cfg.Addr, srv.RequestHandler())
+	var ed editor.Interface
+	if s.cfg.Nvim {
+		logger = s.cfg.LoggerWith(
+			logger, config.NvimListenFlag, config.NvimOpenFlag,
+		)
+		ed = editor.NewNeovim(
+			logger, s.cfg.NvimListen, s.cfg.NvimOpen, tmpDir, s.cfg.Shred,
+		)
+	} else {
+		launcher := editor.NewLauncher(
+			s.cfg.Terminal, s.cfg.Tmux, s.cfg.TmuxTarget, tmpDir,
+		)
+		e := editor.NewEditor(
+			logger, launcher, s.cfg.EditorCandidates(), tmpDir, s.cfg.Shred,
+		)
+		if err = e.Resolve(); err != nil {
+			return err
+		}
+		ed = e
+	}
+	// the stored content can't be re-encrypted while the server is running
+	l, err := lockState(logger, s.cfg, false)
+	if err != nil {
+		return err
+	}
+	defer l.Unlock()
+	c, err := openCipher(s.cfg)
+	if err != nil {
+		return err
+	}
+	srv := service.NewService(logger, s.cfg, ed, c)
+
+	// the request traces are exported on the background, flushed on shutdown
+	if s.cfg.OTLPEndpoint != "" {
+		url, err := s.cfg.TracesURL()
+		if err != nil {
+			return err
+		}
+		exporter := tracing.NewOTLPExporter(logger, url,
+			tracing.Attr{Key: "service.name", Value: AppName})
+		defer exporter.Shutdown()
+		srv.WithTracer(tracing.NewTracer(exporter))
+	}
+	server := srv.Server()
+
+	ctx, stop := signal.NotifyContext(context.Background(),
+		os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// the metrics are served on a separate address, optional
+	if s.cfg.MetricsAddr != "" {
+		metricsServer := srv.MetricsServer()
+		go func() {
+			logger.Debug("starting metrics server...")
+			if err := metricsServer.ListenAndServe(s.cfg.MetricsAddr); err != nil {
+				logger.Error("metrics server failed", "err", err.Error())
+				stop()
+			}
+		}()
+		defer func() {
+			_ = metricsServer.Shutdown()
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+		logger.Debug("shutting down edit-server...")
+		_ = server.Shutdown()
+	}()
+
+	// the listener is passed by systemd, when started by the socket unit
+	ln, err := daemon.Listener(s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	logger.Debug("starting edit-server...", "listener", ln.Addr().String())
+	return server.Serve(ln)
 }
 
 // NewStart instantiates "start" subcommand and its flags.
@@ -58,6 +178,7 @@ func NewStart(logger *slog.Logger, cfg *config.Config) *Start {
 			Short:        "Starts the edit-server API backend service",
 			Long:         startDesc,
 			SilenceUsage: true,
+			Annotations:  map[string]string{stdoutLogsAnnotation: ""},
 		},
 		cfg: cfg,
 	}
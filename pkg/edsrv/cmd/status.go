@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/otaviof/edsrv/pkg/edsrv/config"
 	"github.com/otaviof/edsrv/pkg/edsrv/service"
@@ -17,11 +22,30 @@ type Status struct {
 	logger *slog.Logger   // shared logger instance
 	cmd    *cobra.Command // cobra instance
 	cfg    *config.Config // flags for configuration
+
+	output string // output format, json or text
+}
+
+const (
+	// outputJSON JSON output format.
+	outputJSON = "json"
+	// outputText human readable output format.
+	outputText = "text"
+)
+
+// validateOutput validates the output format flag.
+func validateOutput(output string) error {
+	if output != outputJSON && output != outputText {
+		return fmt.Errorf("%w: invalid --output %q, expected %q or %q",
+			config.ErrInvalidConfig, output, outputJSON, outputText)
+	}
+	return nil
 }
 
 var statusDesc = fmt.Sprintf(`# %s status
 
-Makes a noop request to assert edit-server status.
+Makes a noop request to assert edit-server status, and shows the running
+version, uptime, editor and enabled features.
 
 `, AppName)
 
@@ -30,24 +54,62 @@ func (s *Status) Cmd() *cobra.Command {
 	return s.cmd
 }
 
-// preRunE validates the application address.
-func (s *Status) preRunE(_ *cobra.Command, _ []string) error {
-	return s.cfg.ValidateAddrFlag()
+// preRunE loads the configuration file, and validates the application address and
+// output format.
+func (s *Status) preRunE(cmd *cobra.Command, _ []string) error {
+	if err := loadSettings(s.cfg, cmd); err != nil {
+		return err
+	}
+	if err := s.cfg.ValidateAddrFlag(); err != nil {
+		return err
+	}
+	return validateOutput(s.output)
 }
 
-// runE executes the request against the application status endpoint.
-func (s *Status) runE(_ *cobra.Command, _ []string) error {
+// runE executes the request against the application status endpoint, and prints
+// the status on the output format.
+func (s *Status) runE(cmd *cobra.Command, _ []string) error {
 	logger := s.cfg.LoggerWith(s.logger, config.AddrFlag)
 
 	c := &fasthttp.HostClient{Addr: s.cfg.Addr}
-	resBody, err := service.StatusRequest(logger, c)
+	resBody, err := service.StatusRequest(logger, c, "application/json")
 	if err != nil {
 		return err
 	}
-
-	logger.Info(string(resBody))
 	logger.Info("edit-server is healthy!")
-	return nil
+
+	if s.output == outputJSON {
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), string(resBody))
+		return err
+	}
+	st := &service.StatusResponse{}
+	if err = json.Unmarshal(resBody, st); err != nil {
+		return err
+	}
+	features := []string{}
+	for name, enabled := range st.Features {
+		if enabled {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Version:\t%s\n", st.Version)
+	fmt.Fprintf(w, "Started:\t%s\n", st.Started.Local().Format(time.DateTime))
+	fmt.Fprintf(w, "Uptime:\t%s\n", st.Uptime)
+	fmt.Fprintf(w, "Editor:\t%s\n", st.Editor.Command)
+	if st.Editor.Path != "" {
+		fmt.Fprintf(w, "Editor Path:\t%s\n", st.Editor.Path)
+	}
+	fmt.Fprintf(w, "Temporary Directory:\t%s\n", st.TmpDir)
+	fmt.Fprintf(w, "Listen Address:\t%s\n", st.Listeners.Addr)
+	if st.Listeners.MetricsAddr != "" {
+		fmt.Fprintf(w, "Metrics Address:\t%s\n", st.Listeners.MetricsAddr)
+	}
+	fmt.Fprintf(w, "Active Sessions:\t%d\n", st.ActiveSessions)
+	fmt.Fprintf(w, "Features:\t%s\n", strings.Join(features, ", "))
+	return w.Flush()
 }
 
 // NewStatus instantiates the "status" subcommand and its flags.
@@ -65,5 +127,7 @@ func NewStatus(logger *slog.Logger, cfg *config.Config) *Status {
 	s.cmd.PreRunE = s.preRunE
 	s.cmd.RunE = s.runE
 	s.cfg.AddAddrFlag(s.cmd.PersistentFlags())
+	s.cmd.Flags().StringVarP(&s.output, "output", "o", outputText,
+		"output format, json or text")
 	return s
 }
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/crypt"
+	"github.com/otaviof/edsrv/pkg/edsrv/history"
+
+	"github.com/spf13/cobra"
+)
+
+// Store represents the "store" subcommand, which manages the encryption of the
+// content stored past the end of a edit session, history and recovery.
+type Store struct {
+	logger *slog.Logger   // shared logger instance
+	cmd    *cobra.Command // cobra instance
+	cfg    *config.Config // flags for configuration
+
+	newKeyFile    string // new encryption secret file
+	newKeyCommand string // new encryption secret command
+	decrypt       bool   // removes the encryption
+}
+
+var storeDesc = fmt.Sprintf(`# %s store
+
+Manages the encryption of the content stored on the state directory, the edit
+history and the undelivered edits. The content is encrypted with AES-256-GCM, the
+key is derived (scrypt) from the secret informed by "--%s" or "--%s".
+
+Content stored before the key is configured stays in plain text, and can't be
+read with the key. Run "rekey" with the same secret as "--new-key-file" to encrypt
+it, "verify" reports the plain text files.
+
+`, AppName, config.KeyFileFlag, config.KeyCommandFlag)
+
+// Cmd exposes the cobra command instance.
+func (s *Store) Cmd() *cobra.Command {
+	return s.cmd
+}
+
+// openCipher unlocks the cipher using the secret informed by the key flags,
+// returns nil when encryption is not configured. Encrypted content requires the
+// secret.
+func openCipher(cfg *config.Config) (*crypt.Cipher, error) {
+	if cfg.KeyFile == "" && cfg.KeyCommand == "" {
+		params, err := crypt.LoadParams(cfg.KeyParamsFile())
+		if err != nil {
+			return nil, err
+		}
+		if params != nil {
+			return nil, fmt.Errorf("%w: the stored content is encrypted, inform "+
+				"%q or %q", crypt.ErrSecret, config.KeyFileFlag, config.KeyCommandFlag)
+		}
+		return nil, nil //nolint:nilnil
+	}
+	secret, err := crypt.ReadSecret(cfg.KeyFile, cfg.KeyCommand)
+	if err != nil {
+		return nil, err
+	}
+	return crypt.Open(cfg.KeyParamsFile(), secret)
+}
+
+// stores instantiates the history and recovery stores with the cipher.
+func stores(cfg *config.Config, c *crypt.Cipher) []*history.Store {
+	return []*history.Store{
+		history.NewStore(cfg.HistoryDir(), cfg.HistoryRetention(), c),
+		history.NewStore(cfg.RecoverDir(), cfg.RecoverRetention(), c),
+	}
+}
+
+// lockState locks the state directory, and finishes the interrupted
+// re-encryption. The server holds the shared lock, the re-encryption the
+// exclusive lock.
+func lockState(
+	logger *slog.Logger,
+	cfg *config.Config,
+	exclusive bool,
+) (*history.Lock, error) {
+	if !exclusive {
+		// the shared lock is taken directly, flock doesn't convert locks
+		// atomically; the pending swap is checked holding it
+		l, err := history.NewLock(cfg.LockFile(), false)
+		if err != nil || !history.Pending(cfg.StateDir) {
+			return l, err
+		}
+		_ = l.Unlock()
+	}
+	// the swap is only resumed holding the exclusive lock
+	l, err := history.NewLock(cfg.LockFile(), true)
+	if err != nil {
+		return nil, err
+	}
+	resumed, err := history.Resume(cfg.StateDir)
+	if err != nil {
+		_ = l.Unlock()
+		return nil, err
+	}
+	if resumed {
+		logger.Warn("interrupted re-encryption finished", "dir", cfg.StateDir)
+	}
+	if exclusive {
+		return l, nil
+	}
+	if err = l.Unlock(); err != nil {
+		return nil, err
+	}
+	return history.NewLock(cfg.LockFile(), false)
+}
+
+// rekey re-encrypts the stored content with the new secret, or decrypts it. The
+// stores and key parameters are staged first, and swapped in together.
+func (s *Store) rekey(_ *cobra.Command, _ []string) error {
+	if err := s.cfg.ValidateKeyFlags(); err != nil {
+		return err
+	}
+	// a running server keeps using the current key, thus it must be stopped
+	l, err := lockState(s.logger, s.cfg, true)
+	if err != nil {
+		return err
+	}
+	defer l.Unlock()
+
+	current, err := openCipher(s.cfg)
+	if err != nil {
+		return err
+	}
+	var params *crypt.Params
+	var next *crypt.Cipher
+	if !s.decrypt {
+		secret, err := crypt.ReadSecret(s.newKeyFile, s.newKeyCommand)
+		if err != nil {
+			return err
+		}
+		if params, next, err = crypt.NewParams(secret); err != nil {
+			return err
+		}
+	}
+
+	swap := history.NewSwap(s.cfg.StateDir)
+	if err = s.stage(swap, current, params, next); err != nil {
+		swap.Discard()
+		return err
+	}
+	if err = swap.Commit(); err != nil {
+		return err
+	}
+	s.logger.Info("stored content re-encrypted", "encrypted", !s.decrypt)
+	return nil
+}
+
+// stage writes the stores encrypted with the next cipher, and the new key
+// parameters, on the swap staging area.
+func (s *Store) stage(
+	swap *history.Swap,
+	current *crypt.Cipher,
+	params *crypt.Params,
+	next *crypt.Cipher,
+) error {
+	for _, st := range stores(s.cfg, current) {
+		staged, err := swap.Stage(st.GetDir())
+		if err != nil {
+			return err
+		}
+		if err = st.Rekey(next, staged); err != nil {
+			return fmt.Errorf("%q: %w", st.GetDir(), err)
+		}
+	}
+	if params == nil {
+		swap.Remove(s.cfg.KeyParamsFile())
+		return nil
+	}
+	staged, err := swap.Stage(s.cfg.KeyParamsFile())
+	if err != nil {
+		return err
+	}
+	return params.Save(staged)
+}
+
+// verify decrypts and authenticates every stored file.
+func (s *Store) verify(_ *cobra.Command, _ []string) error {
+	c, err := openCipher(s.cfg)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, st := range stores(s.cfg, c) {
+		verified, errs := st.Verify()
+		for _, err := range errs {
+			s.logger.Error("verification failed", "dir", st.GetDir(), "err", err.Error())
+		}
+		failed += len(errs)
+		s.logger.Info("store verified", "dir", st.GetDir(),
+			"files", verified, "failed", len(errs), "encrypted", c != nil)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d files failed verification", crypt.ErrCorrupted, failed)
+	}
+	return nil
+}
+
+// NewStore instantiates the "store" subcommand, its subcommands and flags.
+func NewStore(logger *slog.Logger, cfg *config.Config) *Store {
+	s := &Store{
+		logger: logger,
+		cmd: &cobra.Command{
+			Use:          "store",
+			Short:        "Manages the encryption of the stored content",
+			Long:         storeDesc,
+			SilenceUsage: true,
+		},
+		cfg: cfg,
+	}
+	s.cfg.AddStateDirFlag(s.cmd.PersistentFlags())
+	s.cfg.AddKeyFlags(s.cmd.PersistentFlags())
+
+	rekey := &cobra.Command{
+		Use:          "rekey",
+		Short:        "Re-encrypts the stored content with a new secret",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         s.rekey,
+	}
+	f := rekey.Flags()
+	f.StringVar(&s.newKeyFile, "new-key-file", "", "new secret file")
+	f.StringVar(&s.newKeyCommand, "new-key-command", "",
+		"new secret keyring helper command")
+	f.BoolVar(&s.decrypt, "decrypt", false,
+		"removes the encryption, storing the content in plain text")
+	rekey.MarkFlagsMutuallyExclusive("new-key-file", "new-key-command", "decrypt")
+	rekey.MarkFlagsOneRequired("new-key-file", "new-key-command", "decrypt")
+	s.cmd.AddCommand(rekey)
+
+	s.cmd.AddCommand(&cobra.Command{
+		Use:          "verify",
+		Short:        "Verifies the integrity of the stored content",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         s.verify,
+	})
+	return s
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/templates"
+
+	"github.com/spf13/cobra"
+)
+
+// Templates represents the "templates" subcommand, which lists and renders the
+// templates used for empty fields.
+type Templates struct {
+	logger *slog.Logger   // shared logger instance
+	cmd    *cobra.Command // cobra instance
+	cfg    *config.Config // flags for configuration
+
+	url   string // page URL variable
+	title string // page title variable
+	id    string // field ID variable
+}
+
+var templatesDesc = fmt.Sprintf(`# %s templates
+
+Lists and renders the templates for empty fields, Go "text/template" files with
+the %q extension stored on the templates directory.
+
+`, AppName, templates.Extension)
+
+// Cmd exposes the cobra command instance.
+func (t *Templates) Cmd() *cobra.Command {
+	return t.cmd
+}
+
+// list prints the template names.
+func (t *Templates) list(cmd *cobra.Command, _ []string) error {
+	names, err := templates.NewStore(t.cfg.TemplatesDir).List()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		fmt.Fprintln(cmd.OutOrStdout(), name)
+	}
+	return nil
+}
+
+// render prints the template rendered with the informed variables.
+func (t *Templates) render(cmd *cobra.Command, args []string) error {
+	vars := templates.NewVars(t.url, t.title, t.id)
+	payload, err := templates.NewStore(t.cfg.TemplatesDir).Render(args[0], vars)
+	if err != nil {
+		return err
+	}
+	_, err = cmd.OutOrStdout().Write(payload)
+	return err
+}
+
+// NewTemplates instantiates the "templates" subcommand, its "list" and "render"
+// subcommands and flags.
+func NewTemplates(logger *slog.Logger, cfg *config.Config) *Templates {
+	t := &Templates{
+		logger: logger,
+		cmd: &cobra.Command{
+			Use:          "templates",
+			Short:        "Lists and renders the templates for empty fields",
+			Long:         templatesDesc,
+			SilenceUsage: true,
+		},
+		cfg: cfg,
+	}
+	t.cfg.AddTemplatesDirFlag(t.cmd.PersistentFlags())
+
+	t.cmd.AddCommand(&cobra.Command{
+		Use:          "list",
+		Short:        "Lists the template names",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         t.list,
+	})
+
+	render := &cobra.Command{
+		Use:          "render NAME",
+		Short:        "Renders the template with the informed variables",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         t.render,
+	}
+	f := render.Flags()
+	f.StringVar(&t.url, "url", "", "page URL variable")
+	f.StringVar(&t.title, "title", "", "page title variable")
+	f.StringVar(&t.id, "id", "", "field ID variable")
+	t.cmd.AddCommand(render)
+	return t
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/version"
+
+	"github.com/spf13/cobra"
+)
+
+// Version represents the "version" subcommand, which shows the build
+// information.
+type Version struct {
+	logger *slog.Logger   // shared logger instance
+	cmd    *cobra.Command // cobra instance
+	cfg    *config.Config // flags for configuration
+
+	output string // output format, json or text
+}
+
+var versionDesc = fmt.Sprintf(`# %s version
+
+Shows the version, source commit and build date, and the go toolchain.
+
+`, AppName)
+
+// Cmd exposes the cobra command instance.
+func (v *Version) Cmd() *cobra.Command {
+	return v.cmd
+}
+
+// preRunE validates the output format.
+func (v *Version) preRunE(_ *cobra.Command, _ []string) error {
+	return validateOutput(v.output)
+}
+
+// runE prints the build information on the output format.
+func (v *Version) runE(cmd *cobra.Command, _ []string) error {
+	info := version.Get()
+	if v.output == outputJSON {
+		return json.NewEncoder(cmd.OutOrStdout()).Encode(info)
+	}
+	_, err := fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", AppName, info)
+	return err
+}
+
+// NewVersion instantiates the "version" subcommand and its flags.
+func NewVersion(logger *slog.Logger, cfg *config.Config) *Version {
+	v := &Version{
+		logger: logger,
+		cmd: &cobra.Command{
+			Use:          "version",
+			Short:        "Shows the version and build information",
+			Long:         versionDesc,
+			Args:         cobra.NoArgs,
+			SilenceUsage: true,
+		},
+		cfg: cfg,
+	}
+	v.cmd.PreRunE = v.preRunE
+	v.cmd.RunE = v.runE
+	v.cmd.Flags().StringVarP(&v.output, "output", "o", outputText,
+		"output format, json or text")
+	return v
+}
@@ -0,0 +1,193 @@
+package codec
+
+import (
+	"bytes"
+	"mime"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Codec converts the request payload to the conventions expected by the editor,
+// UTF-8 and the configured line ending, and restores the original conventions
+// of the edited payload for the response.
+type Codec struct {
+	lineEnding string // line ending expected by the editor
+	nfc        bool   // unicode NFC normalization
+}
+
+// Conventions represents the original payload encoding, byte-order-mark and line
+// ending conventions.
+type Conventions struct {
+	MediaType  string            // payload media type, plain text or HTML
+	Charset    string            // canonical charset name
+	BOM        bool              // payload starts with a byte-order-mark
+	LineEnding string            // dominant line ending, empty when unknown
+	enc        encoding.Encoding // charset encoding
+}
+
+const (
+	// LF unix line ending.
+	LF = "lf"
+	// CRLF windows line ending.
+	CRLF = "crlf"
+
+	// UTF8 utf-8 charset name.
+	UTF8 = "utf-8"
+	// Latin1 default charset when the payload is not valid UTF-8, windows-1252 is
+	// a superset of ISO-8859-1.
+	Latin1 = "windows-1252"
+
+	// TextPlain plain text media type.
+	TextPlain = "text/plain"
+	// TextHTML HTML media type.
+	TextHTML = "text/html"
+)
+
+// boms known byte-order-marks and the respective charset name.
+var boms = []struct {
+	bom     []byte
+	charset string
+}{
+	{bom: []byte{0xef, 0xbb, 0xbf}, charset: UTF8},
+	{bom: []byte{0xff, 0xfe}, charset: "utf-16le"},
+	{bom: []byte{0xfe, 0xff}, charset: "utf-16be"},
+}
+
+// parseContentType extracts the media type, plain text or HTML, and the
+// "charset" parameter from the content-type.
+func parseContentType(contentType string) (string, string) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return TextPlain, ""
+	}
+	if mediaType != TextHTML {
+		mediaType = TextPlain
+	}
+	return mediaType, params["charset"]
+}
+
+// detectLineEnding detects the dominant line ending of the payload.
+func detectLineEnding(payload []byte) string {
+	crlf := bytes.Count(payload, []byte("\r\n"))
+	lf := bytes.Count(payload, []byte("\n")) - crlf
+	switch {
+	case crlf > 0 && crlf >= lf:
+		return CRLF
+	case lf > 0:
+		return LF
+	default:
+		return ""
+	}
+}
+
+// convertLineEnding converts all line endings to the informed style.
+func convertLineEnding(payload []byte, lineEnding string) []byte {
+	payload = bytes.ReplaceAll(payload, []byte("\r\n"), []byte("\n"))
+	if lineEnding == CRLF {
+		payload = bytes.ReplaceAll(payload, []byte("\n"), []byte("\r\n"))
+	}
+	return payload
+}
+
+// Detect detects the payload conventions, the charset is based on the
+// byte-order-mark, then the content-type, and finally sniffing the payload. An
+// unknown content-type charset is ignored, the payload is sniffed instead.
+func Detect(contentType string, payload []byte) (*Conventions, []byte, error) {
+	c := &Conventions{}
+	var charset string
+	c.MediaType, charset = parseContentType(contentType)
+	for _, b := range boms {
+		if bytes.HasPrefix(payload, b.bom) {
+			c.BOM, c.Charset = true, b.charset
+			payload = payload[len(b.bom):]
+			break
+		}
+	}
+	if c.Charset == "" && charset != "" {
+		if _, err := htmlindex.Get(charset); err == nil {
+			c.Charset = charset
+		}
+	}
+	if c.Charset == "" {
+		c.Charset = Latin1
+		if utf8.Valid(payload) {
+			c.Charset = UTF8
+		}
+	}
+
+	enc, err := htmlindex.Get(c.Charset)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.Charset, err = htmlindex.Name(enc); err != nil {
+		return nil, nil, err
+	}
+	c.enc = enc
+	return c, payload, nil
+}
+
+// Decode detects the payload conventions and converts it to UTF-8, using the
+// editor line ending.
+func (c *Codec) Decode(contentType string, payload []byte) (*Conventions, []byte, error) {
+	conv, payload, err := Detect(contentType, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	if conv.Charset != UTF8 {
+		if payload, err = conv.enc.NewDecoder().Bytes(payload); err != nil {
+			return nil, nil, err
+		}
+	}
+	conv.LineEnding = detectLineEnding(payload)
+
+	payload = convertLineEnding(payload, c.lineEnding)
+	if c.nfc {
+		payload = norm.NFC.Bytes(payload)
+	}
+	return conv, payload, nil
+}
+
+// Restore converts the payload back to the original line endings, keeping it as
+// UTF-8 without the byte-order-mark.
+func (c *Codec) Restore(conv *Conventions, payload []byte) []byte {
+	payload = bytes.TrimPrefix(payload, boms[0].bom)
+	if c.nfc {
+		payload = norm.NFC.Bytes(payload)
+	}
+	if conv.LineEnding != "" {
+		payload = convertLineEnding(payload, conv.LineEnding)
+	}
+	return payload
+}
+
+// Encode converts the edited payload back to the original conventions, when the
+// edited payload can't be represented on the original charset it's kept as
+// UTF-8. Returns the payload and the respective content-type.
+func (c *Codec) Encode(conv *Conventions, payload []byte) ([]byte, string) {
+	payload = c.Restore(conv, payload)
+	if conv.BOM {
+		payload = append(append([]byte{}, boms[0].bom...), payload...)
+	}
+
+	charset := conv.Charset
+	if charset != UTF8 {
+		encoded, err := conv.enc.NewEncoder().Bytes(payload)
+		if err == nil {
+			payload = encoded
+		} else {
+			charset = UTF8
+		}
+	}
+	return payload, mime.FormatMediaType(
+		conv.MediaType, map[string]string{"charset": charset},
+	)
+}
+
+// NewCodec instantiates the Codec with the editor line ending, and optional NFC
+// normalization.
+func NewCodec(lineEnding string, nfc bool) *Codec {
+	return &Codec{lineEnding: lineEnding, nfc: nfc}
+}
@@ -0,0 +1,92 @@
+package codec
+
+import (
+	"testing"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name            string
+		nfc             bool
+		contentType     string
+		payload         []byte
+		wantDecoded     string
+		edited          string
+		wantEncoded     []byte
+		wantContentType string
+	}{{
+		name:            "utf-8 crlf with bom",
+		contentType:     "",
+		payload:         []byte("\xef\xbb\xbfline 1\r\nline 2\r\n"),
+		wantDecoded:     "line 1\nline 2\n",
+		edited:          "line 1\nline 2\nline 3\n",
+		wantEncoded:     []byte("\xef\xbb\xbfline 1\r\nline 2\r\nline 3\r\n"),
+		wantContentType: "text/plain; charset=utf-8",
+	}, {
+		name:            "latin-1 from content-type",
+		contentType:     "text/plain; charset=ISO-8859-1",
+		payload:         []byte("caf\xe9\n"),
+		wantDecoded:     "café\n",
+		edited:          "crème\n",
+		wantEncoded:     []byte("cr\xe8me\n"),
+		wantContentType: "text/plain; charset=windows-1252",
+	}, {
+		name:            "latin-1 sniffed, unrepresentable edit kept as utf-8",
+		contentType:     "",
+		payload:         []byte("caf\xe9"),
+		wantDecoded:     "café",
+		edited:          "café ☕",
+		wantEncoded:     []byte("café ☕"),
+		wantContentType: "text/plain; charset=utf-8",
+	}, {
+		name:            "unknown charset from content-type, sniffed",
+		contentType:     "text/plain; charset=x-user-defined-nope",
+		payload:         []byte("café\n"),
+		wantDecoded:     "café\n",
+		edited:          "crème\n",
+		wantEncoded:     []byte("crème\n"),
+		wantContentType: "text/plain; charset=utf-8",
+	}, {
+		name:            "utf-16le with bom",
+		contentType:     "",
+		payload:         []byte("\xff\xfeo\x00k\x00\r\x00\n\x00"),
+		wantDecoded:     "ok\n",
+		edited:          "ok!\n",
+		wantEncoded:     []byte("\xff\xfeo\x00k\x00!\x00\r\x00\n\x00"),
+		wantContentType: "text/plain; charset=utf-16le",
+	}, {
+		name:            "nfc normalization",
+		nfc:             true,
+		contentType:     "text/plain; charset=utf-8",
+		payload:         []byte("cafe\u0301"),
+		wantDecoded:     "caf\u00e9",
+		edited:          "cafe\u0301!",
+		wantEncoded:     []byte("caf\u00e9!"),
+		wantContentType: "text/plain; charset=utf-8",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCodec(LF, tt.nfc)
+
+			conv, decoded, err := c.Decode(tt.contentType, tt.payload)
+			if err != nil {
+				t.Fatalf("Codec.Decode() error = %v", err)
+			}
+			if string(decoded) != tt.wantDecoded {
+				t.Errorf("Codec.Decode() payload = %q, expected %q",
+					decoded, tt.wantDecoded)
+			}
+
+			encoded, contentType := c.Encode(conv, []byte(tt.edited))
+			if string(encoded) != string(tt.wantEncoded) {
+				t.Errorf("Codec.Encode() payload = %q, expected %q",
+					encoded, tt.wantEncoded)
+			}
+			if contentType != tt.wantContentType {
+				t.Errorf("Codec.Encode() content-type = %q, expected %q",
+					contentType, tt.wantContentType)
+			}
+		})
+	}
+}
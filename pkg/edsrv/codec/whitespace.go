@@ -0,0 +1,64 @@
+package codec
+
+import "bytes"
+
+const (
+	// TrailingNewlinePreserve keeps the original payload trailing newline state.
+	TrailingNewlinePreserve = "preserve"
+	// TrailingNewlineStrip always removes the trailing newlines.
+	TrailingNewlineStrip = "strip"
+	// TrailingNewlineUntouched keeps whatever the editor has saved.
+	TrailingNewlineUntouched = "untouched"
+)
+
+// TrailingNewlinePolicies all supported trailing newline policies.
+var TrailingNewlinePolicies = []string{
+	TrailingNewlinePreserve,
+	TrailingNewlineStrip,
+	TrailingNewlineUntouched,
+}
+
+// hasTrailingNewline asserts the payload ends with a newline.
+func hasTrailingNewline(payload []byte) bool {
+	return bytes.HasSuffix(payload, []byte("\n"))
+}
+
+// trimTrailingNewlines removes all trailing newlines.
+func trimTrailingNewlines(payload []byte) []byte {
+	return bytes.TrimRight(payload, "\r\n")
+}
+
+// trimTrailingWhitespace removes spaces and tabs at the end of every line.
+func trimTrailingWhitespace(payload []byte) []byte {
+	lines := bytes.Split(payload, []byte("\n"))
+	for i, line := range lines {
+		cr := bytes.HasSuffix(line, []byte("\r"))
+		line = bytes.TrimRight(bytes.TrimSuffix(line, []byte("\r")), " \t")
+		if cr {
+			line = append(line, '\r')
+		}
+		lines[i] = line
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// ApplyWhitespace applies the trailing newline policy on the edited payload,
+// comparing with the original, and optionally removes trailing whitespace of
+// every line.
+func ApplyWhitespace(original, edited []byte, policy string, trim bool) []byte {
+	if trim {
+		edited = trimTrailingWhitespace(edited)
+	}
+	switch policy {
+	case TrailingNewlineStrip:
+		return trimTrailingNewlines(edited)
+	case TrailingNewlinePreserve:
+		if !hasTrailingNewline(original) {
+			return trimTrailingNewlines(edited)
+		}
+		if !hasTrailingNewline(edited) {
+			return append(edited, '\n')
+		}
+	}
+	return edited
+}
@@ -0,0 +1,56 @@
+package codec
+
+import "testing"
+
+func TestApplyWhitespace(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		edited   string
+		policy   string
+		trim     bool
+		want     string
+	}{{
+		name:     "preserve, original without newline",
+		original: "single line",
+		edited:   "single line edited\n\n",
+		policy:   TrailingNewlinePreserve,
+		want:     "single line edited",
+	}, {
+		name:     "preserve, original with newline",
+		original: "line\n",
+		edited:   "line edited",
+		policy:   TrailingNewlinePreserve,
+		want:     "line edited\n",
+	}, {
+		name:     "strip",
+		original: "line\n",
+		edited:   "line edited\r\n",
+		policy:   TrailingNewlineStrip,
+		want:     "line edited",
+	}, {
+		name:     "untouched",
+		original: "line",
+		edited:   "line edited\n",
+		policy:   TrailingNewlineUntouched,
+		want:     "line edited\n",
+	}, {
+		name:     "untouched, trimming trailing whitespace",
+		original: "line",
+		edited:   "line 1 \t\r\nline 2  \n",
+		policy:   TrailingNewlineUntouched,
+		trim:     true,
+		want:     "line 1\r\nline 2\n",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyWhitespace(
+				[]byte(tt.original), []byte(tt.edited), tt.policy, tt.trim,
+			)
+			if string(got) != tt.want {
+				t.Errorf("ApplyWhitespace() = %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
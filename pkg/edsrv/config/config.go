@@ -5,28 +5,195 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/codec"
+	"github.com/otaviof/edsrv/pkg/edsrv/editor"
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
+	"github.com/otaviof/edsrv/pkg/edsrv/history"
+	"github.com/otaviof/edsrv/pkg/edsrv/logging"
+	"github.com/otaviof/edsrv/pkg/edsrv/templates"
+	"github.com/otaviof/edsrv/pkg/edsrv/tracing"
 
 	"github.com/spf13/pflag"
 )
 
-// Config represents the configuration informed via command-line flags.
+// Config represents the configuration informed via command-line flags, and the
+// site specific rules from the configuration file.
 type Config struct {
-	LogLevel *slog.Level // log verbosity level
-	Addr     string      // listen address
-	TmpDir   string      // temporary directory
-	Editor   string      // command-line editor
+	LogLevel     *slog.Level          // log verbosity level
+	LogFormat    string               // log format, text or json
+	LogFile      string               // log file, standard output when empty
+	Journald     bool                 // logs on systemd-journald
+	ConfigFile   string               // configuration file path
+	Addr         string               // listen address
+	MetricsAddr  string               // metrics listen address, optional
+	OTLPEndpoint string               // OTLP/HTTP tracing endpoint, optional
+	TmpDir       string               // temporary directory
+	Shred        bool                 // overwrite temporary files before removing
+	Storage      string               // temporary files storage, disk or memory
+	Editors      []string             // command-line editor candidates
+	Fallbacks    []string             // fallback editor candidates
+	AbortCodes   []int                // editor exit codes meaning the edit is aborted
+	Terminal     string               // terminal emulator for terminal based editors
+	Tmux         string               // tmux mode, new window or pane
+	TmuxTarget   string               // tmux target session or window
+	Nvim         bool                 // use a running neovim as editor
+	NvimListen   string               // neovim listen address
+	NvimOpen     string               // how to open the buffer on neovim
+	LineEnding   string               // line ending expected by the editor
+	NFC          bool                 // unicode NFC normalization
+	Rules        []Rule               // site specific rules
+	Profiles     map[string]Overrides // named settings profiles
+
+	TrailingNewline        string // trailing newline policy
+	TrimTrailingWhitespace bool   // trim trailing whitespace on every line
+
+	PreFilters    []string      // filter commands before editing
+	PostFilters   []string      // filter commands after editing
+	FilterTimeout time.Duration // default filter timeout
+
+	TemplatesDir string // templates directory
+
+	StateDir        string        // state directory, history and recovery
+	History         bool          // records the edit history
+	Audit           bool          // records the requests on the audit log
+	HistoryMaxAge   time.Duration // history retention by age
+	HistoryMaxCount int           // history retention by number of entries
+	HistoryMaxSize  int64         // history retention by total size in bytes
+
+	RecoverHook     string // command notified about undelivered edits
+	RecoverEndpoint bool   // serves the undelivered edits on "/recover"
+
+	LogMaxSize    int64         // log file rotation size in bytes
+	LogMaxAge     time.Duration // log file rotation age
+	LogMaxBackups int           // rotated log files kept
+
+	MaxBodySize   int           // maximum request body size in bytes
+	MaxHeaderSize int           // maximum request header size in bytes
+	ReadTimeout   time.Duration // maximum duration for reading the request
+	WriteTimeout  time.Duration // maximum duration for writing the response
+	IdleTimeout   time.Duration // maximum duration for idle keep-alive connections
+
+	AllowedOrigins []string // allowed request origin glob patterns, all when empty
+
+	KeyFile    string // encryption secret file
+	KeyCommand string // encryption secret keyring helper command
+
+	settings map[string][]string // configuration file flag values
+	fromFile map[string]bool     // flags informed by the configuration file
 }
 
 const (
 	// LogLevelFlag log-level flag name.
 	LogLevelFlag = "log-level"
+	// LogFormatFlag log format ("log-format") flag name.
+	LogFormatFlag = "log-format"
+	// LogFileFlag log file ("log-file") flag name.
+	LogFileFlag = "log-file"
+	// LogMaxSizeFlag log file rotation size ("log-max-size") flag name.
+	LogMaxSizeFlag = "log-max-size"
+	// LogMaxAgeFlag log file rotation age ("log-max-age") flag name.
+	LogMaxAgeFlag = "log-max-age"
+	// LogMaxBackupsFlag rotated log files kept ("log-max-backups") flag name.
+	LogMaxBackupsFlag = "log-max-backups"
+	// JournaldFlag logs on systemd-journald ("journald") flag name.
+	JournaldFlag = "journald"
+	// ConfigFlag configuration file ("config") flag name.
+	ConfigFlag = "config"
 	// AddrFlag listen address ("addr") flag name.
 	AddrFlag = "addr"
 	// TmpDirFlag temporary directory ("tmp-dir") flag name.
 	TmpDirFlag = "tmp-dir"
+	// ShredFlag overwrite temporary files before removing ("shred") flag name.
+	ShredFlag = "shred"
+	// StorageFlag temporary files storage ("storage") flag name.
+	StorageFlag = "storage"
 	// EditorFlag editor command and args ("editor") flag name.
 	EditorFlag = "editor"
+	// FallbackEditorFlag fallback editor command and args ("fallback-editor")
+	// flag name.
+	FallbackEditorFlag = "fallback-editor"
+	// AbortExitCodeFlag editor exit codes meaning the edit is aborted
+	// ("abort-exit-code") flag name.
+	AbortExitCodeFlag = "abort-exit-code"
+	// TerminalFlag terminal emulator command ("terminal") flag name.
+	TerminalFlag = "terminal"
+	// TmuxFlag tmux mode ("tmux") flag name.
+	TmuxFlag = "tmux"
+	// TmuxTargetFlag tmux target ("tmux-target") flag name.
+	TmuxTargetFlag = "tmux-target"
+	// NvimFlag neovim editor backend ("nvim") flag name.
+	NvimFlag = "nvim"
+	// NvimListenFlag neovim listen address ("nvim-listen") flag name.
+	NvimListenFlag = "nvim-listen"
+	// NvimOpenFlag neovim buffer open mode ("nvim-open") flag name.
+	NvimOpenFlag = "nvim-open"
+	// LineEndingFlag editor line ending ("line-ending") flag name.
+	LineEndingFlag = "line-ending"
+	// NFCFlag unicode NFC normalization ("nfc") flag name.
+	NFCFlag = "nfc"
+	// TrailingNewlineFlag trailing newline policy ("trailing-newline") flag name.
+	TrailingNewlineFlag = "trailing-newline"
+	// TrimTrailingWhitespaceFlag trims trailing whitespace
+	// ("trim-trailing-whitespace") flag name.
+	TrimTrailingWhitespaceFlag = "trim-trailing-whitespace"
+	// PreFilterFlag filter command before editing ("pre-filter") flag name.
+	PreFilterFlag = "pre-filter"
+	// PostFilterFlag filter command after editing ("post-filter") flag name.
+	PostFilterFlag = "post-filter"
+	// FilterTimeoutFlag default filter timeout ("filter-timeout") flag name.
+	FilterTimeoutFlag = "filter-timeout"
+	// TemplatesDirFlag templates directory ("templates-dir") flag name.
+	TemplatesDirFlag = "templates-dir"
+	// StateDirFlag state directory ("state-dir") flag name.
+	StateDirFlag = "state-dir"
+	// HistoryFlag records the edit history ("history") flag name.
+	HistoryFlag = "history"
+	// AuditFlag records the requests on the audit log ("audit") flag name.
+	AuditFlag = "audit"
+	// HistoryMaxAgeFlag history retention by age ("history-max-age") flag name.
+	HistoryMaxAgeFlag = "history-max-age"
+	// HistoryMaxCountFlag history retention by number of entries
+	// ("history-max-count") flag name.
+	HistoryMaxCountFlag = "history-max-count"
+	// HistoryMaxSizeFlag history retention by total size ("history-max-size")
+	// flag name.
+	HistoryMaxSizeFlag = "history-max-size"
+	// RecoverHookFlag undelivered edits notification command ("recover-hook")
+	// flag name.
+	RecoverHookFlag = "recover-hook"
+	// RecoverEndpointFlag serves the undelivered edits ("recover-endpoint") flag
+	// name.
+	RecoverEndpointFlag = "recover-endpoint"
+	// KeyFileFlag encryption secret file ("key-file") flag name.
+	KeyFileFlag = "key-file"
+	// KeyCommandFlag encryption secret keyring helper command ("key-command")
+	// flag name.
+	KeyCommandFlag = "key-command"
+	// MetricsAddrFlag metrics listen address ("metrics-addr") flag name.
+	MetricsAddrFlag = "metrics-addr"
+	// AllowedOriginFlag allowed request origin glob pattern ("allowed-origin")
+	// flag name.
+	AllowedOriginFlag = "allowed-origin"
+	// OTLPEndpointFlag OTLP/HTTP tracing endpoint ("otlp-endpoint") flag name.
+	OTLPEndpointFlag = "otlp-endpoint"
+	// MaxBodySizeFlag maximum request body size ("max-body-size") flag name.
+	MaxBodySizeFlag = "max-body-size"
+	// MaxHeaderSizeFlag maximum request header size ("max-header-size") flag
+	// name.
+	MaxHeaderSizeFlag = "max-header-size"
+	// ReadTimeoutFlag request read timeout ("read-timeout") flag name.
+	ReadTimeoutFlag = "read-timeout"
+	// WriteTimeoutFlag response write timeout ("write-timeout") flag name.
+	WriteTimeoutFlag = "write-timeout"
+	// IdleTimeoutFlag keep-alive idle timeout ("idle-timeout") flag name.
+	IdleTimeoutFlag = "idle-timeout"
 )
 
 // ErrInvalidConfig shows the configuration is invalid, missing elements.
@@ -34,14 +201,61 @@ var ErrInvalidConfig = errors.New("invalid configuration")
 
 // AddLogLevelFlag adds the "log-level" flag to configure its verbosity level.
 func (c *Config) AddLogLevelFlag(f *pflag.FlagSet) {
-	f.Var(
-		NewLogLevelValue(c.LogLevel),
-		LogLevelFlag,
-		fmt.Sprintf(
-			"log verbosity level (default %q)",
-			strings.ToLower(c.LogLevel.String()),
-		),
-	)
+	// the default is shown by the flag, using the effective level name
+	f.Var(NewLogLevelValue(c.LogLevel), LogLevelFlag, "log verbosity level")
+}
+
+// AddLogFlags adds "log-format", "log-file", the log file rotation flags and
+// "journald".
+func (c *Config) AddLogFlags(f *pflag.FlagSet) {
+	f.StringVar(&c.LogFormat, LogFormatFlag, c.LogFormat,
+		fmt.Sprintf("log format, %q", logging.Formats))
+	f.StringVar(&c.LogFile, LogFileFlag, c.LogFile,
+		"log file, standard output when empty")
+	f.Int64Var(&c.LogMaxSize, LogMaxSizeFlag, c.LogMaxSize,
+		"log file rotation size in bytes, zero disables")
+	f.DurationVar(&c.LogMaxAge, LogMaxAgeFlag, c.LogMaxAge,
+		"log file rotation age, zero disables")
+	f.IntVar(&c.LogMaxBackups, LogMaxBackupsFlag, c.LogMaxBackups,
+		"rotated log files kept, zero keeps all")
+	f.BoolVar(&c.Journald, JournaldFlag, c.Journald,
+		"logs on systemd-journald, falls back to standard error")
+}
+
+// ValidateLogFlags validates the log format and rotation flags.
+func (c *Config) ValidateLogFlags() error {
+	if !slices.Contains(logging.Formats, c.LogFormat) {
+		return fmt.Errorf("%w: flag %q must be one of %q",
+			ErrInvalidConfig, LogFormatFlag, logging.Formats)
+	}
+	if c.LogMaxSize < 0 || c.LogMaxAge < 0 || c.LogMaxBackups < 0 {
+		return fmt.Errorf("%w: log rotation flags must not be negative",
+			ErrInvalidConfig)
+	}
+	if c.LogFile != "" && c.Journald {
+		return fmt.Errorf("%w: flags %q and %q are mutually exclusive",
+			ErrInvalidConfig, LogFileFlag, JournaldFlag)
+	}
+	return nil
+}
+
+// LogOptions returns the logging output options.
+func (c *Config) LogOptions(identifier string) logging.Options {
+	return logging.Options{
+		Identifier: identifier,
+		Level:      c.LogLevel,
+		Format:     c.LogFormat,
+		File:       c.LogFile,
+		MaxSize:    c.LogMaxSize,
+		MaxAge:     c.LogMaxAge,
+		MaxBackups: c.LogMaxBackups,
+		Journald:   c.Journald,
+	}
+}
+
+// AddConfigFlag adds the "config" flag to inform the configuration file.
+func (c *Config) AddConfigFlag(f *pflag.FlagSet) {
+	f.StringVar(&c.ConfigFile, ConfigFlag, c.ConfigFile, "configuration file")
 }
 
 // AddAddrFlag adds "addr" flag, also present on "status" subcommand.
@@ -49,21 +263,179 @@ func (c *Config) AddAddrFlag(f *pflag.FlagSet) {
 	f.StringVar(&c.Addr, AddrFlag, c.Addr, "listen address and port")
 }
 
-// AddTmpDirFlag adds "tmp-dir" flag.
+// AddTmpDirFlag adds "tmp-dir", "shred" and "storage" flags.
 func (c *Config) AddTmpDirFlag(f *pflag.FlagSet) {
 	f.StringVar(&c.TmpDir, TmpDirFlag, c.TmpDir, "temporary directory")
+	f.BoolVar(&c.Shred, ShredFlag, c.Shred,
+		"overwrites the temporary file content before removing")
+	f.StringVar(&c.Storage, StorageFlag, c.Storage,
+		fmt.Sprintf("temporary files storage, %q", file.Storages))
+}
+
+// AddEditorFlags adds "editor" and "fallback-editor" flags, both can be
+// repeated to inform a ordered list of candidates, and "abort-exit-code".
+func (c *Config) AddEditorFlags(f *pflag.FlagSet) {
+	f.StringArrayVar(&c.Editors, EditorFlag, c.Editors,
+		"command-line editor snippet, repeat for more candidates")
+	f.StringArrayVar(&c.Fallbacks, FallbackEditorFlag, c.Fallbacks,
+		"fallback editor snippet, tried after the editor candidates")
+	f.IntSliceVar(&c.AbortCodes, AbortExitCodeFlag, c.AbortCodes,
+		"editor exit code meaning the edit is aborted (\":cq\" on vim)")
+}
+
+// IsAbortCode asserts the editor exit status means the edit is aborted.
+func (c *Config) IsAbortCode(status int) bool {
+	return slices.Contains(c.AbortCodes, status)
+}
+
+// AddTerminalFlags adds "terminal", "tmux" and "tmux-target" flags.
+func (c *Config) AddTerminalFlags(f *pflag.FlagSet) {
+	f.StringVar(&c.Terminal, TerminalFlag, c.Terminal,
+		"terminal emulator to run terminal based editors (foot, kitty, etc)")
+	f.StringVar(&c.Tmux, TmuxFlag, c.Tmux,
+		fmt.Sprintf("runs the editor on a new tmux %q or %q",
+			editor.TmuxWindow, editor.TmuxPane))
+	f.StringVar(&c.TmuxTarget, TmuxTargetFlag, c.TmuxTarget,
+		"tmux target session or window")
+}
+
+// AddNvimFlags adds "nvim", "nvim-listen" and "nvim-open" flags.
+func (c *Config) AddNvimFlags(f *pflag.FlagSet) {
+	f.BoolVar(&c.Nvim, NvimFlag, c.Nvim,
+		"edits on a running neovim instance, instead of the editor command")
+	f.StringVar(&c.NvimListen, NvimListenFlag, c.NvimListen,
+		"neovim listen address, unix socket path or host:port")
+	f.StringVar(&c.NvimOpen, NvimOpenFlag, c.NvimOpen,
+		fmt.Sprintf("opens the buffer on neovim %q, %q or %q",
+			editor.NvimTab, editor.NvimSplit, editor.NvimVsplit))
+}
+
+// AddEncodingFlags adds "line-ending" and "nfc" flags.
+func (c *Config) AddEncodingFlags(f *pflag.FlagSet) {
+	f.StringVar(&c.LineEnding, LineEndingFlag, c.LineEnding,
+		fmt.Sprintf("line ending expected by the editor, %q or %q",
+			codec.LF, codec.CRLF))
+	f.BoolVar(&c.NFC, NFCFlag, c.NFC, "unicode NFC normalization")
+}
+
+// AddWhitespaceFlags adds "trailing-newline" and "trim-trailing-whitespace"
+// flags.
+func (c *Config) AddWhitespaceFlags(f *pflag.FlagSet) {
+	f.StringVar(&c.TrailingNewline, TrailingNewlineFlag, c.TrailingNewline,
+		fmt.Sprintf("trailing newline policy, %q",
+			codec.TrailingNewlinePolicies))
+	f.BoolVar(&c.TrimTrailingWhitespace, TrimTrailingWhitespaceFlag,
+		c.TrimTrailingWhitespace, "trims trailing whitespace on every line")
 }
 
-// AddEditorFlag adds "editor" flag.
-func (c *Config) AddEditorFlag(f *pflag.FlagSet) {
-	f.StringVar(&c.Editor, EditorFlag, c.Editor, "command-line editor snippet")
+// AddFilterFlags adds "pre-filter", "post-filter" and "filter-timeout" flags.
+func (c *Config) AddFilterFlags(f *pflag.FlagSet) {
+	f.StringArrayVar(&c.PreFilters, PreFilterFlag, c.PreFilters,
+		"filter command applied before editing, repeat for a pipeline")
+	f.StringArrayVar(&c.PostFilters, PostFilterFlag, c.PostFilters,
+		"filter command applied after editing, repeat for a pipeline")
+	f.DurationVar(&c.FilterTimeout, FilterTimeoutFlag, c.FilterTimeout,
+		"default filter timeout")
+}
+
+// AddTemplatesDirFlag adds "templates-dir" flag, also present on "templates"
+// subcommand.
+func (c *Config) AddTemplatesDirFlag(f *pflag.FlagSet) {
+	f.StringVar(&c.TemplatesDir, TemplatesDirFlag, c.TemplatesDir,
+		"templates directory, used for empty fields")
+}
+
+// AddStateDirFlag adds "state-dir" flag.
+func (c *Config) AddStateDirFlag(f *pflag.FlagSet) {
+	f.StringVar(&c.StateDir, StateDirFlag, c.StateDir,
+		"state directory, for the edit history and recovery")
+}
+
+// AddKeyFlags adds "key-file" and "key-command" flags, the encryption secret for
+// the stored content.
+func (c *Config) AddKeyFlags(f *pflag.FlagSet) {
+	f.StringVar(&c.KeyFile, KeyFileFlag, c.KeyFile,
+		"encrypts the stored content with the secret on the file")
+	f.StringVar(&c.KeyCommand, KeyCommandFlag, c.KeyCommand,
+		"encrypts the stored content with the secret printed by the command")
+}
+
+// AddRecoverFlags adds "recover-hook" and "recover-endpoint" flags.
+func (c *Config) AddRecoverFlags(f *pflag.FlagSet) {
+	f.StringVar(&c.RecoverHook, RecoverHookFlag, c.RecoverHook,
+		"command notified when a edit can't be delivered to the client")
+	f.BoolVar(&c.RecoverEndpoint, RecoverEndpointFlag, c.RecoverEndpoint,
+		"serves the undelivered edits text on \"/recover\", for loopback clients")
+}
+
+// AddHistoryRetentionFlags adds "history-max-age", "history-max-count" and
+// "history-max-size" flags, also present on "history" subcommand.
+func (c *Config) AddHistoryRetentionFlags(f *pflag.FlagSet) {
+	f.DurationVar(&c.HistoryMaxAge, HistoryMaxAgeFlag, c.HistoryMaxAge,
+		"history retention by age, zero disables")
+	f.IntVar(&c.HistoryMaxCount, HistoryMaxCountFlag, c.HistoryMaxCount,
+		"history retention by number of entries, zero disables")
+	f.Int64Var(&c.HistoryMaxSize, HistoryMaxSizeFlag, c.HistoryMaxSize,
+		"history retention by total size in bytes, zero disables")
+}
+
+// AddHistoryFlags adds "history" and the history retention flags.
+func (c *Config) AddHistoryFlags(f *pflag.FlagSet) {
+	f.BoolVar(&c.History, HistoryFlag, c.History,
+		"records the original and edited text on the state directory")
+	c.AddHistoryRetentionFlags(f)
+}
+
+// AddAuditFlag adds the "audit" flag.
+func (c *Config) AddAuditFlag(f *pflag.FlagSet) {
+	f.BoolVar(&c.Audit, AuditFlag, c.Audit,
+		"records the requests on the audit log, on the state directory")
+}
+
+// AddServerFlags adds "max-body-size", "max-header-size", "read-timeout",
+// "write-timeout" and "idle-timeout" flags, the HTTP server limits, and
+// "metrics-addr".
+func (c *Config) AddServerFlags(f *pflag.FlagSet) {
+	f.StringVar(&c.MetricsAddr, MetricsAddrFlag, c.MetricsAddr,
+		"metrics listen address and port, disabled when empty")
+	f.IntVar(&c.MaxBodySize, MaxBodySizeFlag, c.MaxBodySize,
+		"maximum request body size in bytes")
+	f.IntVar(&c.MaxHeaderSize, MaxHeaderSizeFlag, c.MaxHeaderSize,
+		"maximum request header size in bytes")
+	f.DurationVar(&c.ReadTimeout, ReadTimeoutFlag, c.ReadTimeout,
+		"maximum duration for reading the request")
+	f.DurationVar(&c.WriteTimeout, WriteTimeoutFlag, c.WriteTimeout,
+		"maximum duration for writing the response")
+	f.DurationVar(&c.IdleTimeout, IdleTimeoutFlag, c.IdleTimeout,
+		"maximum duration for idle keep-alive connections")
+	f.StringArrayVar(&c.AllowedOrigins, AllowedOriginFlag, c.AllowedOrigins,
+		"allowed request origin glob pattern, can be repeated, all when empty")
+}
+
+// AddTracingFlags adds the "otlp-endpoint" flag.
+func (c *Config) AddTracingFlags(f *pflag.FlagSet) {
+	f.StringVar(&c.OTLPEndpoint, OTLPEndpointFlag, c.OTLPEndpoint,
+		"OTLP/HTTP endpoint to export the request traces, disabled when empty")
 }
 
 // AddStartFlags adds all flags related to the "start" subcommand.
 func (c *Config) AddStartFlags(f *pflag.FlagSet) {
 	c.AddAddrFlag(f)
+	c.AddServerFlags(f)
+	c.AddTracingFlags(f)
 	c.AddTmpDirFlag(f)
-	c.AddEditorFlag(f)
+	c.AddEditorFlags(f)
+	c.AddTerminalFlags(f)
+	c.AddNvimFlags(f)
+	c.AddEncodingFlags(f)
+	c.AddWhitespaceFlags(f)
+	c.AddFilterFlags(f)
+	c.AddTemplatesDirFlag(f)
+	c.AddStateDirFlag(f)
+	c.AddHistoryFlags(f)
+	c.AddAuditFlag(f)
+	c.AddRecoverFlags(f)
+	c.AddKeyFlags(f)
 }
 
 // ValidateAddrFlag validates the "addr" flag.
@@ -75,16 +447,30 @@ func (c *Config) ValidateAddrFlag() error {
 	return nil
 }
 
-// ValidateEditorFlag validates the "editor" flag.
+// EditorCandidates returns the ordered list of editor candidates, the editors
+// followed by the fallbacks, skipping empty entries.
+func (c *Config) EditorCandidates() []string {
+	candidates := []string{}
+	for _, e := range append(append([]string{}, c.Editors...), c.Fallbacks...) {
+		if strings.TrimSpace(e) != "" {
+			candidates = append(candidates, e)
+		}
+	}
+	return candidates
+}
+
+// ValidateEditorFlag validates the "editor" flag, at least one candidate must be
+// informed.
 func (c *Config) ValidateEditorFlag() error {
-	if c.Editor == "" {
-		return fmt.Errorf("%w: flag %q is not informed",
-			ErrInvalidConfig, EditorFlag)
+	if len(c.EditorCandidates()) == 0 {
+		return fmt.Errorf("%w: flag %q is not informed, and $VISUAL and "+
+			"$EDITOR are empty", ErrInvalidConfig, EditorFlag)
 	}
 	return nil
 }
 
-// ValidateTmpDirFlag validates "tmp-dir" flag.
+// ValidateTmpDirFlag validates "tmp-dir" flag, the directory must be safe to use
+// for temporary files.
 func (c *Config) ValidateTmpDirFlag() error {
 	if c.TmpDir == "" {
 		return fmt.Errorf("%w: flag %q is not informed",
@@ -97,28 +483,302 @@ func (c *Config) ValidateTmpDirFlag() error {
 	if !stat.IsDir() {
 		return fmt.Errorf("%w: %q is not found", ErrInvalidConfig, c.TmpDir)
 	}
+	if err = file.CheckTmpDir(c.TmpDir); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	return nil
+}
+
+// ValidateTerminalFlags validates "terminal", "tmux" and "tmux-target" flags.
+func (c *Config) ValidateTerminalFlags() error {
+	if c.Terminal != "" && strings.TrimSpace(c.Terminal) == "" {
+		return fmt.Errorf("%w: flag %q invalid value %q",
+			ErrInvalidConfig, TerminalFlag, c.Terminal)
+	}
+	if c.Terminal != "" && c.Tmux != "" {
+		return fmt.Errorf("%w: flags %q and %q are mutually exclusive",
+			ErrInvalidConfig, TerminalFlag, TmuxFlag)
+	}
+	switch c.Tmux {
+	case "", editor.TmuxWindow, editor.TmuxPane:
+	default:
+		return fmt.Errorf("%w: flag %q invalid value %q",
+			ErrInvalidConfig, TmuxFlag, c.Tmux)
+	}
+	if c.TmuxTarget != "" && c.Tmux == "" {
+		return fmt.Errorf("%w: flag %q requires %q",
+			ErrInvalidConfig, TmuxTargetFlag, TmuxFlag)
+	}
+	return nil
+}
+
+// ValidateNvimFlags validates "nvim-listen" and "nvim-open" flags, when the
+// neovim editor is enabled.
+func (c *Config) ValidateNvimFlags() error {
+	if !c.Nvim {
+		return nil
+	}
+	if c.NvimListen == "" {
+		return fmt.Errorf("%w: flag %q is not informed and $NVIM is empty",
+			ErrInvalidConfig, NvimListenFlag)
+	}
+	switch c.NvimOpen {
+	case editor.NvimTab, editor.NvimSplit, editor.NvimVsplit:
+	default:
+		return fmt.Errorf("%w: flag %q invalid value %q",
+			ErrInvalidConfig, NvimOpenFlag, c.NvimOpen)
+	}
+	return nil
+}
+
+// ValidateLineEndingFlag validates the "line-ending" flag.
+func (c *Config) ValidateLineEndingFlag() error {
+	switch c.LineEnding {
+	case codec.LF, codec.CRLF:
+		return nil
+	default:
+		return fmt.Errorf("%w: flag %q invalid value %q",
+			ErrInvalidConfig, LineEndingFlag, c.LineEnding)
+	}
+}
+
+// ValidateTrailingNewlineFlag validates the "trailing-newline" flag.
+func (c *Config) ValidateTrailingNewlineFlag() error {
+	if !slices.Contains(codec.TrailingNewlinePolicies, c.TrailingNewline) {
+		return fmt.Errorf("%w: flag %q invalid value %q",
+			ErrInvalidConfig, TrailingNewlineFlag, c.TrailingNewline)
+	}
 	return nil
 }
 
+// ValidateStorageFlag validates the "storage" flag.
+func (c *Config) ValidateStorageFlag() error {
+	if !slices.Contains(file.Storages, c.Storage) {
+		return fmt.Errorf("%w: flag %q invalid value %q",
+			ErrInvalidConfig, StorageFlag, c.Storage)
+	}
+	return nil
+}
+
+// ValidateFilterTimeoutFlag validates the "filter-timeout" flag.
+func (c *Config) ValidateFilterTimeoutFlag() error {
+	if c.FilterTimeout <= 0 {
+		return fmt.Errorf("%w: flag %q must be positive",
+			ErrInvalidConfig, FilterTimeoutFlag)
+	}
+	return nil
+}
+
+// ValidateStateDirFlag validates the "state-dir" flag.
+func (c *Config) ValidateStateDirFlag() error {
+	if c.StateDir == "" {
+		return fmt.Errorf("%w: flag %q is not informed",
+			ErrInvalidConfig, StateDirFlag)
+	}
+	return nil
+}
+
+// ValidateKeyFlags validates the "key-file" and "key-command" flags.
+func (c *Config) ValidateKeyFlags() error {
+	if c.KeyFile != "" && c.KeyCommand != "" {
+		return fmt.Errorf("%w: flags %q and %q are mutually exclusive",
+			ErrInvalidConfig, KeyFileFlag, KeyCommandFlag)
+	}
+	return nil
+}
+
+// ValidateServerFlags validates the HTTP server limits, sizes and timeouts must
+// be positive.
+func (c *Config) ValidateServerFlags() error {
+	if c.MaxBodySize <= 0 || c.MaxHeaderSize <= 0 {
+		return fmt.Errorf("%w: flags %q and %q must be positive",
+			ErrInvalidConfig, MaxBodySizeFlag, MaxHeaderSizeFlag)
+	}
+	if c.ReadTimeout <= 0 || c.WriteTimeout <= 0 || c.IdleTimeout <= 0 {
+		return fmt.Errorf("%w: flags %q, %q and %q must be positive",
+			ErrInvalidConfig, ReadTimeoutFlag, WriteTimeoutFlag, IdleTimeoutFlag)
+	}
+	if c.MetricsAddr != "" && c.MetricsAddr == c.Addr {
+		return fmt.Errorf("%w: flags %q and %q must be different addresses",
+			ErrInvalidConfig, MetricsAddrFlag, AddrFlag)
+	}
+	for _, pattern := range c.AllowedOrigins {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: flag %q invalid pattern %q: %w",
+				ErrInvalidConfig, AllowedOriginFlag, pattern, err)
+		}
+	}
+	return nil
+}
+
+// OriginAllowed asserts the request origin matches the allowed origins, when
+// informed. Requests without origin, from non-browser clients, are allowed.
+func (c *Config) OriginAllowed(origin string) bool {
+	if len(c.AllowedOrigins) == 0 || origin == "" {
+		return true
+	}
+	for _, pattern := range c.AllowedOrigins {
+		if ok, _ := path.Match(pattern, origin); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateHistoryFlags validates the history retention flags.
+func (c *Config) ValidateHistoryFlags() error {
+	if c.HistoryMaxAge < 0 || c.HistoryMaxCount < 0 || c.HistoryMaxSize < 0 {
+		return fmt.Errorf("%w: history retention flags must not be negative",
+			ErrInvalidConfig)
+	}
+	return nil
+}
+
+// HistoryDir returns the history directory, under the state directory.
+func (c *Config) HistoryDir() string {
+	return filepath.Join(c.StateDir, "history")
+}
+
+// AuditFile returns the audit log file, under the state directory.
+func (c *Config) AuditFile() string {
+	return filepath.Join(c.StateDir, "audit.jsonl")
+}
+
+// RecoverDir returns the recovery directory, for undelivered edits, under the
+// state directory.
+func (c *Config) RecoverDir() string {
+	return filepath.Join(c.StateDir, "recover")
+}
+
+// KeyParamsFile returns the encryption key parameters file, under the state
+// directory.
+func (c *Config) KeyParamsFile() string {
+	return filepath.Join(c.StateDir, "key.json")
+}
+
+// LockFile returns the state directory lock file, held by the running server.
+func (c *Config) LockFile() string {
+	return filepath.Join(c.StateDir, "edsrv.lock")
+}
+
+// HistoryRetention returns the history retention limits.
+func (c *Config) HistoryRetention() history.Retention {
+	return history.Retention{
+		MaxAge:   c.HistoryMaxAge,
+		MaxCount: c.HistoryMaxCount,
+		MaxSize:  c.HistoryMaxSize,
+	}
+}
+
+// RecoverRetention returns the recovery area retention limits, the same as the
+// history.
+func (c *Config) RecoverRetention() history.Retention {
+	return c.HistoryRetention()
+}
+
+// ValidateTracingFlags validates the "otlp-endpoint" flag, when informed.
+func (c *Config) ValidateTracingFlags() error {
+	if c.OTLPEndpoint == "" {
+		return nil
+	}
+	if _, err := tracing.TracesURL(c.OTLPEndpoint); err != nil {
+		return fmt.Errorf("%w: flag %q: %w", ErrInvalidConfig, OTLPEndpointFlag, err)
+	}
+	return nil
+}
+
+// TracesURL returns the OTLP/HTTP traces URL, see tracing.TracesURL.
+func (c *Config) TracesURL() (string, error) {
+	return tracing.TracesURL(c.OTLPEndpoint)
+}
+
+// startValidators returns the validators employed on "start" subcommand, in
+// order.
+func (c *Config) startValidators() []func() error {
+	editorValidator := c.ValidateEditorFlag
+	if c.Nvim {
+		editorValidator = c.ValidateNvimFlags
+	}
+	return []func() error{
+		c.ValidateAddrFlag,
+		c.ValidateServerFlags,
+		c.ValidateTracingFlags,
+		editorValidator,
+		c.ValidateTerminalFlags,
+		c.ValidateLineEndingFlag,
+		c.ValidateTrailingNewlineFlag,
+		c.ValidateFilterTimeoutFlag,
+		c.ValidateStorageFlag,
+		c.ValidateStateDirFlag,
+		c.ValidateHistoryFlags,
+		c.ValidateKeyFlags,
+		c.ValidateTmpDirFlag,
+	}
+}
+
 // ValidateStartFlags validates all flags employed on "start" subcommand.
 func (c *Config) ValidateStartFlags() error {
-	var err error
-	if err = c.ValidateAddrFlag(); err != nil {
-		return err
+	for _, validate := range c.startValidators() {
+		if err := validate(); err != nil {
+			return err
+		}
 	}
-	if err = c.ValidateEditorFlag(); err != nil {
-		return err
+	return nil
+}
+
+// ValidateAll runs all the validators, the logging and "start" subcommand flags,
+// returning all the errors found.
+func (c *Config) ValidateAll() error {
+	errs := []error{c.ValidateLogFlags()}
+	for _, validate := range c.startValidators() {
+		errs = append(errs, validate())
 	}
-	return c.ValidateTmpDirFlag()
+	return errors.Join(errs...)
 }
 
 // LoggerWith decorates logger with the flags informed, where empty flag values
 // are skipped.
 func (c *Config) LoggerWith(logger *slog.Logger, flags ...string) *slog.Logger {
 	m := map[string]string{
-		AddrFlag:   c.Addr,
-		EditorFlag: c.Editor,
-		TmpDirFlag: c.TmpDir,
+		AddrFlag:       c.Addr,
+		EditorFlag:     strings.Join(c.EditorCandidates(), ","),
+		TmpDirFlag:     c.TmpDir,
+		ShredFlag:      strconv.FormatBool(c.Shred),
+		StorageFlag:    c.Storage,
+		TerminalFlag:   c.Terminal,
+		TmuxFlag:       c.Tmux,
+		TmuxTargetFlag: c.TmuxTarget,
+		NvimListenFlag: c.NvimListen,
+		NvimOpenFlag:   c.NvimOpen,
+		LineEndingFlag: c.LineEnding,
+		NFCFlag:        strconv.FormatBool(c.NFC),
+		ConfigFlag:     c.ConfigFile,
+
+		TrailingNewlineFlag:        c.TrailingNewline,
+		TrimTrailingWhitespaceFlag: strconv.FormatBool(c.TrimTrailingWhitespace),
+		PreFilterFlag:              strings.Join(c.PreFilters, ","),
+		PostFilterFlag:             strings.Join(c.PostFilters, ","),
+		FilterTimeoutFlag:          c.FilterTimeout.String(),
+		TemplatesDirFlag:           c.TemplatesDir,
+		StateDirFlag:               c.StateDir,
+		HistoryFlag:                strconv.FormatBool(c.History),
+		HistoryMaxAgeFlag:          c.HistoryMaxAge.String(),
+		HistoryMaxCountFlag:        strconv.Itoa(c.HistoryMaxCount),
+		HistoryMaxSizeFlag:         strconv.FormatInt(c.HistoryMaxSize, 10),
+		AuditFlag:                  strconv.FormatBool(c.Audit),
+		RecoverHookFlag:            c.RecoverHook,
+		RecoverEndpointFlag:        strconv.FormatBool(c.RecoverEndpoint),
+		KeyFileFlag:                c.KeyFile,
+		KeyCommandFlag:             c.KeyCommand,
+		AbortExitCodeFlag:          joinInts(c.AbortCodes),
+		MetricsAddrFlag:            c.MetricsAddr,
+		AllowedOriginFlag:          strings.Join(c.AllowedOrigins, ","),
+		OTLPEndpointFlag:           c.OTLPEndpoint,
+		MaxBodySizeFlag:            strconv.Itoa(c.MaxBodySize),
+		MaxHeaderSizeFlag:          strconv.Itoa(c.MaxHeaderSize),
+		ReadTimeoutFlag:            c.ReadTimeout.String(),
+		WriteTimeoutFlag:           c.WriteTimeout.String(),
+		IdleTimeoutFlag:            c.IdleTimeout.String(),
 	}
 	for _, k := range flags {
 		v, ok := m[k]
@@ -130,13 +790,73 @@ func (c *Config) LoggerWith(logger *slog.Logger, flags ...string) *slog.Logger {
 	return logger
 }
 
+// joinInts joins the integers with comma.
+func joinInts(ints []int) string {
+	s := make([]string, 0, len(ints))
+	for _, i := range ints {
+		s = append(s, strconv.Itoa(i))
+	}
+	return strings.Join(s, ",")
+}
+
+// getenvs returns the values of the informed environment variables, skipping
+// the empty ones.
+func getenvs(names ...string) []string {
+	values := []string{}
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 // NewConfig instantiate a new Config with default values.
 func NewConfig() *Config {
 	defaultLogLevel := slog.LevelDebug
 	return &Config{
-		LogLevel: &defaultLogLevel,
-		Addr:     "127.0.0.1:8928",
-		TmpDir:   os.Getenv("TMPDIR"),
-		Editor:   os.Getenv("EDITOR"),
+		LogLevel:   &defaultLogLevel,
+		LogFormat:  logging.FormatText,
+		Addr:       "127.0.0.1:8928",
+		TmpDir:     os.Getenv("TMPDIR"),
+		Editors:    getenvs("VISUAL", "EDITOR"),
+		Fallbacks:  []string{},
+		AbortCodes: []int{1},
+		NvimListen: os.Getenv("NVIM"),
+		NvimOpen:   editor.NvimTab,
+		LineEnding: codec.LF,
+		Storage:    file.StorageDisk,
+		ConfigFile: DefaultConfigFile(),
+		Rules:      []Rule{},
+		Profiles:   map[string]Overrides{},
+		settings:   map[string][]string{},
+		fromFile:   map[string]bool{},
+
+		OTLPEndpoint: os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+
+		TrailingNewline: codec.TrailingNewlinePreserve,
+
+		AllowedOrigins: []string{},
+
+		PreFilters:    []string{},
+		PostFilters:   []string{},
+		FilterTimeout: 10 * time.Second,
+
+		TemplatesDir: templates.DefaultDir(),
+
+		StateDir:        DefaultStateDir(),
+		HistoryMaxAge:   30 * 24 * time.Hour,
+		HistoryMaxCount: 1000,
+		HistoryMaxSize:  64 << 20,
+
+		LogMaxSize:    10 << 20,
+		LogMaxAge:     7 * 24 * time.Hour,
+		LogMaxBackups: 5,
+
+		MaxBodySize:   4 << 20,
+		MaxHeaderSize: 8 << 10,
+		ReadTimeout:   30 * time.Second,
+		WriteTimeout:  30 * time.Second,
+		IdleTimeout:   time.Minute,
 	}
 }
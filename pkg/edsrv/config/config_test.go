@@ -0,0 +1,16 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewConfig_Editors(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "vim")
+
+	c := NewConfig()
+	if want := []string{"vim"}; !reflect.DeepEqual(c.Editors, want) {
+		t.Errorf("NewConfig() editors = %q, expected %q", c.Editors, want)
+	}
+}
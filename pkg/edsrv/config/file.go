@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File represents the configuration file contents.
+type File struct {
+	Settings map[string]any       `yaml:"settings,omitempty"` // global flag values
+	Profiles map[string]Overrides `yaml:"profiles,omitempty"` // named settings
+	Rules    []Rule               `yaml:"rules,omitempty"`    // site specific settings
+}
+
+// DefaultConfigFile returns the default configuration file location, under the
+// user configuration directory ("$XDG_CONFIG_HOME").
+func DefaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "edsrv", "config.yaml")
+}
+
+// DefaultStateDir returns the default state directory, "$XDG_STATE_HOME/edsrv"
+// or "~/.local/state/edsrv" when the variable is not set.
+func DefaultStateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "edsrv")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", "edsrv")
+}
+
+// LoadConfigFile loads the configuration file, the default location is optional
+// while a explicitly informed file must exist.
+func (c *Config) LoadConfigFile() error {
+	if c.ConfigFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.ConfigFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && c.ConfigFile == DefaultConfigFile() {
+			return nil
+		}
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	f := &File{}
+	if err = yaml.Unmarshal(data, f); err != nil {
+		return fmt.Errorf("%w: %q: %w", ErrInvalidConfig, c.ConfigFile, err)
+	}
+	settings, err := settingsValues(f.Settings)
+	if err != nil {
+		return fmt.Errorf("%w: %q: %w", ErrInvalidConfig, c.ConfigFile, err)
+	}
+	for name, p := range f.Profiles {
+		if err = p.validate(); err != nil {
+			return fmt.Errorf("%q: profile %q: %w", c.ConfigFile, name, err)
+		}
+	}
+	for i := range f.Rules {
+		if err = f.Rules[i].compile(); err != nil {
+			return fmt.Errorf("%q: rule %d: %w", c.ConfigFile, i, err)
+		}
+		if p := f.Rules[i].Profile; p != "" {
+			if _, ok := f.Profiles[p]; !ok {
+				return fmt.Errorf("%q: rule %d: %w: %q",
+					c.ConfigFile, i, ErrProfileNotFound, p)
+			}
+		}
+	}
+	if f.Profiles != nil {
+		c.Profiles = f.Profiles
+	}
+	c.Rules = f.Rules
+	c.settings = settings
+	return nil
+}
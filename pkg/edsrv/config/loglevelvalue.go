@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/spf13/pflag"
 )
@@ -35,8 +36,11 @@ func (l *LogLevelValue) Set(level string) error {
 	return nil
 }
 
-// String shows the current level name.
+// String shows the current level name, or the effective level when not set.
 func (l *LogLevelValue) String() string {
+	if l.level == "" && l.logLevel != nil {
+		return strings.ToLower(l.logLevel.String())
+	}
 	return l.level
 }
 
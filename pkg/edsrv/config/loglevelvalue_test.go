@@ -63,3 +63,10 @@ func TestLogLevelValue_Set(t *testing.T) {
 		})
 	}
 }
+
+func TestLogLevelValue_String(t *testing.T) {
+	level := slog.LevelWarn
+	if got := NewLogLevelValue(&level).String(); got != "warn" {
+		t.Errorf("LogLevelValue.String() = %q, expected = %q", got, "warn")
+	}
+}
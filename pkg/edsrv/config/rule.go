@@ -0,0 +1,220 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/codec"
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
+	"github.com/otaviof/edsrv/pkg/edsrv/filter"
+)
+
+// Overrides represents the settings which can be overridden per profile or site
+// rule, empty values are skipped.
+type Overrides struct {
+	Format                 string          `yaml:"format,omitempty"`
+	TrailingNewline        string          `yaml:"trailingNewline,omitempty"`
+	TrimTrailingWhitespace *bool           `yaml:"trimTrailingWhitespace,omitempty"`
+	PreFilters             []filter.Filter `yaml:"preFilters,omitempty"`
+	PostFilters            []filter.Filter `yaml:"postFilters,omitempty"`
+	Template               string          `yaml:"template,omitempty"`
+	Storage                string          `yaml:"storage,omitempty"`
+	Extension              string          `yaml:"extension,omitempty"`
+}
+
+// Rule represents settings for specific sites, the rule matches when all the
+// informed criteria match the request metadata.
+type Rule struct {
+	Host      string `yaml:"host,omitempty"`    // hostname glob pattern
+	URL       string `yaml:"url,omitempty"`     // URL regular expression
+	ID        string `yaml:"id,omitempty"`      // field ID glob pattern
+	Profile   string `yaml:"profile,omitempty"` // profile name
+	Overrides `yaml:",inline"`
+
+	urlRe *regexp.Regexp // compiled URL regular expression
+}
+
+// Settings represents the effective settings for a single edit request, global
+// configuration decorated by the profile and the matching rules.
+type Settings struct {
+	Profile                string          // selected profile name
+	Format                 string          // payload format, empty for auto detection
+	TrailingNewline        string          // trailing newline policy
+	TrimTrailingWhitespace bool            // trim trailing whitespace on every line
+	PreFilters             []filter.Filter // filters before editing
+	PostFilters            []filter.Filter // filters after editing
+	Template               string          // template name for empty fields
+	Storage                string          // temporary file storage
+	Extension              string          // temporary file name extension
+}
+
+// Metadata represents the edit request metadata informed by the client.
+type Metadata struct {
+	URL       string // page URL
+	ID        string // field ID
+	Title     string // page title
+	Format    string // payload format hint
+	Profile   string // profile name
+	Template  string // template name hint
+	Extension string // file name extension hint
+}
+
+const (
+	// FormatHTML the payload is HTML, edited as Markdown.
+	FormatHTML = "html"
+	// FormatText the payload is plain text.
+	FormatText = "text"
+)
+
+// compile validates the rule attributes and compiles the URL expression.
+func (r *Rule) compile() error {
+	if r.Host == "" && r.URL == "" && r.ID == "" {
+		return fmt.Errorf("%w: rule without host, url or id", ErrInvalidConfig)
+	}
+	if _, err := path.Match(r.Host, ""); err != nil {
+		return fmt.Errorf("%w: rule host %q: %w", ErrInvalidConfig, r.Host, err)
+	}
+	if _, err := path.Match(r.ID, ""); err != nil {
+		return fmt.Errorf("%w: rule id %q: %w", ErrInvalidConfig, r.ID, err)
+	}
+	if r.URL != "" {
+		var err error
+		if r.urlRe, err = regexp.Compile(r.URL); err != nil {
+			return fmt.Errorf("%w: rule url %q: %w", ErrInvalidConfig, r.URL, err)
+		}
+	}
+	return r.Overrides.validate()
+}
+
+// ErrProfileNotFound the informed profile is not declared.
+var ErrProfileNotFound = errors.New("profile not found")
+
+// Matches asserts the rule matches the request metadata.
+func (r *Rule) Matches(m *Metadata) bool {
+	if r.Host != "" {
+		u, err := url.Parse(m.URL)
+		if err != nil {
+			return false
+		}
+		if ok, _ := path.Match(r.Host, u.Hostname()); !ok {
+			return false
+		}
+	}
+	if r.urlRe != nil && !r.urlRe.MatchString(m.URL) {
+		return false
+	}
+	if r.ID != "" {
+		if ok, _ := path.Match(r.ID, m.ID); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// validate validates the informed override values.
+func (o *Overrides) validate() error {
+	switch o.Format {
+	case "", FormatHTML, FormatText:
+	default:
+		return fmt.Errorf("%w: invalid format %q", ErrInvalidConfig, o.Format)
+	}
+	if o.TrailingNewline != "" &&
+		!slices.Contains(codec.TrailingNewlinePolicies, o.TrailingNewline) {
+		return fmt.Errorf("%w: invalid trailing newline policy %q",
+			ErrInvalidConfig, o.TrailingNewline)
+	}
+	if o.Storage != "" && !slices.Contains(file.Storages, o.Storage) {
+		return fmt.Errorf("%w: invalid storage %q", ErrInvalidConfig, o.Storage)
+	}
+	if _, err := file.NormalizeExtension(o.Extension); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	for _, f := range append(append([]filter.Filter{}, o.PreFilters...),
+		o.PostFilters...) {
+		if f.Command == "" {
+			return fmt.Errorf("%w: filter without command", ErrInvalidConfig)
+		}
+	}
+	return nil
+}
+
+// apply decorates the settings with the informed overrides.
+func (o *Overrides) apply(s *Settings) {
+	if o.Format != "" {
+		s.Format = o.Format
+	}
+	if o.TrailingNewline != "" {
+		s.TrailingNewline = o.TrailingNewline
+	}
+	if o.TrimTrailingWhitespace != nil {
+		s.TrimTrailingWhitespace = *o.TrimTrailingWhitespace
+	}
+	if o.PreFilters != nil {
+		s.PreFilters = o.PreFilters
+	}
+	if o.PostFilters != nil {
+		s.PostFilters = o.PostFilters
+	}
+	if o.Template != "" {
+		s.Template = o.Template
+	}
+	if o.Storage != "" {
+		s.Storage = o.Storage
+	}
+	if o.Extension != "" {
+		s.Extension, _ = file.NormalizeExtension(o.Extension)
+	}
+}
+
+// SettingsFor returns the effective settings for the request metadata, based on
+// the global configuration, decorated by the profile and then the matching
+// rules, in order. The profile is informed by the client, or by the last
+// matching rule naming one. The format, template and extension hints informed
+// by the client take precedence.
+func (c *Config) SettingsFor(m *Metadata) (*Settings, error) {
+	s := &Settings{
+		Profile:                m.Profile,
+		TrailingNewline:        c.TrailingNewline,
+		TrimTrailingWhitespace: c.TrimTrailingWhitespace,
+		Storage:                c.Storage,
+		PreFilters:             filter.FromCommands(c.PreFilters),
+		PostFilters:            filter.FromCommands(c.PostFilters),
+	}
+	matching := []*Rule{}
+	for i := range c.Rules {
+		if c.Rules[i].Matches(m) {
+			matching = append(matching, &c.Rules[i])
+			if m.Profile == "" && c.Rules[i].Profile != "" {
+				s.Profile = c.Rules[i].Profile
+			}
+		}
+	}
+	if s.Profile != "" {
+		p, ok := c.Profiles[s.Profile]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrProfileNotFound, s.Profile)
+		}
+		p.apply(s)
+	}
+	for _, r := range matching {
+		r.Overrides.apply(s)
+	}
+	if m.Format != "" {
+		s.Format = m.Format
+	}
+	if m.Template != "" {
+		s.Template = m.Template
+	}
+	if m.Extension != "" {
+		ext, err := file.NormalizeExtension(m.Extension)
+		if err != nil {
+			return nil, err
+		}
+		s.Extension = ext
+	}
+	return s, nil
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/codec"
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
+	"github.com/otaviof/edsrv/pkg/edsrv/filter"
+)
+
+const rulesConfigFile = `---
+profiles:
+  prose:
+    trailingNewline: untouched
+    storage: memory
+    extension: md
+    preFilters:
+      - command: "fmt -w 72"
+        timeout: 2s
+rules:
+  - host: "docs.example.com"
+    profile: prose
+  - host: "*.slack.com"
+    trailingNewline: strip
+  - url: "^https://github.com/.+/issues/new"
+    id: "issue_body"
+    trimTrailingWhitespace: true
+`
+
+func TestConfig_SettingsFor(t *testing.T) {
+	c := NewConfig()
+	c.ConfigFile = filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(c.ConfigFile, []byte(rulesConfigFile), 0o600)
+	if err != nil {
+		t.Fatalf("unable to write configuration file: %v", err)
+	}
+	if err = c.LoadConfigFile(); err != nil {
+		t.Fatalf("Config.LoadConfigFile() error = %v", err)
+	}
+
+	prose := []filter.Filter{{Command: "fmt -w 72", Timeout: 2 * time.Second}}
+
+	tests := []struct {
+		name    string
+		meta    *Metadata
+		want    Settings
+		wantErr bool
+	}{{
+		name: "no matching rules",
+		meta: &Metadata{URL: "https://example.com"},
+		want: Settings{
+			TrailingNewline: codec.TrailingNewlinePreserve,
+			Storage:         file.StorageDisk,
+		},
+	}, {
+		name: "host rule",
+		meta: &Metadata{URL: "https://team.slack.com/messages"},
+		want: Settings{
+			TrailingNewline: codec.TrailingNewlineStrip,
+			Storage:         file.StorageDisk,
+		},
+	}, {
+		name: "url and id rule",
+		meta: &Metadata{
+			URL: "https://github.com/otaviof/edsrv/issues/new",
+			ID:  "issue_body",
+		},
+		want: Settings{
+			TrailingNewline:        codec.TrailingNewlinePreserve,
+			TrimTrailingWhitespace: true,
+			Storage:                file.StorageDisk,
+		},
+	}, {
+		name: "url rule with another id",
+		meta: &Metadata{
+			URL: "https://github.com/otaviof/edsrv/issues/new",
+			ID:  "issue_title",
+		},
+		want: Settings{
+			TrailingNewline: codec.TrailingNewlinePreserve,
+			Storage:         file.StorageDisk,
+		},
+	}, {
+		name: "profile selected by rule",
+		meta: &Metadata{URL: "https://docs.example.com/page"},
+		want: Settings{
+			Profile:         "prose",
+			TrailingNewline: codec.TrailingNewlineUntouched,
+			PreFilters:      prose,
+			Storage:         file.StorageMemory,
+			Extension:       ".md",
+		},
+	}, {
+		name: "profile informed by the client, rules take precedence",
+		meta: &Metadata{URL: "https://team.slack.com/messages", Profile: "prose"},
+		want: Settings{
+			Profile:         "prose",
+			TrailingNewline: codec.TrailingNewlineStrip,
+			PreFilters:      prose,
+			Storage:         file.StorageMemory,
+			Extension:       ".md",
+		},
+	}, {
+		name: "extension informed by the client takes precedence",
+		meta: &Metadata{URL: "https://docs.example.com/page", Extension: "txt"},
+		want: Settings{
+			Profile:         "prose",
+			TrailingNewline: codec.TrailingNewlineUntouched,
+			PreFilters:      prose,
+			Storage:         file.StorageMemory,
+			Extension:       ".txt",
+		},
+	}, {
+		name:    "invalid extension",
+		meta:    &Metadata{URL: "https://example.com", Extension: "../md"},
+		wantErr: true,
+	}, {
+		name:    "unknown profile",
+		meta:    &Metadata{URL: "https://example.com", Profile: "unknown"},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.SettingsFor(tt.meta)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Config.SettingsFor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Config.SettingsFor() = %+v, expected %+v", *got, tt.want)
+			}
+		})
+	}
+}
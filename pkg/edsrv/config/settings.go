@@ -0,0 +1,201 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Source where a configuration value came from.
+type Source string
+
+const (
+	// SourceDefault the built-in default value.
+	SourceDefault Source = "default"
+	// SourceFile the configuration file "settings".
+	SourceFile Source = "file"
+	// SourceEnv the environment variables the default value is based on.
+	SourceEnv Source = "env"
+	// SourceFlag the command-line flag.
+	SourceFlag Source = "flag"
+)
+
+// Value represents a flag value and where it came from.
+type Value struct {
+	Name   string `json:"name"`   // flag name
+	Value  string `json:"value"`  // effective value
+	Source Source `json:"source"` // where the value came from
+}
+
+// envVars the environment variables the flags default value is based on.
+var envVars = map[string][]string{
+	ConfigFlag:       {"XDG_CONFIG_HOME"},
+	TmpDirFlag:       {"TMPDIR"},
+	EditorFlag:       {"VISUAL", "EDITOR"},
+	NvimListenFlag:   {"NVIM"},
+	OTLPEndpointFlag: {"OTEL_EXPORTER_OTLP_ENDPOINT"},
+	TemplatesDirFlag: {"XDG_CONFIG_HOME"},
+	StateDirFlag:     {"XDG_STATE_HOME"},
+}
+
+// startFlags returns the "start" subcommand flags, the settings informed by the
+// configuration file.
+func startFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("start", pflag.ContinueOnError)
+	NewConfig().AddStartFlags(fs)
+	return fs
+}
+
+// settingsValues converts the configuration file settings into flag values,
+// lists are informed as repeated flags.
+func settingsValues(settings map[string]any) (map[string][]string, error) {
+	values := map[string][]string{}
+	for name, v := range settings {
+		switch v := v.(type) {
+		case nil, map[string]any:
+			return nil, fmt.Errorf("setting %q: invalid value %v", name, v)
+		case []any:
+			values[name] = []string{}
+			for _, item := range v {
+				switch item.(type) {
+				case nil, map[string]any, []any:
+					return nil, fmt.Errorf("setting %q: invalid value %v", name, item)
+				}
+				values[name] = append(values[name], fmt.Sprint(item))
+			}
+		default:
+			values[name] = []string{fmt.Sprint(v)}
+		}
+	}
+	return values, nil
+}
+
+// ApplySettings informs the configuration file settings on the flags not informed
+// on the command-line, the command-line flags take precedence. The settings are
+// named after the "start" subcommand flags, the ones absent on the flag set are
+// skipped. The configuration file must be loaded before, see LoadConfigFile.
+func (c *Config) ApplySettings(fs *pflag.FlagSet) error {
+	known := startFlags()
+	names := make([]string, 0, len(c.settings))
+	for name := range c.settings {
+		if known.Lookup(name) == nil {
+			return fmt.Errorf("%w: %q: unknown setting %q",
+				ErrInvalidConfig, c.ConfigFile, name)
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		f := fs.Lookup(name)
+		if f == nil || f.Changed {
+			continue
+		}
+		for _, v := range c.settings[name] {
+			if err := fs.Set(name, v); err != nil {
+				return fmt.Errorf("%w: %q: setting %q: %w",
+					ErrInvalidConfig, c.ConfigFile, name, err)
+			}
+			c.fromFile[name] = true
+		}
+	}
+	return nil
+}
+
+// Source returns where the flag value came from.
+func (c *Config) Source(f *pflag.Flag) Source {
+	switch {
+	case c.fromFile[f.Name]:
+		return SourceFile
+	case f.Changed:
+		return SourceFlag
+	}
+	for _, name := range envVars[f.Name] {
+		if os.Getenv(name) != "" {
+			return SourceEnv
+		}
+	}
+	return SourceDefault
+}
+
+// Values returns the flags effective value and source, sorted by name.
+func (c *Config) Values(fs *pflag.FlagSet) []Value {
+	values := []Value{}
+	fs.VisitAll(func(f *pflag.Flag) {
+		values = append(values, Value{
+			Name:   f.Name,
+			Value:  f.Value.String(),
+			Source: c.Source(f),
+		})
+	})
+	return values
+}
+
+// starterValue renders the flag default value as YAML.
+func starterValue(f *pflag.Flag) string {
+	quote := f.Value.Type() == "string" || f.Value.Type() == "stringArray"
+	sv, ok := f.Value.(pflag.SliceValue)
+	if !ok {
+		if quote {
+			return strconv.Quote(f.Value.String())
+		}
+		return f.Value.String()
+	}
+	items := []string{}
+	for _, item := range sv.GetSlice() {
+		if item == "" {
+			continue
+		}
+		if quote {
+			item = strconv.Quote(item)
+		}
+		items = append(items, item)
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// starterHeader the starter configuration file header.
+const starterHeader = `---
+# edsrv configuration file, "edsrv config show" prints the effective values
+# and where each one came from, "edsrv config validate" checks this file.
+
+# Global settings, named after the "edsrv start" flags, repeated flags are
+# informed as lists. The command-line flags take precedence.
+settings:
+`
+
+// starterFooter the starter configuration file profiles and rules examples.
+const starterFooter = `
+# Named settings profiles, selected by the "X-Edsrv-Profile" request header, or
+# by the profile of the matching rules.
+# profiles:
+#   prose:
+#     trailingNewline: untouched
+#     extension: md
+#     postFilters:
+#       - command: "fmt -w 72"
+
+# Site specific settings, the rules matching the page "host" (glob), "url"
+# (regular expression) and field "id" (glob) are applied in order.
+# rules:
+#   - host: "*.slack.com"
+#     trailingNewline: strip
+#   - host: "mail.example.com"
+#     profile: prose
+`
+
+// StarterFile returns a commented starter configuration file, showing every
+// setting with its default value.
+func StarterFile() []byte {
+	var b bytes.Buffer
+	b.WriteString(starterHeader)
+	startFlags().VisitAll(func(f *pflag.Flag) {
+		fmt.Fprintf(&b, "  # %s\n  # %s: %s\n", f.Usage, f.Name, starterValue(f))
+	})
+	b.WriteString(starterFooter)
+	return b.Bytes()
+}
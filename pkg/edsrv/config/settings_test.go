@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+const settingsConfigFile = `---
+settings:
+  addr: "127.0.0.1:9999"
+  storage: memory
+  history: true
+  abort-exit-code: [1, 2]
+  editor:
+    - "code -w"
+    - "vim"
+`
+
+func TestConfig_ApplySettings(t *testing.T) {
+	t.Setenv("NVIM", "/run/nvim.sock")
+
+	tests := []struct {
+		name    string
+		content string
+		args    []string
+		want    map[string]Value
+		wantErr bool
+	}{{
+		name:    "settings, flags and environment",
+		content: settingsConfigFile,
+		args:    []string{"--storage=disk"},
+		want: map[string]Value{
+			AddrFlag:          {Value: "127.0.0.1:9999", Source: SourceFile},
+			StorageFlag:       {Value: "disk", Source: SourceFlag},
+			HistoryFlag:       {Value: "true", Source: SourceFile},
+			AbortExitCodeFlag: {Value: "[1,2]", Source: SourceFile},
+			EditorFlag:        {Value: "[code -w,vim]", Source: SourceFile},
+			NvimListenFlag:    {Value: "/run/nvim.sock", Source: SourceEnv},
+			NvimOpenFlag:      {Value: "tab", Source: SourceDefault},
+		},
+	}, {
+		name:    "unknown setting",
+		content: "settings:\n  unknown: true\n",
+		wantErr: true,
+	}, {
+		name:    "logging setting",
+		content: "settings:\n  log-level: info\n",
+		wantErr: true,
+	}, {
+		name:    "invalid value",
+		content: "settings:\n  history-max-count: many\n",
+		wantErr: true,
+	}, {
+		name:    "nested value",
+		content: "settings:\n  editor:\n    command: vim\n",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig()
+			c.ConfigFile = filepath.Join(t.TempDir(), "config.yaml")
+			err := os.WriteFile(c.ConfigFile, []byte(tt.content), 0o600)
+			if err != nil {
+				t.Fatalf("unable to write configuration file: %v", err)
+			}
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			c.AddLogLevelFlag(fs)
+			c.AddStartFlags(fs)
+			if err = fs.Parse(tt.args); err != nil {
+				t.Fatalf("unable to parse flags: %v", err)
+			}
+
+			err = c.LoadConfigFile()
+			if err == nil {
+				err = c.ApplySettings(fs)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Config.ApplySettings() error = %v, wantErr %v",
+					err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, v := range c.Values(fs) {
+				want, ok := tt.want[v.Name]
+				if !ok {
+					continue
+				}
+				if v.Value != want.Value || v.Source != want.Source {
+					t.Errorf("Config.Values() %q = %q (%s), expected %q (%s)",
+						v.Name, v.Value, v.Source, want.Value, want.Source)
+				}
+			}
+		})
+	}
+}
+
+func TestStarterFile(t *testing.T) {
+	// the commented settings must load once uncommented
+	uncomment := regexp.MustCompile(`(?m)^  # ([a-z-]+: .*)$`)
+	content := uncomment.ReplaceAll(StarterFile(), []byte("  $1"))
+
+	c := NewConfig()
+	c.ConfigFile = filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(c.ConfigFile, content, 0o600); err != nil {
+		t.Fatalf("unable to write configuration file: %v", err)
+	}
+	if err := c.LoadConfigFile(); err != nil {
+		t.Fatalf("Config.LoadConfigFile() error = %v", err)
+	}
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	c.AddStartFlags(fs)
+	if err := c.ApplySettings(fs); err != nil {
+		t.Fatalf("Config.ApplySettings() error = %v", err)
+	}
+	if len(c.settings) == 0 {
+		t.Errorf("StarterFile() settings are empty, expected the defaults")
+	}
+}
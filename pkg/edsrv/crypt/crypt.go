@@ -0,0 +1,236 @@
+package crypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Params represents the key derivation parameters, stored next to the encrypted
+// content. The check value asserts the secret is the right one.
+type Params struct {
+	Version int    `json:"version"` // parameters format version
+	Salt    []byte `json:"salt"`    // scrypt salt
+	N       int    `json:"n"`       // scrypt cost
+	R       int    `json:"r"`       // scrypt block size
+	P       int    `json:"p"`       // scrypt parallelization
+	Check   []byte `json:"check"`   // sealed check value
+}
+
+// Cipher represents the authenticated encryption (AES-256-GCM) of the stored
+// content.
+type Cipher struct {
+	aead cipher.AEAD // AES-GCM instance
+}
+
+const (
+	// magic prefix of the sealed content.
+	magic = "edsrv:enc:v1\n"
+	// keyLen AES-256 key length.
+	keyLen = 32
+	// saltLen scrypt salt length.
+	saltLen = 16
+	// checkValue value sealed on the parameters to verify the secret.
+	checkValue = "edsrv"
+	// commandTimeout maximum time for the keyring helper command.
+	commandTimeout = 30 * time.Second
+)
+
+var (
+	// ErrWrongKey the secret doesn't match the stored key parameters.
+	ErrWrongKey = errors.New("wrong encryption key")
+	// ErrCorrupted the sealed content fails the authentication.
+	ErrCorrupted = errors.New("corrupted or tampered content")
+	// ErrNotSealed the content is stored in plain text.
+	ErrNotSealed = errors.New("content is not encrypted")
+	// ErrSecret the secret can't be read from the key file or command.
+	ErrSecret = errors.New("unable to read secret")
+)
+
+// IsSealed asserts the content is sealed (encrypted).
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(magic))
+}
+
+// Seal encrypts and authenticates the data, the additional data is
+// authenticated but not stored (i.e. the file location).
+func (c *Cipher) Seal(data, additional []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(magic)+len(nonce)+len(data)+c.aead.Overhead())
+	out = append(out, magic...)
+	out = append(out, nonce...)
+	return c.aead.Seal(out, nonce, data, additional), nil
+}
+
+// Open decrypts and authenticates the sealed data, using the same additional
+// data informed when sealing.
+func (c *Cipher) Open(sealed, additional []byte) ([]byte, error) {
+	if !IsSealed(sealed) {
+		return nil, ErrNotSealed
+	}
+	sealed = sealed[len(magic):]
+	if len(sealed) < c.aead.NonceSize() {
+		return nil, ErrCorrupted
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	data, err := c.aead.Open(nil, nonce, ciphertext, additional)
+	if err != nil {
+		return nil, ErrCorrupted
+	}
+	return data, nil
+}
+
+// NewCipher instantiates the cipher for the 256 bits key.
+func NewCipher(key []byte) (*Cipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// derive derives the cipher from the secret using the parameters.
+func (p *Params) derive(secret []byte) (*Cipher, error) {
+	key, err := Scrypt(secret, p.Salt, p.N, p.R, p.P, keyLen)
+	if err != nil {
+		return nil, err
+	}
+	return NewCipher(key)
+}
+
+// Unlock derives the cipher from the secret, asserting the secret matches the
+// check value.
+func (p *Params) Unlock(secret []byte) (*Cipher, error) {
+	c, err := p.derive(secret)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = c.Open(p.Check, []byte(checkValue)); err != nil {
+		return nil, ErrWrongKey
+	}
+	return c, nil
+}
+
+// Save writes the parameters on the informed file, atomically.
+func (p *Params) Save(name string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// NewParams generates new key derivation parameters for the secret, returns the
+// parameters and the derived cipher.
+func NewParams(secret []byte) (*Params, *Cipher, error) {
+	p := &Params{Version: 1, Salt: make([]byte, saltLen), N: 1 << 15, R: 8, P: 1}
+	if _, err := rand.Read(p.Salt); err != nil {
+		return nil, nil, err
+	}
+	c, err := p.derive(secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.Check, err = c.Seal([]byte(checkValue), []byte(checkValue)); err != nil {
+		return nil, nil, err
+	}
+	return p, c, nil
+}
+
+// LoadParams reads the parameters file, returns nil when the file doesn't exist.
+func LoadParams(name string) (*Params, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil //nolint:nilnil
+		}
+		return nil, err
+	}
+	p := &Params{}
+	if err = json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("%q: %w", name, err)
+	}
+	return p, nil
+}
+
+// Open unlocks the cipher using the parameters file, when the file doesn't exist
+// new parameters are generated and saved.
+func Open(name string, secret []byte) (*Cipher, error) {
+	p, err := LoadParams(name)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		return p.Unlock(secret)
+	}
+	p, c, err := NewParams(secret)
+	if err != nil {
+		return nil, err
+	}
+	return c, p.Save(name)
+}
+
+// ReadSecret reads the secret from the key file, which must not be accessible by
+// other users, or from the standard output of the keyring helper command. The
+// trailing newline is removed.
+func ReadSecret(keyFile, keyCommand string) ([]byte, error) {
+	var secret []byte
+	switch {
+	case keyFile != "":
+		info, err := os.Stat(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSecret, err)
+		}
+		if info.Mode().Perm()&0o077 != 0 {
+			return nil, fmt.Errorf("%w: %q is accessible by other users (%s)",
+				ErrSecret, keyFile, info.Mode().Perm())
+		}
+		if secret, err = os.ReadFile(keyFile); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSecret, err)
+		}
+	case keyCommand != "":
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		defer cancel()
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "sh", "-c", keyCommand) //nolint:gosec
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %w (%s)", ErrSecret, keyCommand, err,
+				strings.TrimSpace(stderr.String()))
+		}
+		secret = out
+	default:
+		return nil, fmt.Errorf("%w: key file or command is not informed", ErrSecret)
+	}
+	secret = bytes.TrimRight(secret, "\r\n")
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("%w: secret is empty", ErrSecret)
+	}
+	return secret, nil
+}
@@ -0,0 +1,90 @@
+package crypt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCipher(t *testing.T) {
+	params := filepath.Join(t.TempDir(), "key.json")
+	c, err := Open(params, []byte("secret"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	sealed, err := c.Seal([]byte("text"), []byte("entry/edited"))
+	if err != nil {
+		t.Fatalf("Cipher.Seal() error = %v", err)
+	}
+	if !IsSealed(sealed) {
+		t.Errorf("IsSealed() = false, expected true")
+	}
+
+	// reopening with the same secret unlocks the same key
+	c, err = Open(params, []byte("secret"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data, err := c.Open(sealed, []byte("entry/edited"))
+	if err != nil {
+		t.Fatalf("Cipher.Open() error = %v", err)
+	}
+	if string(data) != "text" {
+		t.Errorf("Cipher.Open() = %q, expected %q", data, "text")
+	}
+
+	// moved or tampered content fails the authentication
+	if _, err = c.Open(sealed, []byte("entry/original")); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("Cipher.Open() error = %v, expected %v", err, ErrCorrupted)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err = c.Open(sealed, []byte("entry/edited")); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("Cipher.Open() error = %v, expected %v", err, ErrCorrupted)
+	}
+
+	if _, err = Open(params, []byte("wrong")); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Open() error = %v, expected %v", err, ErrWrongKey)
+	}
+}
+
+func TestReadSecret(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("from file\n"), 0o644); err != nil {
+		t.Fatalf("unable to write key file: %v", err)
+	}
+	if _, err := ReadSecret(keyFile, ""); !errors.Is(err, ErrSecret) {
+		t.Errorf("ReadSecret() error = %v, expected %v", err, ErrSecret)
+	}
+	if err := os.Chmod(keyFile, 0o600); err != nil {
+		t.Fatalf("unable to change mode: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		keyFile    string
+		keyCommand string
+		want       string
+	}{{
+		name:    "key file",
+		keyFile: keyFile,
+		want:    "from file",
+	}, {
+		name:       "keyring helper command",
+		keyCommand: "echo from command",
+		want:       "from command",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadSecret(tt.keyFile, tt.keyCommand)
+			if err != nil {
+				t.Fatalf("ReadSecret() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ReadSecret() = %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
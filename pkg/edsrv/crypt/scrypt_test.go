@@ -0,0 +1,56 @@
+package crypt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// RFC 7914, section 11
+	got := pbkdf2([]byte("passwd"), []byte("salt"), 1, 64, sha256.New)
+	want := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+		"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"
+	if hex.EncodeToString(got) != want {
+		t.Errorf("pbkdf2() = %x, expected %s", got, want)
+	}
+}
+
+func TestScrypt(t *testing.T) {
+	// RFC 7914, section 12
+	tests := []struct {
+		password string
+		salt     string
+		n, r, p  int
+		want     string
+	}{{
+		password: "",
+		salt:     "",
+		n:        16, r: 1, p: 1,
+		want: "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442" +
+			"fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906",
+	}, {
+		password: "password",
+		salt:     "NaCl",
+		n:        1024, r: 8, p: 16,
+		want: "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b373162" +
+			"2eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			got, err := Scrypt([]byte(tt.password), []byte(tt.salt),
+				tt.n, tt.r, tt.p, 64)
+			if err != nil {
+				t.Fatalf("Scrypt() error = %v", err)
+			}
+			if hex.EncodeToString(got) != tt.want {
+				t.Errorf("Scrypt() = %x, expected %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Scrypt(nil, nil, 15, 1, 1, 32); err == nil {
+		t.Errorf("Scrypt() expected error for invalid cost parameter")
+	}
+}
@@ -0,0 +1,294 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const (
+	// PlatformLinux systemd user units.
+	PlatformLinux = "linux"
+	// PlatformDarwin launchd user agent.
+	PlatformDarwin = "darwin"
+
+	// Label the launchd service label.
+	Label = "io.github.otaviof.edsrv"
+	// ServiceUnit the systemd service unit name.
+	ServiceUnit = "edsrv.service"
+	// SocketUnit the systemd socket unit name.
+	SocketUnit = "edsrv.socket"
+
+	// SystemdRuntimeDir the systemd specifier for the user runtime directory,
+	// "$XDG_RUNTIME_DIR".
+	SystemdRuntimeDir = "%t"
+)
+
+var (
+	// ErrUnsupportedPlatform the platform has no supported service manager.
+	ErrUnsupportedPlatform = errors.New("unsupported platform")
+	// ErrSocketUnsupported socket activation is only supported by systemd.
+	ErrSocketUnsupported = errors.New("socket activation is only supported by systemd")
+)
+
+// EnvVar represents a environment variable informed to the service.
+type EnvVar struct {
+	Name  string // variable name
+	Value string // variable value
+}
+
+// Definition represents the service attributes, rendered as the platform service
+// definition files.
+type Definition struct {
+	Executable string   // edsrv executable full path
+	Args       []string // command-line arguments, after the executable
+	Env        []EnvVar // environment variables
+	Addr       string   // listen address, for the socket unit
+	Socket     bool     // systemd socket activation
+}
+
+// Layout represents the service definition locations.
+type Layout struct {
+	Dir       string // service definitions directory
+	LogFile   string // service log file, rotated by edsrv, launchd only
+	ErrorFile string // service standard error, i.e. crashes, launchd only
+}
+
+// File represents a service definition file.
+type File struct {
+	Path    string // full path
+	Content []byte // file content
+}
+
+// DefaultLayout returns the user service definition locations for the platform,
+// "$XDG_CONFIG_HOME/systemd/user" for systemd and "~/Library/LaunchAgents" for
+// launchd.
+func DefaultLayout(platform string) (*Layout, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	switch platform {
+	case PlatformLinux:
+		config := os.Getenv("XDG_CONFIG_HOME")
+		if !filepath.IsAbs(config) {
+			config = filepath.Join(home, ".config")
+		}
+		return &Layout{Dir: filepath.Join(config, "systemd", "user")}, nil
+	case PlatformDarwin:
+		logs := filepath.Join(home, "Library", "Logs")
+		return &Layout{
+			Dir:       filepath.Join(home, "Library", "LaunchAgents"),
+			LogFile:   filepath.Join(logs, "edsrv.log"),
+			ErrorFile: filepath.Join(logs, "edsrv-err.log"),
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedPlatform, platform)
+	}
+}
+
+// Paths returns all the service definition files the platform may have.
+func Paths(platform string, l *Layout) ([]string, error) {
+	switch platform {
+	case PlatformLinux:
+		return []string{
+			filepath.Join(l.Dir, ServiceUnit),
+			filepath.Join(l.Dir, SocketUnit),
+		}, nil
+	case PlatformDarwin:
+		return []string{filepath.Join(l.Dir, Label+".plist")}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedPlatform, platform)
+	}
+}
+
+// systemdQuote quotes the argument for the unit "ExecStart", escaping the
+// specifiers and variables expansion.
+func systemdQuote(arg string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$")
+	return `"` + r.Replace(arg) + `"`
+}
+
+// systemdEnv quotes the environment variable assignment, the specifiers are
+// expanded, i.e. SystemdRuntimeDir.
+func systemdEnv(e EnvVar) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(e.Name+"="+e.Value) + `"`
+}
+
+// xmlEscape escapes the text for the plist.
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+var funcs = template.FuncMap{
+	"systemdQuote": systemdQuote,
+	"systemdEnv":   systemdEnv,
+	"xml":          xmlEscape,
+}
+
+var serviceUnitTmpl = template.Must(template.New(ServiceUnit).Funcs(funcs).Parse(
+	`[Unit]
+Description=edsrv, edit-server for browser extensions
+Documentation=https://github.com/otaviof/edsrv
+{{- if .Socket }}
+Requires=` + SocketUnit + `
+After=` + SocketUnit + `
+{{- end }}
+
+[Service]
+Type=simple
+ExecStart={{ systemdQuote .Executable }}{{ range .Args }} {{ systemdQuote . }}{{ end }}
+{{- range .Env }}
+Environment={{ systemdEnv . }}
+{{- end }}
+Restart=on-failure
+
+[Install]
+WantedBy=default.target
+`))
+
+var socketUnitTmpl = template.Must(template.New(SocketUnit).Parse(
+	`[Unit]
+Description=edsrv socket, edit-server for browser extensions
+Documentation=https://github.com/otaviof/edsrv
+
+[Socket]
+ListenStream={{ .Addr }}
+
+[Install]
+WantedBy=sockets.target
+`))
+
+var plistTmpl = template.Must(template.New(Label).Funcs(funcs).Parse(
+	`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" ` +
+		`"http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+    <key>Label</key>
+    <string>` + Label + `</string>
+    <key>ServiceDescription</key>
+    <string>edit-server for browser extensions</string>
+    <key>ProgramArguments</key>
+    <array>
+        <string>{{ xml .Executable }}</string>
+        {{- range .Args }}
+        <string>{{ xml . }}</string>
+        {{- end }}
+    </array>
+    {{- if .Env }}
+    <key>EnvironmentVariables</key>
+    <dict>
+        {{- range .Env }}
+        <key>{{ xml .Name }}</key>
+        <string>{{ xml .Value }}</string>
+        {{- end }}
+    </dict>
+    {{- end }}
+    {{- if .ErrorFile }}
+    <key>StandardErrorPath</key>
+    <string>{{ xml .ErrorFile }}</string>
+    {{- end }}
+    <key>RunAtLoad</key>
+    <true/>
+    <key>KeepAlive</key>
+    <true/>
+</dict>
+</plist>
+`))
+
+// launchdArgs appends the log file flag to the arguments, unless informed, so
+// the log file is rotated by edsrv instead of growing on launchd output.
+func launchdArgs(args []string, logFile string) []string {
+	if logFile == "" {
+		return args
+	}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--log-file=") {
+			return args
+		}
+	}
+	return append(append([]string{}, args...), "--log-file="+logFile)
+}
+
+// render executes the template with the data.
+func render(tmpl *template.Template, data any) ([]byte, error) {
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Render renders the service definition files for the platform, the systemd
+// service unit and optionally the socket unit, or the launchd plist.
+func Render(platform string, l *Layout, d *Definition) ([]*File, error) {
+	paths, err := Paths(platform, l)
+	if err != nil {
+		return nil, err
+	}
+	if platform == PlatformDarwin {
+		if d.Socket {
+			return nil, ErrSocketUnsupported
+		}
+		content, err := render(plistTmpl, struct {
+			*Definition
+			Args      []string
+			ErrorFile string
+		}{d, launchdArgs(d.Args, l.LogFile), l.ErrorFile})
+		if err != nil {
+			return nil, err
+		}
+		return []*File{{Path: paths[0], Content: content}}, nil
+	}
+
+	content, err := render(serviceUnitTmpl, d)
+	if err != nil {
+		return nil, err
+	}
+	files := []*File{{Path: paths[0], Content: content}}
+	if d.Socket {
+		if content, err = render(socketUnitTmpl, d); err != nil {
+			return nil, err
+		}
+		files = append(files, &File{Path: paths[1], Content: content})
+	}
+	return files, nil
+}
+
+// Install writes the service definition files, only readable by the current
+// user.
+func Install(files []*File) error {
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.Path), 0o700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(f.Path, f.Content, 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Installed returns the service definition files present on the layout.
+func Installed(platform string, l *Layout) ([]string, error) {
+	paths, err := Paths(platform, l)
+	if err != nil {
+		return nil, err
+	}
+	installed := []string{}
+	for _, p := range paths {
+		if _, err = os.Stat(p); err == nil {
+			installed = append(installed, p)
+		}
+	}
+	return installed, nil
+}
@@ -0,0 +1,143 @@
+package daemon
+
+import (
+	"encoding/xml"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	d := &Definition{
+		Executable: "/usr/local/bin/edsrv",
+		Args:       []string{"start", "--editor=code -w", `--pre-filter=fmt "%x" $HOME`},
+		Env: []EnvVar{
+			{Name: "PATH", Value: "/usr/bin:/bin"},
+			{Name: "TMPDIR", Value: SystemdRuntimeDir},
+		},
+		Addr: "127.0.0.1:8928",
+	}
+	l := &Layout{Dir: "/home/user/.config/systemd/user"}
+
+	tests := []struct {
+		name      string
+		platform  string
+		socket    bool
+		wantPaths []string
+		want      []string
+		wantErr   error
+	}{{
+		name:      "systemd service",
+		platform:  PlatformLinux,
+		wantPaths: []string{filepath.Join(l.Dir, ServiceUnit)},
+		want: []string{
+			`ExecStart="/usr/local/bin/edsrv" "start" "--editor=code -w" ` +
+				`"--pre-filter=fmt \"%%x\" $$HOME"`,
+			`Environment="PATH=/usr/bin:/bin"`,
+			`Environment="TMPDIR=%t"`,
+			"WantedBy=default.target",
+		},
+	}, {
+		name:     "systemd service and socket",
+		platform: PlatformLinux,
+		socket:   true,
+		wantPaths: []string{
+			filepath.Join(l.Dir, ServiceUnit),
+			filepath.Join(l.Dir, SocketUnit),
+		},
+		want: []string{
+			"Requires=edsrv.socket",
+			"ListenStream=127.0.0.1:8928",
+		},
+	}, {
+		name:      "launchd plist",
+		platform:  PlatformDarwin,
+		wantPaths: []string{filepath.Join(l.Dir, Label+".plist")},
+		want: []string{
+			"<string>--pre-filter=fmt &#34;%x&#34; $HOME</string>",
+			"<key>PATH</key>",
+			"<string>--log-file=/var/log/edsrv.log</string>",
+			"<key>StandardErrorPath</key>\n    <string>/var/log/edsrv-err.log</string>",
+		},
+	}, {
+		name:     "launchd socket",
+		platform: PlatformDarwin,
+		socket:   true,
+		wantErr:  ErrSocketUnsupported,
+	}, {
+		name:     "unsupported platform",
+		platform: "plan9",
+		wantErr:  ErrUnsupportedPlatform,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d.Socket = tt.socket
+			l.LogFile, l.ErrorFile = "", ""
+			if tt.platform == PlatformDarwin {
+				l.LogFile = "/var/log/edsrv.log"
+				l.ErrorFile = "/var/log/edsrv-err.log"
+			}
+			files, err := Render(tt.platform, l, d)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Render() error = %v, expected %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			paths := []string{}
+			content := ""
+			for _, f := range files {
+				paths = append(paths, f.Path)
+				content += string(f.Content)
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("Render() paths = %v, expected %v", paths, tt.wantPaths)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(content, want) {
+					t.Errorf("Render() = %s\nexpected %q", content, want)
+				}
+			}
+			if tt.platform == PlatformDarwin {
+				if err = xml.Unmarshal(files[0].Content, new(any)); err != nil {
+					t.Errorf("Render() plist is not valid XML: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestManagerCommands(t *testing.T) {
+	c, err := ManagerCommands(PlatformLinux, []string{
+		filepath.Join("user", ServiceUnit),
+		filepath.Join("user", SocketUnit),
+	})
+	if err != nil {
+		t.Fatalf("ManagerCommands() error = %v", err)
+	}
+	want := []string{"systemctl", "--user", "enable", "--now", SocketUnit}
+	if !reflect.DeepEqual(c.Install[1], want) {
+		t.Errorf("ManagerCommands() install = %v, expected %v", c.Install[1], want)
+	}
+	want = []string{"systemctl", "--user", "disable", "--now", ServiceUnit, SocketUnit}
+	if !reflect.DeepEqual(c.Uninstall[0], want) {
+		t.Errorf("ManagerCommands() uninstall = %v, expected %v", c.Uninstall[0], want)
+	}
+}
+
+func TestListener(t *testing.T) {
+	// without socket activation, listens on the address
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+	ln, err := Listener("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listener() error = %v", err)
+	}
+	defer ln.Close()
+	if !strings.HasPrefix(ln.Addr().String(), "127.0.0.1:") {
+		t.Errorf("Listener() addr = %q, expected 127.0.0.1", ln.Addr())
+	}
+}
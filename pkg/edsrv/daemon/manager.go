@@ -0,0 +1,84 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Commands represents the service manager commands for each action, on the
+// informed service definition files.
+type Commands struct {
+	Install   [][]string // enables and starts the service, after writing the files
+	Uninstall [][]string // stops and disables the service, before removing the files
+	Cleanup   [][]string // reloads the service manager, after removing the files
+	Status    [][]string // shows the service status
+}
+
+// systemctl returns the systemctl command for the user service manager.
+func systemctl(args ...string) []string {
+	return append([]string{"systemctl", "--user"}, args...)
+}
+
+// ManagerCommands returns the service manager commands for the platform, on the
+// informed service definition files. With the socket unit, the socket is enabled
+// instead of the service, started on the first connection.
+func ManagerCommands(platform string, paths []string) (*Commands, error) {
+	switch platform {
+	case PlatformLinux:
+		units := []string{}
+		enable := ServiceUnit
+		for _, p := range paths {
+			units = append(units, filepath.Base(p))
+			if filepath.Base(p) == SocketUnit {
+				enable = SocketUnit
+			}
+		}
+		return &Commands{
+			Install: [][]string{
+				systemctl("daemon-reload"),
+				systemctl("enable", "--now", enable),
+			},
+			Uninstall: [][]string{
+				systemctl(append([]string{"disable", "--now"}, units...)...),
+			},
+			Cleanup: [][]string{
+				systemctl("daemon-reload"),
+			},
+			Status: [][]string{
+				systemctl(append([]string{"status", "--no-pager"}, units...)...),
+			},
+		}, nil
+	case PlatformDarwin:
+		c := &Commands{Status: [][]string{{"launchctl", "list", Label}}}
+		for _, p := range paths {
+			c.Install = append(c.Install, []string{"launchctl", "load", "-w", p})
+			c.Uninstall = append(c.Uninstall, []string{"launchctl", "unload", "-w", p})
+		}
+		return c, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedPlatform, platform)
+	}
+}
+
+// listenFDsStart the first file descriptor passed by systemd socket activation.
+const listenFDsStart = 3
+
+// Listener returns the socket passed by systemd socket activation, or listens on
+// the address otherwise.
+func Listener(addr string) (net.Listener, error) {
+	pid, _ := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	fds, _ := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if pid != os.Getpid() || fds < 1 {
+		return net.Listen("tcp4", addr)
+	}
+	// the variables are not inherited by the editor processes
+	_ = os.Unsetenv("LISTEN_PID")
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_FDNAMES")
+	f := os.NewFile(uintptr(listenFDsStart), "systemd-socket")
+	defer f.Close()
+	return net.FileListener(f)
+}
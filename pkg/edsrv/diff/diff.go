@@ -0,0 +1,298 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+)
+
+// Op represents the edit operation on a single element.
+type Op byte
+
+const (
+	// Equal the element is present on both sides.
+	Equal Op = ' '
+	// Insert the element is only present on the new side.
+	Insert Op = '+'
+	// Delete the element is only present on the old side.
+	Delete Op = '-'
+)
+
+// Edit represents a single element edit, with the element index on the old (A)
+// and new (B) sides, the index is -1 when the element is absent on the side.
+type Edit struct {
+	Op Op  // edit operation
+	A  int // old side index
+	B  int // new side index
+}
+
+// context number of unchanged lines around the changes on unified diffs.
+const context = 3
+
+// noNewline marker for lines without a trailing newline on unified diffs.
+const noNewline = "\\ No newline at end of file\n"
+
+// maxCost maximum edit cost searched from each end for the middle snake, larger
+// changes are replaced as a whole block, bounding the time on large rewrites.
+const maxCost = 1024
+
+// myers holds the Myers' linear space state, the forward and backward furthest
+// reaching paths are reused by every sub-problem.
+type myers[T comparable] struct {
+	a, b  []T    // old and new elements
+	vf    []int  // forward furthest reaching x, per diagonal
+	vb    []int  // backward furthest reaching x, per diagonal from the end
+	off   int    // diagonal zero index on vf and vb
+	edits []Edit // edit script
+}
+
+// Compute returns the shortest edit script to transform the old elements (a)
+// into the new (b), using the Myers' algorithm in linear space. Changes costing
+// more than maxCost are not the shortest, replaced as a whole block instead.
+func Compute[T comparable](a, b []T) []Edit {
+	dmax := min((len(a)+len(b)+1)/2, maxCost)
+	m := &myers[T]{
+		a:     a,
+		b:     b,
+		vf:    make([]int, 2*dmax+3),
+		vb:    make([]int, 2*dmax+3),
+		off:   dmax + 1,
+		edits: make([]Edit, 0, max(len(a), len(b))),
+	}
+	m.compare(0, len(a), 0, len(b))
+	return deletesFirst(m.edits)
+}
+
+// deletesFirst sorts each run of changes with the deletions before the
+// insertions, as unified diffs show them.
+func deletesFirst(edits []Edit) []Edit {
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			i++
+			continue
+		}
+		j := i
+		for j < len(edits) && edits[j].Op != Equal {
+			j++
+		}
+		slices.SortStableFunc(edits[i:j], func(x, y Edit) int {
+			return int(y.Op) - int(x.Op)
+		})
+		i = j
+	}
+	return edits
+}
+
+// equal appends the equal elements, starting on the old (a) and new (b) indexes.
+func (m *myers[T]) equal(a, b, n int) {
+	for i := 0; i < n; i++ {
+		m.edits = append(m.edits, Edit{Op: Equal, A: a + i, B: b + i})
+	}
+}
+
+// replace appends the deletion of the old elements followed by the insertion of
+// the new ones.
+func (m *myers[T]) replace(a0, a1, b0, b1 int) {
+	for x := a0; x < a1; x++ {
+		m.edits = append(m.edits, Edit{Op: Delete, A: x, B: -1})
+	}
+	for y := b0; y < b1; y++ {
+		m.edits = append(m.edits, Edit{Op: Insert, A: -1, B: y})
+	}
+}
+
+// compare appends the edit script of the old (a0 to a1) and new (b0 to b1)
+// elements, splitting the sub-problem on the middle snake.
+func (m *myers[T]) compare(a0, a1, b0, b1 int) {
+	prefix := 0
+	for a0+prefix < a1 && b0+prefix < b1 && m.a[a0+prefix] == m.b[b0+prefix] {
+		prefix++
+	}
+	m.equal(a0, b0, prefix)
+	a0, b0 = a0+prefix, b0+prefix
+	suffix := 0
+	for a0 < a1-suffix && b0 < b1-suffix && m.a[a1-suffix-1] == m.b[b1-suffix-1] {
+		suffix++
+	}
+	a1, b1 = a1-suffix, b1-suffix
+
+	if a0 == a1 || b0 == b1 {
+		m.replace(a0, a1, b0, b1)
+	} else if x0, y0, x1, y1, ok := m.middleSnake(a0, a1, b0, b1); ok {
+		m.compare(a0, x0, b0, y0)
+		m.equal(x0, y0, x1-x0)
+		m.compare(x1, a1, y1, b1)
+	} else {
+		m.replace(a0, a1, b0, b1)
+	}
+	m.equal(a1, b1, suffix)
+}
+
+// middleSnake returns the start and end of the middle snake, the diagonal where
+// the forward and backward paths of the shortest edit script overlap. Returns
+// false when the cost from each end exceeds maxCost.
+func (m *myers[T]) middleSnake(a0, a1, b0, b1 int) (int, int, int, int, bool) {
+	n, mm := a1-a0, b1-b0
+	delta := n - mm
+	odd := delta%2 != 0
+	vf, vb, off := m.vf, m.vb, m.off
+	vf[off+1] = 0
+	vb[off+1] = n + 1
+
+	for d := 0; d <= min((n+mm+1)/2, maxCost); d++ {
+		// forward paths, on diagonals k
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1]
+			} else {
+				x = vf[off+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < mm && m.a[a0+x] == m.b[b0+y] {
+				x++
+				y++
+			}
+			vf[off+k] = x
+			if c := k - delta; odd && c >= -(d-1) && c <= d-1 && x >= vb[off+c] {
+				return a0 + sx, b0 + sy, a0 + x, b0 + y, true
+			}
+		}
+		// backward paths, on diagonals delta+c
+		for c := -d; c <= d; c += 2 {
+			var x int
+			if c == -d || (c != d && vb[off+c+1]-1 < vb[off+c-1]) {
+				x = vb[off+c+1] - 1
+			} else {
+				x = vb[off+c-1]
+			}
+			y := x - (c + delta)
+			ex, ey := x, y
+			for x > 0 && y > 0 && m.a[a0+x-1] == m.b[b0+y-1] {
+				x--
+				y--
+			}
+			vb[off+c] = x
+			if k := c + delta; !odd && k >= -d && k <= d && x <= vf[off+k] {
+				return a0 + x, b0 + y, a0 + ex, b0 + ey, true
+			}
+		}
+	}
+	return 0, 0, 0, 0, false
+}
+
+// SplitLines splits the text in lines, keeping the line terminators.
+func SplitLines(text []byte) []string {
+	lines := []string{}
+	for len(text) > 0 {
+		i := bytes.IndexByte(text, '\n')
+		if i < 0 {
+			lines = append(lines, string(text))
+			break
+		}
+		lines = append(lines, string(text[:i+1]))
+		text = text[i+1:]
+	}
+	return lines
+}
+
+// hunkRange formats the unified diff hunk range, the start is the line before
+// the hunk for empty ranges.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// writeLine writes the diff line with the operation prefix.
+func writeLine(out *bytes.Buffer, op Op, line string) {
+	out.WriteByte(byte(op))
+	out.WriteString(line)
+	if !bytes.HasSuffix([]byte(line), []byte("\n")) {
+		out.WriteString("\n" + noNewline)
+	}
+}
+
+// Lines represents the line by line comparison of the old (a) and new (b) texts,
+// the edit script is computed once for the unified diff, ranges and stat.
+type Lines struct {
+	a, b  []string // old and new lines
+	edits []Edit   // lines edit script
+}
+
+// NewLines compares the old (a) and new (b) texts line by line.
+func NewLines(a, b []byte) *Lines {
+	l := &Lines{a: SplitLines(a), b: SplitLines(b)}
+	l.edits = Compute(l.a, l.b)
+	return l
+}
+
+// Unified returns the unified diff of the old (a) and new (b) texts, empty when
+// the texts are equal.
+func Unified(aName, bName string, a, b []byte) []byte {
+	return NewLines(a, b).Unified(aName, bName)
+}
+
+// Unified returns the unified diff, empty when the texts are equal.
+func (l *Lines) Unified(aName, bName string) []byte {
+	al, bl, edits := l.a, l.b, l.edits
+
+	// lines consumed on each side before the edit index
+	posA, posB := make([]int, len(edits)+1), make([]int, len(edits)+1)
+	for i, e := range edits {
+		posA[i+1], posB[i+1] = posA[i], posB[i]
+		if e.Op != Insert {
+			posA[i+1]++
+		}
+		if e.Op != Delete {
+			posB[i+1]++
+		}
+	}
+
+	var out bytes.Buffer
+	for i := 0; i < len(edits); {
+		for i < len(edits) && edits[i].Op == Equal {
+			i++
+		}
+		if i == len(edits) {
+			break
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+		}
+		start, end := max(i-context, 0), i
+		for {
+			for end < len(edits) && edits[end].Op != Equal {
+				end++
+			}
+			j := end
+			for j < len(edits) && edits[j].Op == Equal {
+				j++
+			}
+			if j < len(edits) && j-end <= 2*context {
+				end = j
+				continue
+			}
+			end = min(end+context, j)
+			break
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(posA[start], posA[end]-posA[start]),
+			hunkRange(posB[start], posB[end]-posB[start]))
+		for _, e := range edits[start:end] {
+			if e.Op == Insert {
+				writeLine(&out, e.Op, bl[e.B])
+			} else {
+				writeLine(&out, e.Op, al[e.A])
+			}
+		}
+		i = end
+	}
+	return out.Bytes()
+}
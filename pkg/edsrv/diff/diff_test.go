@@ -0,0 +1,203 @@
+package diff
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{{
+		name: "equal",
+		a:    "a\nb\n",
+		b:    "a\nb\n",
+		want: "",
+	}, {
+		name: "change in the middle",
+		a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+		b:    "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+		want: "--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+	}, {
+		name: "insert on empty",
+		a:    "",
+		b:    "new\n",
+		want: "--- a\n+++ b\n@@ -0,0 +1 @@\n+new\n",
+	}, {
+		name: "missing trailing newline",
+		a:    "a\nb",
+		b:    "a\nb\n",
+		want: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+	}, {
+		name: "distant changes make separate hunks",
+		a:    "x\n1\n2\n3\n4\n5\n6\n7\n8\ny\n",
+		b:    "X\n1\n2\n3\n4\n5\n6\n7\n8\nY\n",
+		want: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-x\n+X\n 1\n 2\n 3\n" +
+			"@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-y\n+Y\n",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("a", "b", []byte(tt.a), []byte(tt.b))
+			if string(got) != tt.want {
+				t.Errorf("Unified() =\n%s\nexpected\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// applyRanges applies the ranges in reverse order on the old text, using UTF-16
+// offsets.
+func applyRanges(a string, ranges []Range) string {
+	text := utf16.Encode([]rune(a))
+	for i := len(ranges) - 1; i >= 0; i-- {
+		r := ranges[i]
+		tail := append([]uint16{}, text[r.End:]...)
+		text = text[:r.Start]
+		if r.Op == RangeInsert {
+			text = append(text, utf16.Encode([]rune(r.Text))...)
+		}
+		text = append(text, tail...)
+	}
+	return string(utf16.Decode(text))
+}
+
+func TestRanges(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want []Range
+	}{{
+		name: "equal",
+		a:    "a\nb\n",
+		b:    "a\nb\n",
+		want: []Range{},
+	}, {
+		name: "word replaced",
+		a:    "hello world\nbye\n",
+		b:    "hello there\nbye\n",
+		want: nil,
+	}, {
+		name: "offsets after surrogate pairs",
+		a:    "😀 one\n",
+		b:    "😀 two\n",
+		want: nil,
+	}, {
+		name: "lines inserted and removed",
+		a:    "1\n2\n3\n",
+		b:    "0\n1\n3\n4",
+		want: nil,
+	}, {
+		name: "insert on empty",
+		a:    "",
+		b:    "new",
+		want: []Range{{Op: RangeInsert, Start: 0, End: 0, Text: "new"}},
+	}, {
+		name: "large block replaced as lines",
+		a:    strings.Repeat("a", maxBlockRunes) + "\n",
+		b:    "b\n",
+		want: []Range{{
+			Op: RangeDelete, Start: 0, End: maxBlockRunes + 1,
+			Text: strings.Repeat("a", maxBlockRunes) + "\n",
+		}, {
+			Op: RangeInsert, Start: maxBlockRunes + 1, End: maxBlockRunes + 1,
+			Text: "b\n",
+		}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Ranges([]byte(tt.a), []byte(tt.b))
+			if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ranges() = %#v, expected %#v", got, tt.want)
+			}
+			if applied := applyRanges(tt.a, got); applied != tt.b {
+				t.Errorf("applying Ranges() = %q, expected %q", applied, tt.b)
+			}
+		})
+	}
+
+	got := Ranges([]byte("😀 one\n"), []byte("😀 two\n"))
+	if len(got) == 0 || got[0].Start != 3 {
+		t.Errorf("Ranges() = %#v, expected the first range at UTF-16 offset 3", got)
+	}
+}
+
+func TestStat(t *testing.T) {
+	added, removed := Stat([]byte("1\n2\n3\n"), []byte("0\n1\nthree\n"))
+	if added != 2 || removed != 2 {
+		t.Errorf("Stat() = %d, %d, expected 2, 2", added, removed)
+	}
+}
+
+// lcs returns the longest common subsequence length, the shortest edit script
+// has the remaining elements of both sides.
+func lcs(a, b []byte) int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(cur[j], prev[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func TestCompute(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	random := func(n int) []byte {
+		s := make([]byte, r.Intn(n))
+		for i := range s {
+			s[i] = "abc"[r.Intn(3)]
+		}
+		return s
+	}
+
+	for i := 0; i < 2000; i++ {
+		a, b := random(40), random(40)
+		edits := Compute(a, b)
+		got := []byte{}
+		changes := 0
+		for _, e := range edits {
+			switch e.Op {
+			case Equal:
+				if a[e.A] != b[e.B] {
+					t.Fatalf("Compute(%q, %q) equal %d and %d differ", a, b, e.A, e.B)
+				}
+				got = append(got, a[e.A])
+			case Insert:
+				got = append(got, b[e.B])
+				changes++
+			case Delete:
+				changes++
+			}
+		}
+		if string(got) != string(b) {
+			t.Fatalf("Compute(%q, %q) results on %q", a, b, got)
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); changes != want {
+			t.Fatalf("Compute(%q, %q) = %d changes, expected %d", a, b, changes, want)
+		}
+	}
+
+	// large rewrites exceed the cost, replaced as a whole block
+	a, b := make([]int, 3*maxCost), make([]int, 3*maxCost)
+	for i := range a {
+		a[i], b[i] = i, -i-1
+	}
+	edits := Compute(a, b)
+	if len(edits) != len(a)+len(b) || edits[0].Op != Delete || edits[len(a)].Op != Insert {
+		t.Errorf("Compute() = %d edits, expected the whole block replaced", len(edits))
+	}
+}
@@ -0,0 +1,137 @@
+package diff
+
+import "strings"
+
+// Range represents a change on the old text, the offsets are UTF-16 code units,
+// like JavaScript string indexes. Deletions remove the old text between Start
+// and End, insertions add the text at Start, where End equals Start. Applying
+// the ranges in reverse order on the old text results on the new text.
+type Range struct {
+	Op    string `json:"op"`    // operation, insert or delete
+	Start int    `json:"start"` // start offset on the old text
+	End   int    `json:"end"`   // end offset on the old text
+	Text  string `json:"text"`  // inserted or deleted text
+}
+
+const (
+	// RangeInsert the range text is inserted.
+	RangeInsert = "insert"
+	// RangeDelete the range text is deleted.
+	RangeDelete = "delete"
+)
+
+// maxBlockRunes maximum runes on a changed block compared character by
+// character, larger blocks are replaced as whole lines.
+const maxBlockRunes = 1000
+
+// utf16Len returns the UTF-16 length of the text, runes outside the basic
+// multilingual plane take a surrogate pair.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n++
+		if r >= 0x10000 {
+			n++
+		}
+	}
+	return n
+}
+
+// appendRange appends the text operation at the offset, merging it with the last
+// range when contiguous.
+func appendRange(ranges []Range, op string, offset int, text string) []Range {
+	if text == "" {
+		return ranges
+	}
+	if n := len(ranges); n > 0 && ranges[n-1].Op == op {
+		last := &ranges[n-1]
+		if op == RangeDelete && last.End == offset {
+			last.End += utf16Len(text)
+			last.Text += text
+			return ranges
+		}
+		if op == RangeInsert && last.Start == offset {
+			last.Text += text
+			return ranges
+		}
+	}
+	end := offset
+	if op == RangeDelete {
+		end += utf16Len(text)
+	}
+	return append(ranges, Range{Op: op, Start: offset, End: end, Text: text})
+}
+
+// blockRanges compares the changed block character by character, the offset is
+// the block start on the old text.
+func blockRanges(ranges []Range, offset int, a, b string) []Range {
+	ar, br := []rune(a), []rune(b)
+	if len(ar)+len(br) > maxBlockRunes {
+		ranges = appendRange(ranges, RangeDelete, offset, a)
+		return appendRange(ranges, RangeInsert, offset+utf16Len(a), b)
+	}
+	for _, e := range Compute(ar, br) {
+		switch e.Op {
+		case Equal:
+			offset += utf16Len(string(ar[e.A]))
+		case Delete:
+			ranges = appendRange(ranges, RangeDelete, offset, string(ar[e.A]))
+			offset += utf16Len(string(ar[e.A]))
+		case Insert:
+			ranges = appendRange(ranges, RangeInsert, offset, string(br[e.B]))
+		}
+	}
+	return ranges
+}
+
+// Ranges returns the changes between the old (a) and new (b) texts, the lines
+// are compared first, and then the characters on each changed block.
+func Ranges(a, b []byte) []Range {
+	return NewLines(a, b).Ranges()
+}
+
+// Ranges returns the changes, the characters on each changed block of lines are
+// compared.
+func (l *Lines) Ranges() []Range {
+	al, bl, edits := l.a, l.b, l.edits
+	ranges := []Range{}
+	offset := 0
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			offset += utf16Len(al[edits[i].A])
+			i++
+			continue
+		}
+		var oldBlock, newBlock strings.Builder
+		for ; i < len(edits) && edits[i].Op != Equal; i++ {
+			if edits[i].Op == Delete {
+				oldBlock.WriteString(al[edits[i].A])
+			} else {
+				newBlock.WriteString(bl[edits[i].B])
+			}
+		}
+		ranges = blockRanges(ranges, offset, oldBlock.String(), newBlock.String())
+		offset += utf16Len(oldBlock.String())
+	}
+	return ranges
+}
+
+// Stat returns the number of lines added and removed between the old (a) and new
+// (b) texts.
+func Stat(a, b []byte) (int, int) {
+	return NewLines(a, b).Stat()
+}
+
+// Stat returns the number of lines added and removed.
+func (l *Lines) Stat() (int, int) {
+	added, removed := 0, 0
+	for _, e := range l.edits {
+		switch e.Op {
+		case Insert:
+			added++
+		case Delete:
+			removed++
+		}
+	}
+	return added, removed
+}
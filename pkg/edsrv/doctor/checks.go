@@ -0,0 +1,257 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/editor"
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
+	"github.com/otaviof/edsrv/pkg/edsrv/service"
+
+	"github.com/valyala/fasthttp"
+)
+
+// BlockThreshold editors returning faster than the threshold are considered
+// non-blocking, the editor process forks and returns right away.
+const BlockThreshold = time.Second
+
+// ManifestName native messaging host manifest name, after the service label.
+const ManifestName = "io.github.otaviof.edsrv.json"
+
+// ConfigFile checks the configuration file parses.
+func ConfigFile(cfg *config.Config) Check {
+	const name = "config"
+	return func() *Result {
+		if err := cfg.LoadConfigFile(); err != nil {
+			return fail(name, fmt.Sprintf("fix the YAML on %q, or inform a "+
+				"different file with --%s", cfg.ConfigFile, config.ConfigFlag),
+				"%s", err)
+		}
+		if _, err := os.Stat(cfg.ConfigFile); err != nil {
+			return pass(name, "no configuration file, using the defaults")
+		}
+		return pass(name, "%q parses with %d profiles and %d rules",
+			cfg.ConfigFile, len(cfg.Profiles), len(cfg.Rules))
+	}
+}
+
+// EditorResolves checks a editor candidate is found on PATH.
+func EditorResolves(ed *editor.Editor) Check {
+	const name = "editor"
+	return func() *Result {
+		if err := ed.Resolve(); err != nil {
+			return fail(name, fmt.Sprintf("install the editor, or inform it with "+
+				"--%s or $VISUAL/$EDITOR", config.EditorFlag), "%s", err)
+		}
+		return pass(name, "%q resolves to %q", ed.GetCommand(), ed.GetPath())
+	}
+}
+
+// NvimReachable checks the Neovim instance listens on the address.
+func NvimReachable(addr string) Check {
+	const name = "editor"
+	return func() *Result {
+		fix := fmt.Sprintf("start Neovim with \"nvim --listen %s\", or inform "+
+			"the address with --%s", addr, config.NvimListenFlag)
+		if addr == "" {
+			return fail(name, fix, "the Neovim listen address is not informed")
+		}
+		network := "unix"
+		if _, _, err := net.SplitHostPort(addr); err == nil {
+			network = "tcp"
+		}
+		conn, err := net.DialTimeout(network, addr, time.Second)
+		if err != nil {
+			return fail(name, fix, "Neovim is not reachable on %q: %s", addr, err)
+		}
+		_ = conn.Close()
+		return pass(name, "Neovim listens on %q", addr)
+	}
+}
+
+// EditorBlocks checks the editor blocks until the file is closed, a throwaway
+// file is edited, so the editor is shown and must be closed.
+func EditorBlocks(
+	logger *slog.Logger,
+	ed editor.Interface,
+	storage string,
+	threshold time.Duration,
+) Check {
+	const name = "editor blocks"
+	return func() *Result {
+		payload := []byte("edsrv doctor: close the editor to finish the check.\n")
+		logger.Info("opening the editor on a throwaway file, close it to continue",
+			"editor", ed.GetCommand())
+		started := time.Now()
+		f, err := ed.Edit(context.Background(), payload,
+			file.Options{Storage: storage})
+		elapsed := time.Since(started)
+		if err != nil {
+			return fail(name, "make sure the editor command runs on the "+
+				"terminal, or inform a different one with --"+config.EditorFlag,
+				"test edit failed: %s", err)
+		}
+		_ = f.Remove()
+		if elapsed < threshold {
+			return warn(name, "use the editor flag to wait for the file to be "+
+				"closed, i.e. \"code --wait\", \"subl --wait\" or \"gvim -f\"",
+				"%q returned after %s, the edits are not delivered",
+				ed.GetCommand(), elapsed.Round(time.Millisecond))
+		}
+		return pass(name, "%q waited %s for the file to be closed",
+			ed.GetCommand(), elapsed.Round(time.Second))
+	}
+}
+
+// TmpDir checks the temporary directory is writable and safe to use.
+func TmpDir(dir string) Check {
+	const name = "tmp dir"
+	return func() *Result {
+		fix := fmt.Sprintf("inform a directory owned by you with --%s, i.e. "+
+			"\"mkdir -m 0700 ~/.cache/edsrv\"", config.TmpDirFlag)
+		if dir == "" {
+			return fail(name, fix, "the temporary directory is not informed")
+		}
+		if err := file.CheckTmpDir(dir); err != nil {
+			if errors.Is(err, file.ErrInsecure) {
+				return fail(name, fmt.Sprintf("run \"chmod +t %s\" or %s", dir, fix),
+					"%s", err)
+			}
+			return fail(name, fix, "%s", err)
+		}
+		f, err := os.CreateTemp(dir, "edsrv-doctor-*")
+		if err != nil {
+			return fail(name, fix, "%q is not writable: %s", dir, err)
+		}
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return pass(name, "%q is writable and safe to use", dir)
+	}
+}
+
+// serverName the server header value, which identifies edsrv.
+const serverName = "edsrv"
+
+// Port checks the listen address is free, or held by edsrv.
+func Port(addr string) Check {
+	const name = "port"
+	return func() *Result {
+		ln, err := net.Listen("tcp", addr)
+		if err == nil {
+			_ = ln.Close()
+			return pass(name, "%q is free", addr)
+		}
+		req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseRequest(req)
+		defer fasthttp.ReleaseResponse(res)
+		req.SetRequestURI("http://" + addr + service.StatusPath)
+		c := &fasthttp.HostClient{Addr: addr}
+		if c.DoTimeout(req, res, 2*time.Second) == nil &&
+			string(res.Header.Server()) == serverName {
+			return pass(name, "%q is held by edsrv", addr)
+		}
+		return fail(name, fmt.Sprintf("stop the other process, or inform a "+
+			"different address with --%s", config.AddrFlag),
+			"%q is held by another process: %s", addr, err)
+	}
+}
+
+// Server checks edsrv is reachable on the address, and shows its version.
+func Server(logger *slog.Logger, addr string) Check {
+	const name = "server"
+	return func() *Result {
+		c := &fasthttp.HostClient{Addr: addr}
+		body, err := service.StatusRequest(logger, c, "application/json")
+		if err != nil {
+			return warn(name, "start it with \"edsrv start\", or install it as "+
+				"a service", "edsrv is not reachable on %q: %s", addr, err)
+		}
+		st := &service.StatusResponse{}
+		if err = json.Unmarshal(body, st); err != nil {
+			return warn(name, "upgrade the running edsrv to the same version",
+				"edsrv on %q replied a unexpected status: %s", addr, err)
+		}
+		return pass(name, "edsrv %s is running on %q, up for %s",
+			st.Version.Version, addr, st.Uptime)
+	}
+}
+
+// ManifestDirs returns the browsers native messaging hosts directories for the
+// current platform.
+func ManifestDirs() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	if runtime.GOOS == "darwin" {
+		base := filepath.Join(home, "Library", "Application Support")
+		return []string{
+			filepath.Join(base, "Mozilla", "NativeMessagingHosts"),
+			filepath.Join(base, "Google", "Chrome", "NativeMessagingHosts"),
+			filepath.Join(base, "Chromium", "NativeMessagingHosts"),
+			filepath.Join(base, "BraveSoftware", "Brave-Browser",
+				"NativeMessagingHosts"),
+		}
+	}
+	config := filepath.Join(home, ".config")
+	return []string{
+		filepath.Join(home, ".mozilla", "native-messaging-hosts"),
+		filepath.Join(config, "google-chrome", "NativeMessagingHosts"),
+		filepath.Join(config, "chromium", "NativeMessagingHosts"),
+		filepath.Join(config, "BraveSoftware", "Brave-Browser",
+			"NativeMessagingHosts"),
+	}
+}
+
+// manifest the native messaging host manifest attributes checked.
+type manifest struct {
+	Name string `json:"name"` // host name
+	Path string `json:"path"` // host executable
+}
+
+// NativeMessaging checks the edsrv native messaging manifests found on the
+// directories are valid, the browser extensions reach edsrv over HTTP, so the
+// manifests are optional.
+func NativeMessaging(dirs []string) Check {
+	const name = "native messaging"
+	return func() *Result {
+		found := []string{}
+		for _, dir := range dirs {
+			path := filepath.Join(dir, ManifestName)
+			data, err := os.ReadFile(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			fix := fmt.Sprintf("reinstall the manifest, or remove %q", path)
+			if err != nil {
+				return fail(name, fix, "%s", err)
+			}
+			m := &manifest{}
+			if err = json.Unmarshal(data, m); err != nil {
+				return fail(name, fix, "%q is invalid: %s", path, err)
+			}
+			if _, err = exec.LookPath(m.Path); err != nil {
+				return fail(name, fix, "%q host %q is not executable: %s",
+					path, m.Path, err)
+			}
+			found = append(found, path)
+		}
+		if len(found) == 0 {
+			return pass(name, "no manifests, not required, the extensions "+
+				"reach edsrv over HTTP")
+		}
+		return pass(name, "%s", strings.Join(found, ", "))
+	}
+}
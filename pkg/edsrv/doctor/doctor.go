@@ -0,0 +1,95 @@
+package doctor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Status the check outcome.
+type Status string
+
+const (
+	// StatusPass the check passed.
+	StatusPass Status = "pass"
+	// StatusWarn the check found a problem which may not prevent editing.
+	StatusWarn Status = "warn"
+	// StatusFail the check found a problem which prevents editing.
+	StatusFail Status = "fail"
+)
+
+// Result represents a check outcome, with the fix for the problem found.
+type Result struct {
+	Name    string `json:"name"`          // check name
+	Status  Status `json:"status"`        // check outcome
+	Message string `json:"message"`       // what was found
+	Fix     string `json:"fix,omitempty"` // how to fix the problem
+}
+
+// Check runs a single diagnostic.
+type Check func() *Result
+
+// pass instantiates a passing result.
+func pass(name, format string, args ...any) *Result {
+	return &Result{Name: name, Status: StatusPass, Message: fmt.Sprintf(format, args...)}
+}
+
+// warn instantiates a warning result with the fix.
+func warn(name, fix, format string, args ...any) *Result {
+	return &Result{
+		Name:    name,
+		Status:  StatusWarn,
+		Message: fmt.Sprintf(format, args...),
+		Fix:     fix,
+	}
+}
+
+// fail instantiates a failing result with the fix.
+func fail(name, fix, format string, args ...any) *Result {
+	return &Result{
+		Name:    name,
+		Status:  StatusFail,
+		Message: fmt.Sprintf(format, args...),
+		Fix:     fix,
+	}
+}
+
+// Report the outcome of all checks, in order.
+type Report []*Result
+
+// Run runs the checks in order.
+func Run(checks ...Check) Report {
+	r := make(Report, 0, len(checks))
+	for _, c := range checks {
+		r = append(r, c())
+	}
+	return r
+}
+
+// Count returns the number of results with the informed status.
+func (r Report) Count(s Status) int {
+	n := 0
+	for _, res := range r {
+		if res.Status == s {
+			n++
+		}
+	}
+	return n
+}
+
+// Write writes the report as a human readable table, the fixes are shown below
+// the problems.
+func (r Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, res := range r {
+		fmt.Fprintf(tw, "[%s]\t%s\t%s\n",
+			strings.ToUpper(string(res.Status)), res.Name, res.Message)
+		if res.Fix != "" {
+			fmt.Fprintf(tw, "\t\tfix: %s\n", res.Fix)
+		}
+	}
+	fmt.Fprintf(tw, "\n%d passed, %d warnings, %d failed\n",
+		r.Count(StatusPass), r.Count(StatusWarn), r.Count(StatusFail))
+	return tw.Flush()
+}
@@ -0,0 +1,145 @@
+package doctor
+
+import (
+	"bytes"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/editor"
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
+)
+
+func TestChecks(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	insecureDir := t.TempDir()
+	if err := os.Chmod(insecureDir, 0o777); err != nil {
+		t.Fatalf("unable to chmod %q: %v", insecureDir, err)
+	}
+
+	invalidConfig := config.NewConfig()
+	invalidConfig.ConfigFile = filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(invalidConfig.ConfigFile, []byte("rules: {"), 0o600); err != nil {
+		t.Fatalf("unable to write config file: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	defer ln.Close()
+	// another process, closing the connections right away
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	manifestDir := t.TempDir()
+	invalidManifestDir := t.TempDir()
+	for dir, content := range map[string]string{
+		manifestDir:        `{"name":"io.github.otaviof.edsrv","path":"/bin/sh"}`,
+		invalidManifestDir: `{"name":`,
+	} {
+		err = os.WriteFile(filepath.Join(dir, ManifestName), []byte(content), 0o600)
+		if err != nil {
+			t.Fatalf("unable to write manifest: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		check Check
+		want  Status
+	}{{
+		name:  "config invalid",
+		check: ConfigFile(invalidConfig),
+		want:  StatusFail,
+	}, {
+		name:  "tmp dir",
+		check: TmpDir(t.TempDir()),
+		want:  StatusPass,
+	}, {
+		name:  "tmp dir insecure",
+		check: TmpDir(insecureDir),
+		want:  StatusFail,
+	}, {
+		name: "editor not found",
+		check: EditorResolves(editor.NewEditor(logger, &editor.Direct{},
+			[]string{"edsrv-missing-editor"}, t.TempDir(), false)),
+		want: StatusFail,
+	}, {
+		name: "editor returns right away",
+		check: EditorBlocks(logger, editor.NewFakeEditor([]byte("edited")),
+			file.StorageDisk, time.Minute),
+		want: StatusWarn,
+	}, {
+		name: "editor blocks",
+		check: EditorBlocks(logger, editor.NewFakeEditor([]byte("edited")),
+			file.StorageDisk, 0),
+		want: StatusPass,
+	}, {
+		name:  "port free",
+		check: Port("127.0.0.1:0"),
+		want:  StatusPass,
+	}, {
+		name:  "port held by another process",
+		check: Port(ln.Addr().String()),
+		want:  StatusFail,
+	}, {
+		name:  "server unreachable",
+		check: Server(logger, ln.Addr().String()),
+		want:  StatusWarn,
+	}, {
+		name:  "native messaging without manifests",
+		check: NativeMessaging([]string{t.TempDir()}),
+		want:  StatusPass,
+	}, {
+		name:  "native messaging manifest",
+		check: NativeMessaging([]string{manifestDir}),
+		want:  StatusPass,
+	}, {
+		name:  "native messaging invalid manifest",
+		check: NativeMessaging([]string{manifestDir, invalidManifestDir}),
+		want:  StatusFail,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.check()
+			if got.Status != tt.want {
+				t.Errorf("check = %+v, expected status %q", got, tt.want)
+			}
+			if got.Status != StatusPass && got.Fix == "" {
+				t.Errorf("check = %+v, expected a fix", got)
+			}
+		})
+	}
+}
+
+func TestReport_Write(t *testing.T) {
+	r := Run(
+		func() *Result { return pass("a", "ok") },
+		func() *Result { return warn("b", "do this", "hmm") },
+		func() *Result { return fail("c", "do that", "broken") },
+	)
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("Report.Write() error = %v", err)
+	}
+	for _, want := range []string{"[PASS]", "[WARN]", "fix: do that", "1 passed, 1 warnings, 1 failed"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Report.Write() = %q, expected %q", buf.String(), want)
+		}
+	}
+}
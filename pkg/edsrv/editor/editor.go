@@ -1,25 +1,51 @@
 package editor
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os/exec"
 	"strings"
+	"sync"
 
 	"github.com/otaviof/edsrv/pkg/edsrv/file"
+	"github.com/otaviof/edsrv/pkg/edsrv/tracing"
 )
 
-// Editor represents the external editor.
+// Editor represents the external editor, chosen from a ordered list of candidate
+// editor commands.
 type Editor struct {
-	logger  *slog.Logger // shared logger instance
-	command []string     // external editor command and arguments
-	tmpDir  string       // path to temporary directory
+	logger     *slog.Logger // shared logger instance
+	launcher   Launcher     // runs the editor and waits for completion
+	candidates [][]string   // candidate editor commands and arguments
+	selected   int          // index of the selected candidate
+	path       string       // resolved executable path of the selected candidate
+	tmpDir     string       // path to temporary directory
+	shred      bool         // overwrite temporary files before removing
+	m          sync.Mutex   // protects the selected candidate
 }
 
 var _ Interface = &Editor{}
 
-// GetCommand shows the editor command.
+// ErrEditorNotFound none of the candidate editors is found on PATH.
+var ErrEditorNotFound = errors.New("editor not found")
+
+// GetCommand shows the selected editor command.
 func (e *Editor) GetCommand() string {
-	return strings.Join(e.command, " ")
+	e.m.Lock()
+	defer e.m.Unlock()
+	if e.selected >= len(e.candidates) {
+		return ""
+	}
+	return strings.Join(e.candidates[e.selected], " ")
+}
+
+// GetPath shows the resolved executable path of the selected editor.
+func (e *Editor) GetPath() string {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return e.path
 }
 
 // GetTmpDir exposes the temporary directory location.
@@ -27,45 +53,121 @@ func (e *Editor) GetTmpDir() string {
 	return e.tmpDir
 }
 
-// runCommandAndWait runs the editor command and waits for the result.
-func (e *Editor) runCommandAndWait(f *file.File) error {
-	script := e.command
-	script = append(script, f.Name())
+// resolve selects the first candidate, starting on the informed index, which the
+// executable is found on PATH.
+func (e *Editor) resolve(start int) error {
+	for i := start; i < len(e.candidates); i++ {
+		logger := e.logger.With("candidate", strings.Join(e.candidates[i], " "))
+		path, err := exec.LookPath(e.candidates[i][0])
+		if err != nil {
+			logger.Warn("editor candidate is not found", "err", err.Error())
+			continue
+		}
+		e.selected, e.path = i, path
+		logger.Info("editor selected", "path", path)
+		return nil
+	}
+	e.selected, e.path = len(e.candidates), ""
+	return fmt.Errorf("%w: none of the candidates is found on PATH",
+		ErrEditorNotFound)
+}
 
-	logger := e.logger.With("script", script)
-	logger.Info("running editor command and waiting...")
+// Resolve selects the first candidate editor found on PATH.
+func (e *Editor) Resolve() error {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return e.resolve(0)
+}
 
-	cmd := exec.Command(script[0], script[1:]...) //nolint:gosec
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Error("error reading script combined output", "err", err.Error())
-		return err
-	}
+// script returns the selected editor command, when the selected executable has
+// disappeared it falls back to the next candidate.
+func (e *Editor) script() ([]string, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
 
-	logger.Debug("editor command result", "output", string(output))
-	return nil
+	// when all candidates are exhausted, trying them all over again
+	start := 0
+	if e.selected < len(e.candidates) {
+		if _, err := exec.LookPath(e.path); err == nil {
+			return append([]string{}, e.candidates[e.selected]...), nil
+		}
+		e.logger.Warn("selected editor has disappeared, falling back",
+			"path", e.path)
+		start = e.selected + 1
+	}
+	if err := e.resolve(start); err != nil {
+		return nil, err
+	}
+	return append([]string{}, e.candidates[e.selected]...), nil
 }
 
-// Edit edits the informed payload on a temporary file, using the external editor.
-func (e *Editor) Edit(payload []byte) (file.Interface, error) {
+// Edit edits the informed payload on a temporary file, with the informed options,
+// using the external editor.
+func (e *Editor) Edit(
+	ctx context.Context,
+	payload []byte,
+	opts file.Options,
+) (file.Interface, error) {
+	script, err := e.script()
+	if err != nil {
+		return nil, err
+	}
+
 	e.logger.Debug("creating temporary file for payload")
-	f, err := file.NewFile(e.tmpDir, payload)
+	f, err := newTracedTmpFile(ctx, e.logger, opts, e.tmpDir, payload, e.shred,
+		isRenameOnSave(script[0]))
 	if err != nil {
 		return nil, err
 	}
-	f.LoggerWith(e.logger).Debug("temporary file created")
-	if err = e.runCommandAndWait(f); err != nil {
+	logger := f.LoggerWith(e.logger)
+	logger.Debug("temporary file created")
+
+	// the editor's own swap and backup files must not hold the content either
+	if file.IsMemory(f) {
+		if args := memoryArgs(script[0]); args != nil {
+			script = append(script, args...)
+		} else {
+			logger.Warn("editor may keep swap or backup files on disk")
+		}
+	}
+	script = append(script, f.Name())
+	logger = logger.With("script", script)
+	logger.Info("running editor command and waiting...")
+	_, span := tracing.Start(ctx, "editor")
+	span.SetAttr("editor.command", script[0])
+	err = e.launcher.Launch(logger, script)
+	span.SetAttr("editor.exit_status", ExitStatus(err))
+	span.RecordError(err)
+	span.End()
+	if err != nil {
+		_ = f.Remove()
 		return nil, err
 	}
 	return f, nil
 }
 
-// NewEditor instantiates a new editor with the desired command and temporary
-// directory.
-func NewEditor(logger *slog.Logger, command, tmpDir string) *Editor {
+// NewEditor instantiates a new editor with the desired launcher, ordered list of
+// candidate commands and temporary directory, with shred the temporary files
+// are overwritten before removing. The candidates must be resolved before use,
+// see Resolve.
+func NewEditor(
+	logger *slog.Logger,
+	launcher Launcher,
+	commands []string,
+	tmpDir string,
+	shred bool,
+) *Editor {
+	candidates := [][]string{}
+	for _, command := range commands {
+		if fields := strings.Fields(command); len(fields) > 0 {
+			candidates = append(candidates, fields)
+		}
+	}
 	return &Editor{
-		logger:  logger,
-		command: strings.Split(command, " "),
-		tmpDir:  tmpDir,
+		logger:     logger,
+		launcher:   launcher,
+		candidates: candidates,
+		tmpDir:     tmpDir,
+		shred:      shred,
 	}
 }
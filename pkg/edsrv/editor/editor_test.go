@@ -0,0 +1,103 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
+)
+
+func TestEditor_Resolve(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(
+		os.Stdout,
+		&slog.HandlerOptions{Level: slog.LevelDebug},
+	))
+
+	first := filepath.Join(tmpDir, "first-editor")
+	second := filepath.Join(tmpDir, "second-editor")
+	for _, name := range []string{first, second} {
+		if err := os.WriteFile(name, []byte("#!/bin/sh\n"), 0o700); err != nil {
+			t.Fatalf("unable to create fake editor: %v", err)
+		}
+	}
+
+	e := NewEditor(logger, &Direct{}, []string{
+		"edsrv-editor-does-not-exist",
+		first + " --wait",
+		"",
+		second,
+	}, tmpDir, false)
+
+	if err := e.Resolve(); err != nil {
+		t.Fatalf("Editor.Resolve() error = %v", err)
+	}
+	if e.GetCommand() != first+" --wait" || e.GetPath() != first {
+		t.Errorf("Editor.Resolve() command = %q, path = %q, expected %q",
+			e.GetCommand(), e.GetPath(), first)
+	}
+
+	// removing the selected editor, the next candidate must be picked instead
+	if err := os.Remove(first); err != nil {
+		t.Fatalf("unable to remove fake editor: %v", err)
+	}
+	script, err := e.script()
+	if err != nil {
+		t.Fatalf("Editor.script() error = %v", err)
+	}
+	if len(script) != 1 || script[0] != second {
+		t.Errorf("Editor.script() script = %v, expected %q", script, second)
+	}
+
+	// removing the last candidate, there's nothing else to fall back to
+	if err = os.Remove(second); err != nil {
+		t.Fatalf("unable to remove fake editor: %v", err)
+	}
+	if _, err = e.script(); !errors.Is(err, ErrEditorNotFound) {
+		t.Errorf("Editor.script() error = %v, expected %v",
+			err, ErrEditorNotFound)
+	}
+}
+
+func TestEditor_EditMemory(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("memory storage is only supported on Linux")
+	}
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	// the fake vim records its arguments, one per line
+	argsFile := filepath.Join(tmpDir, "args")
+	vim := filepath.Join(tmpDir, "vim")
+	script := fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' \"$@\" >%s\n", argsFile)
+	if err := os.WriteFile(vim, []byte(script), 0o700); err != nil {
+		t.Fatalf("unable to create fake editor: %v", err)
+	}
+
+	e := NewEditor(logger, &Direct{}, []string{vim}, tmpDir, false)
+	if err := e.Resolve(); err != nil {
+		t.Fatalf("Editor.Resolve() error = %v", err)
+	}
+	f, err := e.Edit(context.Background(), []byte("secret"),
+		file.Options{Storage: file.StorageMemory})
+	if err != nil {
+		t.Fatalf("Editor.Edit() error = %v", err)
+	}
+	defer f.Remove()
+
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("unable to read editor arguments: %v", err)
+	}
+	want := strings.Join(vimMemoryArgs, "\n") + "\n" + f.Name() + "\n"
+	if string(args) != want {
+		t.Errorf("editor arguments = %q, expected %q", args, want)
+	}
+}
@@ -1,9 +1,14 @@
 package editor
 
-import "github.com/otaviof/edsrv/pkg/edsrv/file"
+import (
+	"context"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
+)
 
 type FakeEditor struct {
 	payload []byte
+	err     error
 }
 
 var _ Interface = &FakeEditor{}
@@ -16,8 +21,20 @@ func (*FakeEditor) GetTmpDir() string {
 	return "none"
 }
 
-func (e *FakeEditor) Edit([]byte) (file.Interface, error) {
-	return file.NewFakeFile("fake", e.payload), nil
+func (e *FakeEditor) Edit(
+	_ context.Context,
+	original []byte,
+	_ file.Options,
+) (file.Interface, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return file.NewFakeFile("fake", original, e.payload), nil
+}
+
+func (e *FakeEditor) WithError(err error) *FakeEditor {
+	e.err = err
+	return e
 }
 
 func NewFakeEditor(payload []byte) *FakeEditor {
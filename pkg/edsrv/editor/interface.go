@@ -1,6 +1,10 @@
 package editor
 
-import "github.com/otaviof/edsrv/pkg/edsrv/file"
+import (
+	"context"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
+)
 
 type Interface interface {
 	// GetCommand shows the Editor command in use.
@@ -9,6 +13,7 @@ type Interface interface {
 	// GetTmpDir shows the temporary directory in use.
 	GetTmpDir() string
 
-	// Edit edits the payload using the external editor.
-	Edit([]byte) (file.Interface, error)
+	// Edit edits the payload using the external editor, the temporary file is
+	// created with the informed options. The context carries the tracing span.
+	Edit(ctx context.Context, payload []byte, opts file.Options) (file.Interface, error)
 }
@@ -0,0 +1,125 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Launcher runs the editor script and waits until the editor is done with the
+// temporary file.
+type Launcher interface {
+	// Launch runs the script (editor command, arguments and file) and blocks
+	// until the editor is finished.
+	Launch(logger *slog.Logger, script []string) error
+}
+
+const (
+	// TmuxWindow opens the editor on a new tmux window.
+	TmuxWindow = "window"
+	// TmuxPane opens the editor on a new tmux pane, splitting the current one.
+	TmuxPane = "pane"
+)
+
+// ErrEditorFailed the editor finished with a non-zero exit status.
+var ErrEditorFailed = errors.New("editor failed")
+
+// ExitError the editor finished with a non-zero exit status, reported by the
+// wrapper script of the terminal and tmux launchers.
+type ExitError struct {
+	Status int // editor exit status
+}
+
+// Error shows the editor exit status.
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("%s: exit status %d", ErrEditorFailed, e.Status)
+}
+
+// Unwrap exposes ErrEditorFailed.
+func (*ExitError) Unwrap() error {
+	return ErrEditorFailed
+}
+
+// ExitStatus returns the editor exit status for the informed edit error, zero
+// on success and -1 when the status is unknown.
+func ExitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	var wrapperErr *ExitError
+	if errors.As(err, &wrapperErr) {
+		return wrapperErr.Status
+	}
+	return -1
+}
+
+// Direct runs the editor as a child process, expecting the command to block
+// until the editing is done.
+type Direct struct{}
+
+var _ Launcher = &Direct{}
+
+// Launch runs the editor command and waits for the result.
+func (*Direct) Launch(logger *slog.Logger, script []string) error {
+	cmd := exec.Command(script[0], script[1:]...) //nolint:gosec
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		logger.Error("error reading script combined output", "err", err.Error())
+		return err
+	}
+
+	logger.Debug("editor command result", "output", string(output))
+	return nil
+}
+
+// NewLauncher instantiates the Launcher for the informed terminal emulator or
+// tmux mode, when both are empty the editor runs directly.
+func NewLauncher(terminal, tmux, tmuxTarget, tmpDir string) Launcher {
+	switch {
+	case tmux != "":
+		return NewTmux(tmux, tmuxTarget, tmpDir)
+	case terminal != "":
+		return NewTerminal(terminal, tmpDir)
+	default:
+		return &Direct{}
+	}
+}
+
+// shellQuote quotes the informed string to be safely used as a single word on a
+// POSIX shell script.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// wrapperScript returns a shell script which runs the positional arguments as
+// the editor command and, regardless of how the shell finishes, runs the notify
+// snippet with the editor exit status on "$status" variable.
+func wrapperScript(notify string) string {
+	return strings.Join([]string{
+		"status=129",
+		fmt.Sprintf("trap '%s' EXIT", strings.ReplaceAll(notify, "'", `'\''`)),
+		"trap 'exit 129' HUP INT TERM",
+		`"$@"`,
+		"status=$?",
+	}, "; ")
+}
+
+// parseExitStatus parses the exit status written by the wrapper script, any
+// other status than zero is considered an error.
+func parseExitStatus(s string) error {
+	status, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%w: unable to parse exit status %q", ErrEditorFailed, s)
+	}
+	if status != 0 {
+		return &ExitError{Status: status}
+	}
+	return nil
+}
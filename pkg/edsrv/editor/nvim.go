@@ -0,0 +1,242 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
+	"github.com/otaviof/edsrv/pkg/edsrv/msgpack"
+	"github.com/otaviof/edsrv/pkg/edsrv/tracing"
+)
+
+// Neovim represents a running Neovim instance as the editor, the temporary file
+// is opened as a new buffer through msgpack-RPC, and the edit is done when the
+// buffer is unloaded or ":EdsrvDone" runs.
+type Neovim struct {
+	logger *slog.Logger // shared logger instance
+	addr   string       // neovim listen address, unix socket or host:port
+	open   string       // command to open the buffer, tab or split
+	tmpDir string       // path to temporary directory
+	shred  bool         // overwrite temporary files before removing
+}
+
+var _ Interface = &Neovim{}
+
+const (
+	// NvimTab opens the temporary file on a new tab.
+	NvimTab = "tab"
+	// NvimSplit opens the temporary file on a horizontal split.
+	NvimSplit = "split"
+	// NvimVsplit opens the temporary file on a vertical split.
+	NvimVsplit = "vsplit"
+
+	// nvimDoneEvent notification sent by Neovim when the edit is done.
+	nvimDoneEvent = "edsrv_done"
+)
+
+// ErrNvimRPC error returned by Neovim on a msgpack-RPC request.
+var ErrNvimRPC = errors.New("neovim rpc error")
+
+// nvimOpenCommands maps the open mode to the respective ex command.
+var nvimOpenCommands = map[string]string{
+	NvimTab:    "tabedit",
+	NvimSplit:  "split",
+	NvimVsplit: "vsplit",
+}
+
+// nvimEditLua opens the temporary file on a new buffer, which is wiped when its
+// window closes, and notifies the edsrv channel when the buffer is unloaded. The
+// buffer is written in place, and for memory-backed files swap and undo files
+// are disabled. The ":EdsrvDone" command saves and closes the buffer.
+const nvimEditLua = `
+local chan, path, open, memory = ...
+vim.cmd(open .. ' ' .. vim.fn.fnameescape(path))
+local buf = vim.api.nvim_get_current_buf()
+vim.bo[buf].bufhidden = 'wipe'
+vim.bo[buf].backupcopy = 'yes'
+if memory then
+  vim.bo[buf].swapfile = false
+  vim.bo[buf].undofile = false
+end
+vim.api.nvim_create_autocmd('BufUnload', {
+  buffer = buf,
+  once = true,
+  callback = function()
+    vim.rpcnotify(chan, '` + nvimDoneEvent + `', path)
+  end,
+})
+vim.api.nvim_buf_create_user_command(buf, 'EdsrvDone', function()
+  vim.cmd('silent update')
+  vim.cmd('bwipeout ' .. buf)
+end, {})
+`
+
+// nvimConn msgpack-RPC connection with Neovim.
+type nvimConn struct {
+	conn  net.Conn         // network connection
+	enc   *msgpack.Encoder // request encoder
+	dec   *msgpack.Decoder // response and notification decoder
+	msgID int64            // last request message ID
+}
+
+// call executes a msgpack-RPC request and waits for its response, notifications
+// received in the meantime are ignored.
+func (c *nvimConn) call(method string, params ...any) (any, error) {
+	c.msgID++
+	if err := c.enc.Encode([]any{int64(0), c.msgID, method, params}); err != nil {
+		return nil, err
+	}
+	for {
+		msg, err := c.next()
+		if err != nil {
+			return nil, err
+		}
+		if len(msg) != 4 || msg[0] != int64(1) || msg[1] != c.msgID {
+			continue
+		}
+		if msg[2] != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrNvimRPC, method, msg[2])
+		}
+		return msg[3], nil
+	}
+}
+
+// next reads the next msgpack-RPC message.
+func (c *nvimConn) next() ([]any, error) {
+	v, err := c.dec.Decode()
+	if err != nil {
+		return nil, err
+	}
+	msg, ok := v.([]any)
+	if !ok || len(msg) == 0 {
+		return nil, fmt.Errorf("%w: unexpected message %v", ErrNvimRPC, v)
+	}
+	return msg, nil
+}
+
+// waitNotification blocks until the informed notification method is received.
+func (c *nvimConn) waitNotification(method string) ([]any, error) {
+	for {
+		msg, err := c.next()
+		if err != nil {
+			return nil, err
+		}
+		if len(msg) == 3 && msg[0] == int64(2) && msg[1] == method {
+			params, _ := msg[2].([]any)
+			return params, nil
+		}
+	}
+}
+
+// dial connects to the Neovim address, addresses containing a colon and no
+// slashes are considered TCP, everything else a unix socket path.
+func (n *Neovim) dial() (*nvimConn, error) {
+	network := "unix"
+	if strings.Contains(n.addr, ":") && !strings.Contains(n.addr, "/") {
+		network = "tcp"
+	}
+	conn, err := net.Dial(network, n.addr)
+	if err != nil {
+		return nil, err
+	}
+	return &nvimConn{
+		conn: conn,
+		enc:  msgpack.NewEncoder(conn),
+		dec:  msgpack.NewDecoder(conn),
+	}, nil
+}
+
+// GetCommand shows the Neovim server address in use.
+func (n *Neovim) GetCommand() string {
+	return fmt.Sprintf("nvim --server %s", n.addr)
+}
+
+// GetTmpDir exposes the temporary directory location.
+func (n *Neovim) GetTmpDir() string {
+	return n.tmpDir
+}
+
+// editOnNvim opens the file on Neovim and waits for the buffer to be unloaded.
+func (n *Neovim) editOnNvim(
+	logger *slog.Logger,
+	f file.Interface,
+	memory bool,
+) error {
+	c, err := n.dial()
+	if err != nil {
+		return err
+	}
+	defer c.conn.Close()
+
+	apiInfo, err := c.call("nvim_get_api_info")
+	if err != nil {
+		return err
+	}
+	info, ok := apiInfo.([]any)
+	if !ok || len(info) == 0 {
+		return fmt.Errorf("%w: unexpected api info %v", ErrNvimRPC, apiInfo)
+	}
+	chanID := info[0]
+	logger = logger.With("channel", chanID)
+
+	logger.Info("opening file on neovim and waiting...")
+	_, err = c.call("nvim_exec_lua", nvimEditLua,
+		[]any{chanID, f.Name(), nvimOpenCommands[n.open], memory})
+	if err != nil {
+		return err
+	}
+
+	if _, err = c.waitNotification(nvimDoneEvent); err != nil {
+		return fmt.Errorf("%w: waiting for buffer: %w", ErrNvimRPC, err)
+	}
+	logger.Debug("neovim buffer is unloaded")
+	return nil
+}
+
+// Edit edits the informed payload on a temporary file, with the informed options,
+// opened as a new buffer on the running Neovim instance.
+func (n *Neovim) Edit(
+	ctx context.Context,
+	payload []byte,
+	opts file.Options,
+) (file.Interface, error) {
+	n.logger.Debug("creating temporary file for payload")
+	f, err := newTracedTmpFile(ctx, n.logger, opts, n.tmpDir, payload, n.shred,
+		false)
+	if err != nil {
+		return nil, err
+	}
+	logger := f.LoggerWith(n.logger)
+	logger.Debug("temporary file created")
+	_, span := tracing.Start(ctx, "editor")
+	span.SetAttr("editor.command", n.GetCommand())
+	err = n.editOnNvim(logger, f, file.IsMemory(f))
+	span.RecordError(err)
+	span.End()
+	if err != nil {
+		_ = f.Remove()
+		return nil, err
+	}
+	return f, nil
+}
+
+// NewNeovim instantiates the Neovim editor with the informed server address,
+// open mode and temporary directory, with shred the temporary files are
+// overwritten before removing.
+func NewNeovim(
+	logger *slog.Logger,
+	addr, open, tmpDir string,
+	shred bool,
+) *Neovim {
+	return &Neovim{
+		logger: logger,
+		addr:   addr,
+		open:   open,
+		tmpDir: tmpDir,
+		shred:  shred,
+	}
+}
@@ -0,0 +1,123 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
+	"github.com/otaviof/edsrv/pkg/edsrv/msgpack"
+)
+
+// fakeNvim fake msgpack-RPC server acting as Neovim, when the lua snippet is
+// executed it writes the edited payload on the file and notifies the buffer is
+// unloaded, or returns the informed error.
+func fakeNvim(t *testing.T, ln net.Listener, edited []byte, rpcErr any) {
+	t.Helper()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	enc, dec := msgpack.NewEncoder(conn), msgpack.NewDecoder(conn)
+	for {
+		v, err := dec.Decode()
+		if err != nil {
+			return
+		}
+		msg, _ := v.([]any)
+		msgID, method := msg[1], msg[2]
+
+		switch method {
+		case "nvim_get_api_info":
+			_ = enc.Encode([]any{int64(1), msgID, nil, []any{int64(3), nil}})
+		case "nvim_exec_lua":
+			if rpcErr != nil {
+				_ = enc.Encode([]any{int64(1), msgID, rpcErr, nil})
+				continue
+			}
+			params, _ := msg[3].([]any)
+			args, _ := params[1].([]any)
+			path, _ := args[1].(string)
+			if err = os.WriteFile(path, edited, 0o600); err != nil {
+				t.Errorf("unable to write file: %v", err)
+			}
+			_ = enc.Encode([]any{int64(1), msgID, nil, nil})
+			_ = enc.Encode([]any{int64(2), nvimDoneEvent, []any{path}})
+		}
+	}
+}
+
+func TestNeovim_Edit(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(
+		os.Stdout,
+		&slog.HandlerOptions{Level: slog.LevelDebug},
+	))
+
+	edited := []byte("edited on neovim")
+
+	tests := []struct {
+		name    string
+		storage string
+		rpcErr  any
+		wantErr bool
+	}{{
+		name:    "buffer is edited and unloaded",
+		storage: file.StorageDisk,
+		rpcErr:  nil,
+		wantErr: false,
+	}, {
+		name:    "memory-backed buffer is edited and unloaded",
+		storage: file.StorageMemory,
+		rpcErr:  nil,
+		wantErr: false,
+	}, {
+		name:    "neovim returns an error",
+		storage: file.StorageDisk,
+		rpcErr:  []any{int64(0), "Vim:E492: Not an editor command"},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := filepath.Join(tmpDir, "nvim.sock")
+			ln, err := net.Listen("unix", addr)
+			if err != nil {
+				t.Fatalf("unable to listen: %v", err)
+			}
+			defer ln.Close()
+			go fakeNvim(t, ln, edited, tt.rpcErr)
+
+			n := NewNeovim(logger, addr, NvimTab, tmpDir, false)
+			f, err := n.Edit(context.Background(), []byte("payload"),
+				file.Options{Storage: tt.storage})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Neovim.Edit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrNvimRPC) {
+					t.Errorf("Neovim.Edit() error = %v, expected %v",
+						err, ErrNvimRPC)
+				}
+				return
+			}
+			defer f.Remove()
+
+			payload, err := f.Read()
+			if err != nil {
+				t.Fatalf("unable to read file: %v", err)
+			}
+			if string(payload) != string(edited) {
+				t.Errorf("Neovim.Edit() payload = %q, expected %q",
+					payload, edited)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
+	"github.com/otaviof/edsrv/pkg/edsrv/tracing"
+)
+
+// renameOnSaveEditors editors saving a new file and renaming it over the
+// original, thus unable to save memory-backed files.
+var renameOnSaveEditors = map[string]bool{
+	"emacs":             true,
+	"emacsclient":       true,
+	"gedit":             true,
+	"gnome-text-editor": true,
+	"kate":              true,
+	"mousepad":          true,
+	"subl":              true,
+	"sublime_text":      true,
+}
+
+// isRenameOnSave asserts the editor command renames on save.
+func isRenameOnSave(command string) bool {
+	return renameOnSaveEditors[filepath.Base(command)]
+}
+
+// vimEditors editors accepting the vim arguments below.
+var vimEditors = map[string]bool{
+	"gvim": true,
+	"nvim": true,
+	"vim":  true,
+}
+
+// vimMemoryArgs vim arguments disabling the swap, backup, undo and viminfo (or
+// shada) files, which would write the memory file content to disk.
+var vimMemoryArgs = []string{
+	"-n", "-i", "NONE", "--cmd", "set noundofile nobackup nowritebackup",
+}
+
+// memoryArgs returns the editor arguments keeping the memory file content off
+// the disk, nil when unknown for the editor command.
+func memoryArgs(command string) []string {
+	if vimEditors[filepath.Base(command)] {
+		return vimMemoryArgs
+	}
+	return nil
+}
+
+// newTmpFile instantiates the temporary file on the informed storage, memory
+// storage falls back to disk when not supported by the platform, or when the
+// editor renames on save. The extension only applies to disk storage.
+func newTmpFile(
+	logger *slog.Logger,
+	opts file.Options,
+	tmpDir string,
+	payload []byte,
+	shred bool,
+	renameOnSave bool,
+) (file.Interface, error) {
+	if opts.Storage == file.StorageMemory {
+		if renameOnSave {
+			logger.Warn("editor renames on save, falling back to disk storage")
+			return file.NewFile(tmpDir, opts.Extension, payload, shred)
+		}
+		f, err := file.NewMemFile(payload)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, file.ErrMemoryUnsupported) {
+			return nil, err
+		}
+		logger.Warn("falling back to disk storage", "err", err.Error())
+	}
+	return file.NewFile(tmpDir, opts.Extension, payload, shred)
+}
+
+// newTracedTmpFile instantiates the temporary file, see newTmpFile, on a span.
+func newTracedTmpFile(
+	ctx context.Context,
+	logger *slog.Logger,
+	opts file.Options,
+	tmpDir string,
+	payload []byte,
+	shred bool,
+	renameOnSave bool,
+) (file.Interface, error) {
+	_, span := tracing.Start(ctx, "create temporary file")
+	defer span.End()
+	span.SetAttr("file.storage", opts.Storage)
+	span.SetAttr("file.size", len(payload))
+	f, err := newTmpFile(logger, opts, tmpDir, payload, shred, renameOnSave)
+	span.RecordError(err)
+	return f, err
+}
@@ -0,0 +1,132 @@
+package editor
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Terminal launches terminal based editors inside a terminal emulator window,
+// the editor exit status is reported back through a named pipe, thus it works
+// even when the terminal emulator forks into the background.
+type Terminal struct {
+	command []string // terminal emulator command and arguments
+	tmpDir  string   // path to temporary directory, for the named pipe
+}
+
+var _ Launcher = &Terminal{}
+
+// terminalExecArgs known terminal emulators and the arguments needed before the
+// command to be executed.
+var terminalExecArgs = map[string][]string{
+	"alacritty":      {"-e"},
+	"foot":           {},
+	"footclient":     {},
+	"gnome-terminal": {"--"},
+	"kitty":          {},
+	"konsole":        {"-e"},
+	"wezterm":        {"start", "--"},
+	"xterm":          {"-e"},
+}
+
+// randomName generates a unique file name with the informed prefix and suffix.
+func randomName(prefix, suffix string) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%s%s", prefix, hex.EncodeToString(b), suffix), nil
+}
+
+// Launch runs the script inside a new terminal emulator window and waits for the
+// editor exit status written on the named pipe.
+func (t *Terminal) Launch(logger *slog.Logger, script []string) error {
+	if len(t.command) == 0 {
+		return fmt.Errorf("%w: terminal emulator command is empty", ErrEditorFailed)
+	}
+	name, err := randomName("edsrv-", ".fifo")
+	if err != nil {
+		return err
+	}
+	fifo := filepath.Join(t.tmpDir, name)
+	if err = syscall.Mkfifo(fifo, 0o600); err != nil {
+		return err
+	}
+	defer os.Remove(fifo)
+
+	// opening the named pipe for reading and writing never blocks, and allows
+	// closing it to interrupt the reading
+	r, err := os.OpenFile(fifo, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	notify := fmt.Sprintf(`printf "%%d\n" "$status" >%s`, shellQuote(fifo))
+	args := append([]string{}, t.command[1:]...)
+	args = append(args, "sh", "-c", wrapperScript(notify), "edsrv")
+	args = append(args, script...)
+
+	logger = logger.With("terminal", t.command[0], "fifo", fifo)
+	logger.Info("running editor on terminal emulator and waiting...")
+
+	cmd := exec.Command(t.command[0], args...) //nolint:gosec
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+
+	statusCh := make(chan string, 1)
+	readErrCh := make(chan error, 1)
+	go func() {
+		line, err := bufio.NewReader(r).ReadString('\n')
+		if err != nil {
+			readErrCh <- err
+			return
+		}
+		statusCh <- line
+	}()
+
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- cmd.Wait()
+	}()
+
+	for {
+		select {
+		case status := <-statusCh:
+			logger.Debug("editor exit status", "status", strings.TrimSpace(status))
+			return parseExitStatus(status)
+		case err = <-readErrCh:
+			return err
+		case err = <-waitCh:
+			if err != nil {
+				logger.Error("terminal emulator failed", "err", err.Error())
+				return fmt.Errorf("%w: terminal %q: %w",
+					ErrEditorFailed, t.command[0], err)
+			}
+			// the terminal process may have forked into the background, or
+			// handed the command over to a running instance, thus waiting only
+			// for the named pipe
+			logger.Debug("terminal emulator process is finished, waiting on fifo")
+			waitCh = nil
+		}
+	}
+}
+
+// NewTerminal instantiates the Terminal launcher with the informed terminal
+// emulator command, when only a known emulator name is informed its arguments
+// to execute a command are added.
+func NewTerminal(terminal, tmpDir string) *Terminal {
+	command := strings.Fields(terminal)
+	if len(command) == 1 {
+		command = append(command, terminalExecArgs[command[0]]...)
+	}
+	return &Terminal{command: command, tmpDir: tmpDir}
+}
@@ -0,0 +1,77 @@
+package editor
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// forkingTerminal fake terminal emulator which runs the command in the
+// background and exits right away.
+const forkingTerminal = `#!/bin/sh
+( "$@" ) >/dev/null 2>&1 &
+exit 0
+`
+
+func TestTerminal_Launch(t *testing.T) {
+	tmpDir := t.TempDir()
+	terminal := filepath.Join(tmpDir, "fake-terminal")
+	if err := os.WriteFile(terminal, []byte(forkingTerminal), 0o700); err != nil {
+		t.Fatalf("unable to create fake terminal: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(
+		os.Stdout,
+		&slog.HandlerOptions{Level: slog.LevelDebug},
+	))
+
+	tests := []struct {
+		name       string
+		terminal   string
+		script     []string
+		wantErr    bool
+		wantStatus int
+	}{{
+		name:     "forking terminal, editor succeeds",
+		terminal: terminal,
+		script:   []string{"sh", "-c", "sleep 0.2", "file"},
+		wantErr:  false,
+	}, {
+		name:       "forking terminal, editor fails",
+		terminal:   terminal,
+		script:     []string{"sh", "-c", "sleep 0.2; exit 3", "file"},
+		wantErr:    true,
+		wantStatus: 3,
+	}, {
+		name:     "terminal fails",
+		terminal: "false",
+		script:   []string{"true"},
+		wantErr:  true,
+	}, {
+		name:     "blank terminal",
+		terminal: " \t",
+		script:   []string{"true"},
+		wantErr:  true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := NewTerminal(tt.terminal, tmpDir)
+			err := term.Launch(logger, tt.script)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Terminal.Launch() error = %v, wantErr %v",
+					err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrEditorFailed) {
+				t.Errorf("Terminal.Launch() error = %v, expected %v",
+					err, ErrEditorFailed)
+			}
+			if tt.wantStatus != 0 && ExitStatus(err) != tt.wantStatus {
+				t.Errorf("ExitStatus() = %d, expected %d",
+					ExitStatus(err), tt.wantStatus)
+			}
+		})
+	}
+}
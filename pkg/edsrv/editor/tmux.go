@@ -0,0 +1,139 @@
+package editor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tmuxPollInterval interval to verify the editor pane is still running.
+const tmuxPollInterval = 500 * time.Millisecond
+
+// Tmux launches terminal based editors on a new window or pane of a running tmux
+// session, the completion is signaled using "tmux wait-for". The pane is
+// watched meanwhile, a pane killed before signaling fails the edit.
+type Tmux struct {
+	mode     string        // tmux mode, new window or pane
+	target   string        // optional target session or window
+	tmpDir   string        // path to temporary directory, for the exit status file
+	interval time.Duration // pane poll interval
+}
+
+var _ Launcher = &Tmux{}
+
+// tmux runs the tmux command with the informed arguments, returning its trimmed
+// output, on error the combined output is part of the error.
+func (*Tmux) tmux(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("tmux", args...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: tmux %s: %w (%s)", ErrEditorFailed, args[0], err,
+			strings.TrimSpace(string(output)+stderr.String()))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// alive asserts the pane exists and its command is still running, panes may be
+// kept after the command exits ("remain-on-exit").
+func (t *Tmux) alive(pane string) bool {
+	dead, err := t.tmux("display-message", "-p", "-t", pane, "#{pane_dead}")
+	return err == nil && dead != "1"
+}
+
+// wait waits until the channel is signaled, or the pane is gone. When the pane
+// is gone the exit status file tells whether the script was done.
+func (t *Tmux) wait(channel, pane, statusFile string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	waitCh := make(chan error, 1)
+	go func() {
+		// when the script signals the channel before the command starts, tmux
+		// holds the signal and wait-for returns right away
+		waitCh <- exec.CommandContext(ctx, "tmux", "wait-for", channel).Run()
+	}()
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-waitCh:
+			if err != nil {
+				return fmt.Errorf("%w: tmux wait-for: %w", ErrEditorFailed, err)
+			}
+			return nil
+		case <-ticker.C:
+			if t.alive(pane) {
+				continue
+			}
+			if _, err := os.Stat(statusFile); err == nil {
+				return nil
+			}
+			return fmt.Errorf("%w: tmux pane %s is gone without exit status",
+				ErrEditorFailed, pane)
+		}
+	}
+}
+
+// Launch runs the script on a new tmux window or pane, and waits until the
+// channel is signaled by the script.
+func (t *Tmux) Launch(logger *slog.Logger, script []string) error {
+	channel, err := randomName("edsrv-", "")
+	if err != nil {
+		return err
+	}
+	statusFile := filepath.Join(t.tmpDir, fmt.Sprintf("%s.status", channel))
+	defer os.Remove(statusFile)
+
+	notify := fmt.Sprintf(`printf "%%d\n" "$status" >%s; tmux wait-for -S %s`,
+		shellQuote(statusFile), channel)
+
+	args := []string{"new-window", "-P", "-F", "#{pane_id}"}
+	if t.mode == TmuxPane {
+		args[0] = "split-window"
+	}
+	if t.target != "" {
+		args = append(args, "-t", t.target)
+	}
+	args = append(args, "sh", "-c", wrapperScript(notify), "edsrv")
+	args = append(args, script...)
+
+	logger = logger.With("tmux", t.mode, "channel", channel)
+	logger.Info("running editor on tmux and waiting...")
+
+	pane, err := t.tmux(args...)
+	if err != nil {
+		logger.Error("unable to start tmux", "err", err.Error())
+		return err
+	}
+	logger = logger.With("pane", pane)
+	if err = t.wait(channel, pane, statusFile); err != nil {
+		logger.Error("editor pane failed", "err", err.Error())
+		return err
+	}
+
+	status, err := os.ReadFile(statusFile)
+	if err != nil {
+		return err
+	}
+	logger.Debug("editor exit status", "status", strings.TrimSpace(string(status)))
+	return parseExitStatus(string(status))
+}
+
+// NewTmux instantiates the Tmux launcher with the informed mode and optional
+// target.
+func NewTmux(mode, target, tmpDir string) *Tmux {
+	return &Tmux{
+		mode:     mode,
+		target:   target,
+		tmpDir:   tmpDir,
+		interval: tmuxPollInterval,
+	}
+}
@@ -0,0 +1,112 @@
+package editor
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTmux fake tmux command, records the arguments and runs the command after
+// "sh" in the background, "wait-for" channels are files on the state directory,
+// the pane is alive while the background command runs.
+const fakeTmux = `#!/bin/sh
+state="$(dirname "$0")"
+case "$1" in
+wait-for)
+	if [ "$2" = "-S" ]; then
+		touch "${state}/$3"
+		exit 0
+	fi
+	while [ ! -f "${state}/$2" ]; do sleep 0.05; done
+	;;
+new-window|split-window)
+	echo "$@" >>"${state}/args"
+	while [ "$1" != "sh" ]; do shift; done
+	( "$@" ) >/dev/null 2>&1 &
+	echo $! >"${state}/pane"
+	echo "%1"
+	;;
+display-message)
+	kill -0 "$(cat "${state}/pane")" 2>/dev/null || exit 1
+	echo 0
+	;;
+*)
+	exit 1
+	;;
+esac
+`
+
+func TestTmux_Launch(t *testing.T) {
+	binDir := t.TempDir()
+	tmux := filepath.Join(binDir, "tmux")
+	if err := os.WriteFile(tmux, []byte(fakeTmux), 0o700); err != nil {
+		t.Fatalf("unable to create fake tmux: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	logger := slog.New(slog.NewTextHandler(
+		os.Stdout,
+		&slog.HandlerOptions{Level: slog.LevelDebug},
+	))
+
+	tests := []struct {
+		name       string
+		mode       string
+		target     string
+		script     []string
+		wantArgs   string
+		wantErr    bool
+		wantStatus int
+	}{{
+		name:     "new window, editor succeeds",
+		mode:     TmuxWindow,
+		script:   []string{"sh", "-c", "sleep 0.2", "file"},
+		wantArgs: "new-window -P -F #{pane_id} sh -c",
+	}, {
+		name:       "pane on target, editor fails",
+		mode:       TmuxPane,
+		target:     "work:1",
+		script:     []string{"sh", "-c", "sleep 0.2; exit 3", "file"},
+		wantArgs:   "split-window -P -F #{pane_id} -t work:1 sh -c",
+		wantErr:    true,
+		wantStatus: 3,
+	}, {
+		name:     "pane killed",
+		mode:     TmuxPane,
+		script:   []string{"sh", "-c", "kill -9 $PPID", "file"},
+		wantArgs: "split-window -P -F #{pane_id} sh -c",
+		wantErr:  true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			argsFile := filepath.Join(binDir, "args")
+			_ = os.Remove(argsFile)
+
+			tmux := NewTmux(tt.mode, tt.target, t.TempDir())
+			tmux.interval = 50 * time.Millisecond
+			err := tmux.Launch(logger, tt.script)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Tmux.Launch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrEditorFailed) {
+				t.Errorf("Tmux.Launch() error = %v, expected %v", err, ErrEditorFailed)
+			}
+			if tt.wantStatus != 0 && ExitStatus(err) != tt.wantStatus {
+				t.Errorf("ExitStatus() = %d, expected %d",
+					ExitStatus(err), tt.wantStatus)
+			}
+			args, err := os.ReadFile(argsFile)
+			if err != nil {
+				t.Fatalf("unable to read tmux arguments: %v", err)
+			}
+			if !strings.HasPrefix(string(args), tt.wantArgs) {
+				t.Errorf("tmux arguments = %q, expected prefix %q", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -1,10 +1,14 @@
 package file
 
-import "log/slog"
+import (
+	"bytes"
+	"log/slog"
+)
 
 type FakeFile struct {
-	name    string
-	payload []byte
+	name     string
+	original []byte
+	payload  []byte
 }
 
 var _ Interface = &FakeFile{}
@@ -21,13 +25,18 @@ func (f *FakeFile) Read() ([]byte, error) {
 	return f.payload, nil
 }
 
+func (f *FakeFile) Changed() (bool, error) {
+	return !bytes.Equal(f.original, f.payload), nil
+}
+
 func (*FakeFile) Remove() error {
 	return nil
 }
 
-func NewFakeFile(name string, payload []byte) *FakeFile {
+func NewFakeFile(name string, original, payload []byte) *FakeFile {
 	return &FakeFile{
-		name:    name,
-		payload: payload,
+		name:     name,
+		original: original,
+		payload:  payload,
 	}
 }
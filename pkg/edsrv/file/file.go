@@ -1,14 +1,21 @@
 package file
 
 import (
+	"crypto/sha256"
+	"errors"
 	"log/slog"
 	"os"
+	"syscall"
+	"time"
 )
 
 // File represents the temporary file to be edited by an external editor.
 type File struct {
-	name string // full path to temporary file
-	size int    // original size bytes
+	name    string            // full path to temporary file
+	size    int               // original size bytes
+	shred   bool              // overwrite the content before removing
+	modTime time.Time         // original modification time
+	hash    [sha256.Size]byte // original content hash
 }
 
 // LoggerWith decorates logger with current File instance attributes.
@@ -21,28 +28,104 @@ func (f *File) Name() string {
 	return f.name
 }
 
-// Read reads temporary file content.
+// Read reads temporary file content, the file must not be replaced by a symbolic
+// link or another user's file after the editor is done with it.
 func (f *File) Read() ([]byte, error) {
+	if _, err := checkOwned(f.name, 0); err != nil {
+		return nil, err
+	}
 	return os.ReadFile(f.name)
 }
 
-// Remove removes the temporary file.
+// Changed asserts the file content is changed, when the modification time and
+// size are the same the file is not written, otherwise the content hash is
+// compared, the editor may save the file without changes.
+func (f *File) Changed() (bool, error) {
+	info, err := checkOwned(f.name, 0)
+	if err != nil {
+		return false, err
+	}
+	if info.ModTime().Equal(f.modTime) && info.Size() == int64(f.size) {
+		return false, nil
+	}
+	payload, err := f.Read()
+	if err != nil {
+		return false, err
+	}
+	return sha256.Sum256(payload) != f.hash, nil
+}
+
+// overwrite overwrites the file content with zeros, flushing to the disk. The
+// file is opened without following symbolic links.
+func (f *File) overwrite() error {
+	fh, err := os.OpenFile(f.name, os.O_WRONLY|syscall.O_NOFOLLOW, 0)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	info, err := fh.Stat()
+	if err != nil {
+		return err
+	}
+	zeros := make([]byte, 32*1024)
+	for remaining := info.Size(); remaining > 0; {
+		n := min(remaining, int64(len(zeros)))
+		if _, err = fh.Write(zeros[:n]); err != nil {
+			return err
+		}
+		remaining -= n
+	}
+	return fh.Sync()
+}
+
+// Remove removes the temporary file, when enabled the content is overwritten
+// before removing.
 func (f *File) Remove() error {
+	if f.shred {
+		if err := f.overwrite(); err != nil {
+			return errors.Join(err, os.Remove(f.name))
+		}
+	}
 	return os.Remove(f.name)
 }
 
-// NewFile instantiate a new temporary file on the informed directory, and using
-// the informed payload for its contents.
-func NewFile(tmpDir string, payload []byte) (*File, error) {
-	f, err := os.CreateTemp(tmpDir, "edsrv-*")
+// NewFile instantiate a new temporary file on the informed directory, with the
+// file name extension, and using the informed payload for its contents. The
+// file is created exclusively, only accessible by the current user ("0600").
+// With shred the content is overwritten before removing. The modification time
+// is set in the past, so any write by the editor changes it, even on file
+// systems with coarse timestamps.
+func NewFile(tmpDir, ext string, payload []byte, shred bool) (*File, error) {
+	fh, err := os.CreateTemp(tmpDir, "edsrv-*"+ext)
 	if err != nil {
 		return nil, err
 	}
-	if _, err = f.Write(payload); err != nil {
-		return nil, err
+	f := &File{
+		name:  fh.Name(),
+		size:  len(payload),
+		shred: shred,
+		hash:  sha256.Sum256(payload),
 	}
-	if err = f.Close(); err != nil {
-		return nil, err
+	if err = fh.Chmod(0o600); err != nil {
+		_ = fh.Close()
+		return nil, errors.Join(err, f.Remove())
+	}
+	if _, err = fh.Write(payload); err != nil {
+		_ = fh.Close()
+		return nil, errors.Join(err, f.Remove())
+	}
+	if err = fh.Close(); err != nil {
+		return nil, errors.Join(err, f.Remove())
+	}
+	past := time.Now().Add(-time.Minute)
+	if err = os.Chtimes(f.name, past, past); err != nil {
+		return nil, errors.Join(err, f.Remove())
+	}
+	info, err := os.Stat(f.name)
+	if err != nil {
+		return nil, errors.Join(err, f.Remove())
 	}
-	return &File{name: f.Name(), size: len(payload)}, nil
+	f.modTime = info.ModTime()
+	return f, nil
 }
@@ -0,0 +1,199 @@
+package file
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewPrivateDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    fs.FileMode
+		wantErr bool
+	}{{
+		name: "private directory",
+		mode: 0o700,
+	}, {
+		name: "world-writable with sticky bit",
+		mode: 0o777 | fs.ModeSticky,
+	}, {
+		name:    "world-writable without sticky bit",
+		mode:    0o777,
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			if err := os.Chmod(tmpDir, tt.mode); err != nil {
+				t.Fatalf("unable to change mode: %v", err)
+			}
+			dir, err := NewPrivateDir(tmpDir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewPrivateDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInsecure) {
+					t.Errorf("NewPrivateDir() error = %v, expected %v", err, ErrInsecure)
+				}
+				return
+			}
+			info, err := os.Lstat(dir)
+			if err != nil {
+				t.Fatalf("unable to stat private directory: %v", err)
+			}
+			if info.Mode().Perm() != 0o700 {
+				t.Errorf("NewPrivateDir() mode = %s, expected 0700", info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	f, err := NewFile(dir, "", []byte("secret"), true)
+	if err != nil {
+		t.Fatalf("NewFile() error = %v", err)
+	}
+	info, err := os.Stat(f.Name())
+	if err != nil {
+		t.Fatalf("unable to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("NewFile() mode = %s, expected 0600", info.Mode().Perm())
+	}
+
+	// a hard link keeps the inode reachable, showing the content is overwritten
+	link := filepath.Join(dir, "link")
+	if err = os.Link(f.Name(), link); err != nil {
+		t.Fatalf("unable to link file: %v", err)
+	}
+	if err = f.Remove(); err != nil {
+		t.Fatalf("File.Remove() error = %v", err)
+	}
+	payload, err := os.ReadFile(link)
+	if err != nil {
+		t.Fatalf("unable to read link: %v", err)
+	}
+	if string(payload) != "\x00\x00\x00\x00\x00\x00" {
+		t.Errorf("File.Remove() left content %q, expected zeros", payload)
+	}
+
+	// replacing the file by a symbolic link is refused
+	f, err = NewFile(dir, "", []byte("text"), false)
+	if err != nil {
+		t.Fatalf("NewFile() error = %v", err)
+	}
+	if err = os.Remove(f.Name()); err != nil {
+		t.Fatalf("unable to remove file: %v", err)
+	}
+	if err = os.Symlink(link, f.Name()); err != nil {
+		t.Fatalf("unable to create symbolic link: %v", err)
+	}
+	if _, err = f.Read(); !errors.Is(err, ErrInsecure) {
+		t.Errorf("File.Read() error = %v, expected %v", err, ErrInsecure)
+	}
+}
+
+func TestFile_Changed(t *testing.T) {
+	tests := []struct {
+		name  string
+		write []byte
+		want  bool
+	}{{
+		name:  "not written",
+		write: nil,
+		want:  false,
+	}, {
+		name:  "saved without changes",
+		write: []byte("text"),
+		want:  false,
+	}, {
+		name:  "same size, different content",
+		write: []byte("TEXT"),
+		want:  true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFile(t.TempDir(), "", []byte("text"), false)
+			if err != nil {
+				t.Fatalf("NewFile() error = %v", err)
+			}
+			defer f.Remove()
+			if tt.write != nil {
+				if err = os.WriteFile(f.Name(), tt.write, 0o600); err != nil {
+					t.Fatalf("unable to write file: %v", err)
+				}
+			}
+			got, err := f.Changed()
+			if err != nil {
+				t.Fatalf("File.Changed() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("File.Changed() = %v, expected %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeExtension(t *testing.T) {
+	tests := []struct {
+		name    string
+		ext     string
+		want    string
+		wantErr bool
+	}{{
+		name: "empty",
+		ext:  "",
+		want: "",
+	}, {
+		name: "without dot",
+		ext:  "md",
+		want: ".md",
+	}, {
+		name: "multiple parts",
+		ext:  ".tar.gz",
+		want: ".tar.gz",
+	}, {
+		name:    "path separator",
+		ext:     "../md",
+		wantErr: true,
+	}, {
+		name:    "empty part",
+		ext:     ".md.",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeExtension(tt.ext)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeExtension() error = %v, wantErr %v",
+					err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidExtension) {
+					t.Errorf("NormalizeExtension() error = %v, expected %v",
+						err, ErrInvalidExtension)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeExtension() = %q, expected %q", got, tt.want)
+			}
+			f, err := NewFile(t.TempDir(), got, []byte("text"), false)
+			if err != nil {
+				t.Fatalf("NewFile() error = %v", err)
+			}
+			defer f.Remove()
+			if !strings.HasSuffix(f.Name(), tt.want) {
+				t.Errorf("NewFile() name = %q, expected suffix %q", f.Name(), tt.want)
+			}
+		})
+	}
+}
@@ -12,6 +12,9 @@ type Interface interface {
 	// Read reads file contents.
 	Read() ([]byte, error)
 
+	// Changed asserts the file content is changed since created.
+	Changed() (bool, error)
+
 	// Remove removes the temporary file.
 	Remove() error
 }
@@ -0,0 +1,88 @@
+//go:build linux
+
+package file
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// MemFile represents the temporary file backed by a anonymous memory file
+// ("memfd_create"), the editor accesses the content through the file descriptor
+// path on "/proc", thus the content is never written to disk.
+type MemFile struct {
+	fh   *os.File          // memory file handle
+	name string            // file descriptor path on "/proc"
+	size int               // original size bytes
+	hash [sha256.Size]byte // original content hash
+}
+
+var _ Interface = &MemFile{}
+
+// LoggerWith decorates logger with current MemFile instance attributes.
+func (m *MemFile) LoggerWith(logger *slog.Logger) *slog.Logger {
+	return logger.With("file", m.name, "size", m.size, "storage", StorageMemory)
+}
+
+// Name shows the file descriptor path on "/proc".
+func (m *MemFile) Name() string {
+	return m.name
+}
+
+// Read reads the memory file content.
+func (m *MemFile) Read() ([]byte, error) {
+	if _, err := m.fh.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(m.fh)
+}
+
+// Changed asserts the memory file content is changed, comparing the content
+// hash.
+func (m *MemFile) Changed() (bool, error) {
+	payload, err := m.Read()
+	if err != nil {
+		return false, err
+	}
+	return sha256.Sum256(payload) != m.hash, nil
+}
+
+// Remove releases the memory file, the content is overwritten before.
+func (m *MemFile) Remove() error {
+	if info, err := m.fh.Stat(); err == nil {
+		_, _ = m.fh.WriteAt(make([]byte, info.Size()), 0)
+	}
+	return m.fh.Close()
+}
+
+// IsMemory asserts the file is memory-backed.
+func IsMemory(f Interface) bool {
+	_, ok := f.(*MemFile)
+	return ok
+}
+
+// NewMemFile instantiates a new memory file using the informed payload for its
+// contents.
+func NewMemFile(payload []byte) (*MemFile, error) {
+	fd, err := unix.MemfdCreate("edsrv", unix.MFD_CLOEXEC)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMemoryUnsupported, err)
+	}
+	name := fmt.Sprintf("/proc/%d/fd/%d", os.Getpid(), fd)
+	fh := os.NewFile(uintptr(fd), name)
+	if _, err = fh.Write(payload); err != nil {
+		_ = fh.Close()
+		return nil, err
+	}
+	return &MemFile{
+		fh:   fh,
+		name: name,
+		size: len(payload),
+		hash: sha256.Sum256(payload),
+	}, nil
+}
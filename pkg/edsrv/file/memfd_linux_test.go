@@ -0,0 +1,32 @@
+package file
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMemFile(t *testing.T) {
+	m, err := NewMemFile([]byte("secret"))
+	if err != nil {
+		t.Fatalf("NewMemFile() error = %v", err)
+	}
+	if !strings.HasPrefix(m.Name(), "/proc/") {
+		t.Errorf("MemFile.Name() = %q, expected a /proc path", m.Name())
+	}
+
+	// the editor writes through the file descriptor path
+	if err = os.WriteFile(m.Name(), []byte("edited"), 0o600); err != nil {
+		t.Fatalf("unable to write memory file: %v", err)
+	}
+	payload, err := m.Read()
+	if err != nil {
+		t.Fatalf("MemFile.Read() error = %v", err)
+	}
+	if string(payload) != "edited" {
+		t.Errorf("MemFile.Read() = %q, expected %q", payload, "edited")
+	}
+	if err = m.Remove(); err != nil {
+		t.Fatalf("MemFile.Remove() error = %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !linux
+
+package file
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// MemFile represents the temporary file backed by a anonymous memory file, only
+// supported on Linux.
+type MemFile struct {
+	Interface
+}
+
+// IsMemory asserts the file is memory-backed, never on this platform.
+func IsMemory(Interface) bool {
+	return false
+}
+
+// NewMemFile instantiates a new memory file using the informed payload for its
+// contents, on this platform it fails with ErrMemoryUnsupported, and the disk
+// storage is used instead.
+func NewMemFile([]byte) (*MemFile, error) {
+	return nil, fmt.Errorf("%w: memfd_create is not available on %s",
+		ErrMemoryUnsupported, runtime.GOOS)
+}
@@ -0,0 +1,92 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ErrInsecure the temporary directory or file is not safe to use, it could be
+// tampered with by other users.
+var ErrInsecure = errors.New("insecure temporary location")
+
+// owner returns the file owner user ID.
+func owner(info fs.FileInfo) (int, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(stat.Uid), true
+}
+
+// CheckTmpDir asserts the temporary directory is safe to use, it must be owned by
+// the current user or root, and when world-writable it must have the sticky bit
+// set, so other users can't remove or replace the entries.
+func CheckTmpDir(tmpDir string) error {
+	dir, err := filepath.EvalSymlinks(tmpDir)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %q is not a directory", ErrInsecure, tmpDir)
+	}
+	if uid, ok := owner(info); ok && uid != os.Getuid() && uid != 0 {
+		return fmt.Errorf("%w: %q is owned by another user (%d)",
+			ErrInsecure, tmpDir, uid)
+	}
+	mode := info.Mode()
+	if mode.Perm()&0o002 != 0 && mode&fs.ModeSticky == 0 {
+		return fmt.Errorf("%w: %q is world-writable without the sticky bit",
+			ErrInsecure, tmpDir)
+	}
+	return nil
+}
+
+// checkOwned asserts the path is not a symbolic link, it's owned by the current
+// user, and the informed permission bits are not set.
+func checkOwned(path string, forbidden fs.FileMode) (fs.FileInfo, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		return nil, fmt.Errorf("%w: %q is a symbolic link", ErrInsecure, path)
+	}
+	if uid, ok := owner(info); ok && uid != os.Getuid() {
+		return nil, fmt.Errorf("%w: %q is owned by another user (%d)",
+			ErrInsecure, path, uid)
+	}
+	if info.Mode().Perm()&forbidden != 0 {
+		return nil, fmt.Errorf("%w: %q is accessible by other users (%s)",
+			ErrInsecure, path, info.Mode().Perm())
+	}
+	return info, nil
+}
+
+// NewPrivateDir creates a private ("0700") subdirectory on the temporary
+// directory, randomly named, after asserting the temporary directory is safe.
+// The subdirectory is meant to hold the temporary files for the server lifetime.
+func NewPrivateDir(tmpDir string) (string, error) {
+	if err := CheckTmpDir(tmpDir); err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(tmpDir, "edsrv-")
+	if err != nil {
+		return "", err
+	}
+	info, err := checkOwned(dir, 0o077)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%w: %q is not a directory", ErrInsecure, dir)
+	}
+	return dir, nil
+}
@@ -0,0 +1,48 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// StorageDisk temporary files stored on the temporary directory.
+	StorageDisk = "disk"
+	// StorageMemory temporary files stored in memory, never written to disk.
+	StorageMemory = "memory"
+)
+
+// Storages supported storage options.
+var Storages = []string{StorageDisk, StorageMemory}
+
+// ErrMemoryUnsupported memory-backed files are not supported on the platform.
+var ErrMemoryUnsupported = errors.New("memory-backed files are not supported")
+
+// ErrInvalidExtension the file name extension is not supported.
+var ErrInvalidExtension = errors.New("invalid file extension")
+
+// Options represents the temporary file options.
+type Options struct {
+	Storage   string // temporary file storage, disk or memory
+	Extension string // file name extension, i.e. ".md", only on disk storage
+}
+
+// extensionRe a file name extension, one or more dot separated parts.
+var extensionRe = regexp.MustCompile(`^(\.[A-Za-z0-9_+-]+)+$`)
+
+// NormalizeExtension returns the file name extension with the leading dot, i.e.
+// "md" becomes ".md". Empty extensions are kept.
+func NormalizeExtension(ext string) (string, error) {
+	if ext == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	if len(ext) > 32 || !extensionRe.MatchString(ext) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidExtension, ext)
+	}
+	return ext, nil
+}
@@ -0,0 +1,97 @@
+package filter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/tracing"
+)
+
+// Filter represents a external command which reads the text on stdin and writes
+// the filtered text on stdout.
+type Filter struct {
+	Command string        `yaml:"command"`           // shell command snippet
+	Timeout time.Duration `yaml:"timeout,omitempty"` // maximum execution time
+}
+
+// waitDelay grace period for the command output after the timeout, child
+// processes may hold the pipes open.
+const waitDelay = 100 * time.Millisecond
+
+// ErrFilterFailed the filter command failed or timed out.
+var ErrFilterFailed = errors.New("filter failed")
+
+// run runs the filter command with the informed input, returns the filter
+// output.
+func (f *Filter) run(
+	ctx context.Context,
+	timeout time.Duration,
+	input []byte,
+) ([]byte, error) {
+	if f.Timeout > 0 {
+		timeout = f.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", f.Command) //nolint:gosec
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = waitDelay
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timeout after %s", timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("%w: %q: %w", ErrFilterFailed, f.Command, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// Run runs the filters in order, each filter output is the next filter input,
+// the pipeline stops on the first failing filter. The default timeout is used
+// for filters without their own. Each filter is traced as a child span.
+func Run(
+	ctx context.Context,
+	logger *slog.Logger,
+	filters []Filter,
+	timeout time.Duration,
+	input []byte,
+) ([]byte, error) {
+	var err error
+	for i := range filters {
+		logger := logger.With("filter", filters[i].Command)
+		logger.Debug("running filter...")
+		_, span := tracing.Start(ctx, "filter")
+		span.SetAttr("filter.command", filters[i].Command)
+		input, err = filters[i].run(ctx, timeout, input)
+		span.RecordError(err)
+		span.End()
+		if err != nil {
+			logger.Error("filter failed", "err", err.Error())
+			return nil, err
+		}
+	}
+	return input, nil
+}
+
+// FromCommands instantiates filters for the informed command snippets, using the
+// default timeout.
+func FromCommands(commands []string) []Filter {
+	var filters []Filter
+	for _, command := range commands {
+		filters = append(filters, Filter{Command: command})
+	}
+	return filters
+}
@@ -0,0 +1,55 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	tests := []struct {
+		name    string
+		filters []Filter
+		want    string
+		wantErr bool
+	}{{
+		name:    "no filters",
+		filters: nil,
+		want:    "hello world\n",
+	}, {
+		name:    "pipeline",
+		filters: FromCommands([]string{"tr a-z A-Z", "sed s/WORLD/THERE/"}),
+		want:    "HELLO THERE\n",
+	}, {
+		name:    "failing filter",
+		filters: FromCommands([]string{"tr a-z A-Z", "echo oops >&2; exit 1"}),
+		wantErr: true,
+	}, {
+		name:    "filter timeout",
+		filters: []Filter{{Command: "sleep 5", Timeout: 100 * time.Millisecond}},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(context.Background(), logger, tt.filters, time.Second, []byte("hello world\n"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrFilterFailed) {
+					t.Errorf("Run() error = %v, expected %v", err, ErrFilterFailed)
+				}
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("Run() = %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
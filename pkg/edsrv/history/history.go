@@ -0,0 +1,429 @@
+package history
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/crypt"
+)
+
+// Entry represents the metadata of a recorded edit.
+type Entry struct {
+	ID         string    `json:"id"`                // entry identifier, sortable by time
+	URL        string    `json:"url,omitempty"`     // page URL
+	Title      string    `json:"title,omitempty"`   // page title
+	FieldID    string    `json:"fieldId,omitempty"` // field ID
+	Editor     string    `json:"editor,omitempty"`  // editor command
+	ExitStatus int       `json:"exitStatus"`        // editor exit status
+	Result     string    `json:"result,omitempty"`  // edit result, changed, aborted, etc
+	Error      string    `json:"error,omitempty"`   // edit error message
+	Started    time.Time `json:"started"`           // edit start
+	Finished   time.Time `json:"finished"`          // edit end
+	Size       int64     `json:"-"`                 // entry size on disk
+	Err        error     `json:"-"`                 // metadata decoding error
+}
+
+// Retention represents the history retention limits, zero values disable the
+// respective limit.
+type Retention struct {
+	MaxAge   time.Duration // maximum entry age
+	MaxCount int           // maximum number of entries
+	MaxSize  int64         // maximum total size in bytes
+}
+
+// Store represents the history directory, also employed for the recovery area,
+// each entry is a directory holding the metadata, original and edited texts.
+type Store struct {
+	dir       string        // history directory
+	retention Retention     // retention limits
+	cipher    *crypt.Cipher // encrypts the stored files, optional
+	mutex     sync.Mutex    // serializes the writes
+}
+
+const (
+	// Original original text file name.
+	Original = "original"
+	// Edited edited text file name.
+	Edited = "edited"
+	// metadataFile entry metadata file name.
+	metadataFile = "entry.json"
+	// idLayout time layout of the entry identifier.
+	idLayout = "20060102T150405.000000"
+)
+
+// files entry file names.
+var files = []string{metadataFile, Original, Edited}
+
+var (
+	// ErrEntryNotFound the informed history entry is not found.
+	ErrEntryNotFound = errors.New("history entry not found")
+	// ErrEncrypted the entry is encrypted, the key is required.
+	ErrEncrypted = errors.New("entry is encrypted, key is required")
+)
+
+// GetDir exposes the history directory.
+func (s *Store) GetDir() string {
+	return s.dir
+}
+
+// newID generates a new entry identifier, prefixed by the UTC time.
+func newID(t time.Time) (string, error) {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return t.UTC().Format(idLayout) + "-" + hex.EncodeToString(suffix), nil
+}
+
+// entryDir returns the entry directory, the ID must not contain path elements.
+func (s *Store) entryDir(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("%w: %q", ErrEntryNotFound, id)
+	}
+	return filepath.Join(s.dir, id), nil
+}
+
+// writeFile writes the entry file, encrypted when the cipher is set. The entry ID
+// and file name are authenticated, so files can't be swapped.
+func (s *Store) writeFile(c *crypt.Cipher, id, name string, data []byte) error {
+	dir, err := s.entryDir(id)
+	if err != nil {
+		return err
+	}
+	if c != nil {
+		if data, err = c.Seal(data, []byte(id+"/"+name)); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filepath.Join(dir, name), data, 0o600)
+}
+
+// decode decrypts the entry file content, when the cipher is set the content
+// must be encrypted, and encrypted content requires the cipher.
+func decode(c *crypt.Cipher, id, name string, data []byte) ([]byte, error) {
+	if c == nil {
+		if crypt.IsSealed(data) {
+			return nil, fmt.Errorf("%w: %s/%s", ErrEncrypted, id, name)
+		}
+		return data, nil
+	}
+	data, err := c.Open(data, []byte(id+"/"+name))
+	if errors.Is(err, crypt.ErrNotSealed) {
+		return nil, fmt.Errorf("%s/%s: %w, stored before the key, encrypt it with "+
+			"\"store rekey\"", id, name, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s/%s: %w", id, name, err)
+	}
+	return data, nil
+}
+
+// readFile reads the entry file, decrypting when the cipher is set.
+func (s *Store) readFile(id, name string) ([]byte, error) {
+	p, err := s.Path(id, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	return decode(s.cipher, id, name, data)
+}
+
+// Save records the edit entry with the original and edited texts, and prunes
+// the history afterwards.
+func (s *Store) Save(e *Entry, original, edited []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var err error
+	if e.ID == "" {
+		if e.ID, err = newID(e.Started); err != nil {
+			return err
+		}
+	}
+	dir, err := s.entryDir(e.ID)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	contents := map[string][]byte{
+		metadataFile: data,
+		Original:     original,
+		Edited:       edited,
+	}
+	for name, payload := range contents {
+		if payload == nil {
+			continue
+		}
+		if err = s.writeFile(s.cipher, e.ID, name, payload); err != nil {
+			return err
+		}
+	}
+	_, err = s.prune(time.Now())
+	return err
+}
+
+// load reads the entry metadata and its size on disk.
+func (s *Store) load(id string) (*Entry, error) {
+	dir, err := s.entryDir(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.readFile(id, metadataFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %q", ErrEntryNotFound, id)
+		}
+		return nil, err
+	}
+	e := &Entry{}
+	if err = json.Unmarshal(data, e); err != nil {
+		return nil, fmt.Errorf("%q: %w", id, err)
+	}
+	e.ID = id
+	if e.Size, err = dirSize(dir); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// dirSize returns the entry files total size.
+func dirSize(dir string) (int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, de := range entries {
+		if info, err := de.Info(); err == nil {
+			size += info.Size()
+		}
+	}
+	return size, nil
+}
+
+// undecodable returns the entry marked with the decoding error, the finished
+// time is the entry directory modification time, so retention still applies.
+func (s *Store) undecodable(id string, decodeErr error) (*Entry, error) {
+	dir, err := s.entryDir(id)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{ID: id, Finished: info.ModTime(), Err: decodeErr}
+	if e.Size, err = dirSize(dir); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Get returns the informed entry metadata.
+func (s *Store) Get(id string) (*Entry, error) {
+	return s.load(id)
+}
+
+// Path returns the entry text file path, either Original or Edited.
+func (s *Store) Path(id, name string) (string, error) {
+	dir, err := s.entryDir(id)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Read reads the entry text, either Original or Edited.
+func (s *Store) Read(id, name string) ([]byte, error) {
+	data, err := s.readFile(id, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %q has no %s text", ErrEntryNotFound, id, name)
+	}
+	return data, err
+}
+
+// List returns the entries, newest first. A missing directory has no entries.
+// The entries which metadata can't be decoded, i.e. encrypted without the key,
+// are marked with the error instead.
+func (s *Store) List() ([]*Entry, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []*Entry{}, nil
+		}
+		return nil, err
+	}
+	entries := []*Entry{}
+	for _, de := range dirEntries {
+		if !de.IsDir() {
+			continue
+		}
+		e, err := s.load(de.Name())
+		if err != nil {
+			if e, err = s.undecodable(de.Name(), err); err != nil {
+				continue
+			}
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID > entries[j].ID
+	})
+	return entries, nil
+}
+
+// Remove removes the informed entry.
+func (s *Store) Remove(id string) error {
+	dir, err := s.entryDir(id)
+	if err != nil {
+		return err
+	}
+	if _, err = os.Stat(dir); err != nil {
+		return fmt.Errorf("%w: %q", ErrEntryNotFound, id)
+	}
+	return os.RemoveAll(dir)
+}
+
+// prune removes the entries exceeding the retention limits, newer entries are
+// kept first. Returns the removed entries.
+func (s *Store) prune(now time.Time) ([]*Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	r := s.retention
+	removed := []*Entry{}
+	var total int64
+	for i, e := range entries {
+		total += e.Size
+		expired := r.MaxAge > 0 && now.Sub(e.Finished) > r.MaxAge
+		tooMany := r.MaxCount > 0 && i >= r.MaxCount
+		tooBig := r.MaxSize > 0 && total > r.MaxSize
+		if !expired && !tooMany && !tooBig {
+			continue
+		}
+		if err = s.Remove(e.ID); err != nil {
+			return removed, err
+		}
+		removed = append(removed, e)
+	}
+	return removed, nil
+}
+
+// Prune removes the entries exceeding the retention limits, returns the removed
+// entries.
+func (s *Store) Prune() ([]*Entry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.prune(time.Now())
+}
+
+// entryIDs returns the entry identifiers, a missing directory has no entries.
+func (s *Store) entryIDs() ([]string, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	ids := []string{}
+	for _, de := range dirEntries {
+		if de.IsDir() {
+			ids = append(ids, de.Name())
+		}
+	}
+	return ids, nil
+}
+
+// Verify asserts every entry file is decrypted and authenticated, returns the
+// number of files verified and the errors found.
+func (s *Store) Verify() (int, []error) {
+	ids, err := s.entryIDs()
+	if err != nil {
+		return 0, []error{err}
+	}
+	verified, errs := 0, []error{}
+	for _, id := range ids {
+		for _, name := range files {
+			_, err := s.readFile(id, name)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			verified++
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return verified, errs
+}
+
+// Rekey writes every entry file on the staged directory, encrypted with the
+// next cipher, or plain text when nil. Plain text files are accepted, so existing
+// content can be encrypted. The staged directory replaces the store directory
+// with a Swap.
+func (s *Store) Rekey(next *crypt.Cipher, staged string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ids, err := s.entryIDs()
+	if err != nil {
+		return err
+	}
+	target := NewStore(staged, s.retention, next)
+	for _, id := range ids {
+		dir, err := target.entryDir(id)
+		if err != nil {
+			return err
+		}
+		if err = os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+		for _, name := range files {
+			p, err := s.Path(id, name)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err == nil && crypt.IsSealed(data) {
+				data, err = decode(s.cipher, id, name, data)
+			}
+			if err == nil {
+				err = target.writeFile(next, id, name, data)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return os.MkdirAll(staged, 0o700)
+}
+
+// NewStore instantiates the history store on the informed directory, using the
+// retention limits. When the cipher is set the files are encrypted.
+func NewStore(dir string, retention Retention, c *crypt.Cipher) *Store {
+	return &Store{dir: dir, retention: retention, cipher: c}
+}
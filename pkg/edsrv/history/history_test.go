@@ -0,0 +1,244 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/crypt"
+)
+
+func TestStore(t *testing.T) {
+	s := NewStore(t.TempDir(), Retention{MaxCount: 2}, nil)
+
+	now := time.Now()
+	for i, text := range []string{"first", "second", "third"} {
+		e := &Entry{
+			URL:      "https://example.com",
+			Started:  now.Add(time.Duration(i) * time.Second),
+			Finished: now.Add(time.Duration(i) * time.Second),
+		}
+		if err := s.Save(e, []byte(""), []byte(text)); err != nil {
+			t.Fatalf("Store.Save() error = %v", err)
+		}
+	}
+
+	entries, err := s.List()
+	if err != nil {
+		t.Fatalf("Store.List() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Store.List() = %d entries, expected 2", len(entries))
+	}
+	edited, err := s.Read(entries[0].ID, Edited)
+	if err != nil {
+		t.Fatalf("Store.Read() error = %v", err)
+	}
+	if string(edited) != "third" {
+		t.Errorf("Store.Read() = %q, expected %q", edited, "third")
+	}
+
+	s.retention = Retention{MaxAge: time.Hour}
+	removed, err := s.prune(now.Add(2 * time.Hour))
+	if err != nil {
+		t.Fatalf("Store.prune() error = %v", err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("Store.prune() removed %d entries, expected 2", len(removed))
+	}
+
+	if _, err = s.Get("../etc"); !errors.Is(err, ErrEntryNotFound) {
+		t.Errorf("Store.Get() error = %v, expected %v", err, ErrEntryNotFound)
+	}
+}
+
+func TestStoreEncrypted(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	c, err := crypt.NewCipher(key)
+	if err != nil {
+		t.Fatalf("crypt.NewCipher() error = %v", err)
+	}
+	dir := t.TempDir()
+	s := NewStore(dir, Retention{}, c)
+
+	e := &Entry{URL: "https://example.com", Started: time.Now()}
+	if err = s.Save(e, []byte("original"), []byte("secret text")); err != nil {
+		t.Fatalf("Store.Save() error = %v", err)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, e.ID, Edited))
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	if !crypt.IsSealed(raw) || bytes.Contains(raw, []byte("secret text")) {
+		t.Errorf("stored file is not encrypted: %q", raw)
+	}
+
+	// without the key the entries are marked, pruning still runs
+	noKey := NewStore(dir, Retention{MaxAge: time.Hour}, nil)
+	entries, err := noKey.List()
+	if err != nil {
+		t.Fatalf("Store.List() error = %v", err)
+	}
+	if len(entries) != 1 || !errors.Is(entries[0].Err, ErrEncrypted) {
+		t.Fatalf("Store.List() = %v, expected the entry marked with %v",
+			entries, ErrEncrypted)
+	}
+	if removed, err := noKey.prune(time.Now()); err != nil || len(removed) != 0 {
+		t.Errorf("Store.prune() = %d, %v, expected no entries removed", len(removed), err)
+	}
+
+	next, err := crypt.NewCipher(bytes.Repeat([]byte{2}, 32))
+	if err != nil {
+		t.Fatalf("crypt.NewCipher() error = %v", err)
+	}
+	swap := NewSwap(filepath.Dir(dir))
+	staged, err := swap.Stage(dir)
+	if err != nil {
+		t.Fatalf("Swap.Stage() error = %v", err)
+	}
+	if err = s.Rekey(next, staged); err != nil {
+		t.Fatalf("Store.Rekey() error = %v", err)
+	}
+	if err = swap.Commit(); err != nil {
+		t.Fatalf("Swap.Commit() error = %v", err)
+	}
+
+	if _, errs := s.Verify(); len(errs) == 0 {
+		t.Errorf("Store.Verify() with the previous key expected errors")
+	}
+	rekeyed := NewStore(dir, Retention{}, next)
+	if verified, errs := rekeyed.Verify(); len(errs) > 0 || verified == 0 {
+		t.Errorf("Store.Verify() = %d, %v", verified, errs)
+	}
+	edited, err := rekeyed.Read(e.ID, Edited)
+	if err != nil {
+		t.Fatalf("Store.Read() error = %v", err)
+	}
+	if string(edited) != "secret text" {
+		t.Errorf("Store.Read() = %q, expected %q", edited, "secret text")
+	}
+}
+
+func TestStoreUndecodable(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir, Retention{MaxAge: time.Hour}, nil)
+
+	e := &Entry{URL: "https://example.com", Started: time.Now(), Finished: time.Now()}
+	if err := s.Save(e, []byte("original"), []byte("edited")); err != nil {
+		t.Fatalf("Store.Save() error = %v", err)
+	}
+	broken := filepath.Join(dir, "20200101T000000.000000-abcdef")
+	if err := os.MkdirAll(broken, 0o700); err != nil {
+		t.Fatalf("os.MkdirAll() error = %v", err)
+	}
+	err := os.WriteFile(filepath.Join(broken, metadataFile), []byte("{"), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err = os.Chtimes(broken, old, old); err != nil {
+		t.Fatalf("os.Chtimes() error = %v", err)
+	}
+
+	entries, err := s.List()
+	if err != nil {
+		t.Fatalf("Store.List() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Err != nil || entries[1].Err == nil {
+		t.Fatalf("Store.List() = %v, expected the broken entry marked", entries)
+	}
+
+	removed, err := s.prune(time.Now())
+	if err != nil {
+		t.Fatalf("Store.prune() error = %v", err)
+	}
+	if len(removed) != 1 || removed[0].ID != filepath.Base(broken) {
+		t.Errorf("Store.prune() removed %v, expected the broken entry", removed)
+	}
+}
+
+func TestSwap_Resume(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "history")
+	removed := filepath.Join(dir, "key.json")
+	for _, name := range []string{target, removed} {
+		if err := os.WriteFile(name, []byte("current"), 0o600); err != nil {
+			t.Fatalf("os.WriteFile() error = %v", err)
+		}
+	}
+
+	swap := NewSwap(dir)
+	staged, err := swap.Stage(target)
+	if err != nil {
+		t.Fatalf("Swap.Stage() error = %v", err)
+	}
+	if err = os.WriteFile(staged, []byte("next"), 0o600); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	swap.Remove(removed)
+
+	// interrupted after the journal is written, and the target moved aside
+	data, err := json.Marshal(swap)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, journalFile), data, 0o600); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	if err = os.Rename(target, target+oldSuffix); err != nil {
+		t.Fatalf("os.Rename() error = %v", err)
+	}
+
+	if !Pending(dir) {
+		t.Errorf("Pending() = false, expected the journal found")
+	}
+	resumed, err := Resume(dir)
+	if err != nil || !resumed {
+		t.Fatalf("Resume() = %v, %v, expected the swap resumed", resumed, err)
+	}
+	if content, _ := os.ReadFile(target); string(content) != "next" {
+		t.Errorf("target = %q, expected the staged content", content)
+	}
+	for _, name := range []string{removed, target + oldSuffix, journalFile} {
+		if _, err = os.Stat(filepath.Join(dir, filepath.Base(name))); err == nil {
+			t.Errorf("%q is not removed", name)
+		}
+	}
+	if resumed, err = Resume(dir); err != nil || resumed {
+		t.Errorf("Resume() = %v, %v, expected nothing to resume", resumed, err)
+	}
+	if Pending(dir) {
+		t.Errorf("Pending() = true, expected no journal")
+	}
+}
+
+func TestLock(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "edsrv.lock")
+
+	shared, err := NewLock(name, false)
+	if err != nil {
+		t.Fatalf("NewLock() error = %v", err)
+	}
+	other, err := NewLock(name, false)
+	if err != nil {
+		t.Fatalf("NewLock() shared error = %v", err)
+	}
+	if _, err = NewLock(name, true); !errors.Is(err, ErrLocked) {
+		t.Errorf("NewLock() exclusive error = %v, expected %v", err, ErrLocked)
+	}
+
+	_ = shared.Unlock()
+	_ = other.Unlock()
+	exclusive, err := NewLock(name, true)
+	if err != nil {
+		t.Fatalf("NewLock() exclusive error = %v", err)
+	}
+	defer exclusive.Unlock()
+	if _, err = NewLock(name, false); !errors.Is(err, ErrLocked) {
+		t.Errorf("NewLock() shared error = %v, expected %v", err, ErrLocked)
+	}
+}
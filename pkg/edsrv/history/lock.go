@@ -0,0 +1,54 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ErrLocked the state directory is locked by another process.
+var ErrLocked = errors.New("state directory is locked")
+
+// Lock represents the advisory lock on the state directory, the server holds a
+// shared lock while running, and the re-encryption requires the exclusive lock.
+type Lock struct {
+	f *os.File // lock file
+}
+
+// flock applies the lock operation without blocking.
+func (l *Lock) flock(how int) error {
+	err := syscall.Flock(int(l.f.Fd()), how|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return fmt.Errorf("%w: %q is in use by another edsrv process",
+			ErrLocked, l.f.Name())
+	}
+	return err
+}
+
+// Unlock releases the lock.
+func (l *Lock) Unlock() error {
+	return l.f.Close()
+}
+
+// NewLock acquires the lock file, either exclusive or shared, without waiting.
+func NewLock(name string, exclusive bool) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	l := &Lock{f: f}
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if err = l.flock(how); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return l, nil
+}
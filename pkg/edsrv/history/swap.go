@@ -0,0 +1,154 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// journalFile the committed swap journal, on the state directory.
+	journalFile = "swap.json"
+	// stagedSuffix suffix of the staged files and directories.
+	stagedSuffix = ".staged"
+	// oldSuffix suffix of the replaced files and directories, until the swap is
+	// done.
+	oldSuffix = ".old"
+)
+
+// swapItem represents a target replaced by the staged path, or removed.
+type swapItem struct {
+	Target string `json:"target"`           // file or directory replaced
+	Remove bool   `json:"remove,omitempty"` // the target is removed instead
+}
+
+// Swap replaces files and directories by their staged versions together. Once
+// committed, a journal on the state directory allows finishing an interrupted
+// swap, see Resume.
+type Swap struct {
+	dir   string      // journal directory
+	Items []*swapItem `json:"items"` // swap targets
+}
+
+// Stage returns the staged path for the target, removing leftovers of a previous
+// attempt.
+func (s *Swap) Stage(target string) (string, error) {
+	staged := target + stagedSuffix
+	if err := os.RemoveAll(staged); err != nil {
+		return "", err
+	}
+	s.Items = append(s.Items, &swapItem{Target: target})
+	return staged, nil
+}
+
+// Remove removes the target on commit.
+func (s *Swap) Remove(target string) {
+	s.Items = append(s.Items, &swapItem{Target: target, Remove: true})
+}
+
+// Discard removes the staged paths.
+func (s *Swap) Discard() {
+	for _, item := range s.Items {
+		if !item.Remove {
+			_ = os.RemoveAll(item.Target + stagedSuffix)
+		}
+	}
+}
+
+// exists asserts the path exists.
+func exists(name string) (bool, error) {
+	_, err := os.Lstat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// replace moves the targets aside and the staged paths in place, it can be
+// repeated after an interruption.
+func (s *Swap) replace() error {
+	for _, item := range s.Items {
+		staged, old := item.Target+stagedSuffix, item.Target+oldSuffix
+		ready := item.Remove
+		if !ready {
+			var err error
+			// the staged path is gone once moved in place
+			if ready, err = exists(staged); err != nil {
+				return err
+			}
+		}
+		if !ready {
+			continue
+		}
+		found, err := exists(item.Target)
+		if err != nil {
+			return err
+		}
+		if found {
+			if err = os.RemoveAll(old); err != nil {
+				return err
+			}
+			if err = os.Rename(item.Target, old); err != nil {
+				return err
+			}
+		}
+		if !item.Remove {
+			if err = os.Rename(staged, item.Target); err != nil {
+				return err
+			}
+		}
+	}
+	for _, item := range s.Items {
+		if err := os.RemoveAll(item.Target + oldSuffix); err != nil {
+			return err
+		}
+	}
+	return os.Remove(filepath.Join(s.dir, journalFile))
+}
+
+// Commit writes the journal and replaces the targets by the staged paths.
+func (s *Swap) Commit() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	journal := filepath.Join(s.dir, journalFile)
+	tmp := journal + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, journal); err != nil {
+		return err
+	}
+	return s.replace()
+}
+
+// Pending asserts the directory has the journal of an interrupted swap.
+func Pending(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, journalFile))
+	return err == nil
+}
+
+// Resume finishes the swap interrupted after the commit, when the journal is
+// found on the directory. Returns true when a swap was resumed.
+func Resume(dir string) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, journalFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	s := NewSwap(dir)
+	if err = json.Unmarshal(data, s); err != nil {
+		return false, err
+	}
+	return true, s.replace()
+}
+
+// NewSwap instantiates the swap, the journal is kept on the directory.
+func NewSwap(dir string) *Swap {
+	return &Swap{dir: dir, Items: []*swapItem{}}
+}
@@ -0,0 +1,164 @@
+package logging
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+)
+
+// JournalSocket systemd-journald native protocol socket.
+const JournalSocket = "/run/systemd/journal/socket"
+
+// ErrJournalUnavailable the journal socket is not available.
+var ErrJournalUnavailable = errors.New("journald is unavailable")
+
+// Journal handler writing the records on systemd-journald using the native
+// protocol, the attributes are journal fields. When the journal is unable to
+// receive a record, it's handled by the fallback handler instead.
+type Journal struct {
+	conn       *net.UnixConn // journal socket connection
+	identifier string        // syslog identifier
+	level      slog.Leveler  // minimum level
+	prefix     string        // group prefix for attribute fields
+	fields     []byte        // preformatted attribute fields
+	fallback   slog.Handler  // handles the records the journal is unable to
+}
+
+var _ slog.Handler = &Journal{}
+
+// fieldName converts the attribute key to a journal field name, uppercase
+// letters, digits and underscores, starting with a letter.
+func fieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	s := strings.TrimLeft(string(name), "_0123456789")
+	if s == "" {
+		s = "FIELD"
+	}
+	return s[:min(len(s), 64)]
+}
+
+// appendField appends the field on the native protocol format, values with new
+// lines are informed with their length.
+func appendField(b []byte, name, value string) []byte {
+	if !strings.Contains(value, "\n") {
+		return append(append(append(append(b, name...), '='), value...), '\n')
+	}
+	b = append(append(b, name...), '\n')
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(value)))
+	return append(append(b, value...), '\n')
+}
+
+// appendAttr appends the attribute fields, groups are flattened with the prefix.
+func appendAttr(b []byte, prefix string, a slog.Attr) []byte {
+	v := a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return b
+	}
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "_"
+		}
+		for _, ga := range v.Group() {
+			b = appendAttr(b, prefix, ga)
+		}
+		return b
+	}
+	value := v.String()
+	if v.Kind() == slog.KindTime {
+		value = v.Time().Format(time.RFC3339Nano)
+	}
+	return appendField(b, fieldName(prefix+a.Key), value)
+}
+
+// priority maps the level to syslog priority.
+func priority(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return "3"
+	case level >= slog.LevelWarn:
+		return "4"
+	case level >= slog.LevelInfo:
+		return "6"
+	default:
+		return "7"
+	}
+}
+
+// Enabled asserts the level is enabled.
+func (j *Journal) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= j.level.Level()
+}
+
+// Handle sends the record as a single datagram, when it fails the record is
+// handled by the fallback.
+func (j *Journal) Handle(ctx context.Context, r slog.Record) error {
+	b := appendField(nil, "MESSAGE", r.Message)
+	b = appendField(b, "PRIORITY", priority(r.Level))
+	b = appendField(b, "SYSLOG_IDENTIFIER", j.identifier)
+	b = append(b, j.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		b = appendAttr(b, j.prefix, a)
+		return true
+	})
+	if _, err := j.conn.Write(b); err != nil {
+		return j.fallback.Handle(ctx, r)
+	}
+	return nil
+}
+
+// WithAttrs preformats the attribute fields.
+func (j *Journal) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *j
+	c.fields = append([]byte{}, j.fields...)
+	for _, a := range attrs {
+		c.fields = appendAttr(c.fields, j.prefix, a)
+	}
+	c.fallback = j.fallback.WithAttrs(attrs)
+	return &c
+}
+
+// WithGroup prefixes the following attribute fields with the group name.
+func (j *Journal) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return j
+	}
+	c := *j
+	c.prefix += name + "_"
+	c.fallback = j.fallback.WithGroup(name)
+	return &c
+}
+
+// Close closes the journal socket connection.
+func (j *Journal) Close() error {
+	return j.conn.Close()
+}
+
+// NewJournal connects to the journal socket, the records are sent with the
+// syslog identifier.
+func NewJournal(
+	socket, identifier string,
+	level slog.Leveler,
+	fallback slog.Handler,
+) (*Journal, error) {
+	conn, err := net.DialUnix("unixgram", nil,
+		&net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrJournalUnavailable, err)
+	}
+	return &Journal{
+		conn:       conn,
+		identifier: identifier,
+		level:      level,
+		fallback:   fallback,
+	}, nil
+}
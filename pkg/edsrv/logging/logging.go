@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+const (
+	// FormatText logfmt-like text format.
+	FormatText = "text"
+	// FormatJSON JSON lines format.
+	FormatJSON = "json"
+)
+
+// Formats supported log formats.
+var Formats = []string{FormatText, FormatJSON}
+
+// Options represents the logging output configuration.
+type Options struct {
+	Identifier string        // syslog identifier on the journal
+	Level      slog.Leveler  // minimum level
+	Format     string        // output format, text or json
+	File       string        // log file, standard output when empty
+	Stderr     bool          // standard error instead of standard output
+	MaxSize    int64         // log file rotation size in bytes
+	MaxAge     time.Duration // log file rotation age
+	MaxBackups int           // rotated log files kept
+	Journald   bool          // writes on the journal
+}
+
+// nopCloser closer for the standard outputs.
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// NewFormatHandler instantiates the handler for the format writing on w.
+func NewFormatHandler(w io.Writer, format string, level slog.Leveler) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == FormatJSON {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// NewHandler instantiates the handler for the options, and the closer for its
+// output. The journal falls back to the standard error when unavailable.
+func NewHandler(o Options) (slog.Handler, io.Closer, error) {
+	if o.Journald {
+		stderr := NewFormatHandler(os.Stderr, o.Format, o.Level)
+		j, err := NewJournal(JournalSocket, o.Identifier, o.Level, stderr)
+		if err != nil {
+			slog.New(stderr).Warn("logging on standard error", "err", err.Error())
+			return stderr, nopCloser{}, nil
+		}
+		return j, j, nil
+	}
+	if o.File == "" && o.Stderr {
+		return NewFormatHandler(os.Stderr, o.Format, o.Level), nopCloser{}, nil
+	}
+	if o.File == "" {
+		return NewFormatHandler(os.Stdout, o.Format, o.Level), nopCloser{}, nil
+	}
+	f, err := NewRotatingFile(o.File, o.MaxSize, o.MaxAge, o.MaxBackups)
+	if err != nil {
+		return nil, nil, fmt.Errorf("log file %q: %w", o.File, err)
+	}
+	return NewFormatHandler(f, o.Format, o.Level), f, nil
+}
@@ -0,0 +1,195 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSink(t *testing.T) {
+	var text, jsonBuf bytes.Buffer
+	sink := NewSink(NewFormatHandler(&text, FormatText, slog.LevelDebug))
+
+	// decorated before the handler is configured, like the config and file
+	// LoggerWith decorations
+	logger := slog.New(sink.Handler()).With("addr", "127.0.0.1:8928").
+		WithGroup("file").With("size", 3)
+
+	logger.Info("text")
+	if !strings.Contains(text.String(), "addr=127.0.0.1:8928 file.size=3") {
+		t.Errorf("text output = %q, expected the attributes", text.String())
+	}
+
+	if err := sink.Set(NewFormatHandler(&jsonBuf, FormatJSON, slog.LevelInfo), nil); err != nil {
+		t.Fatalf("Sink.Set() error = %v", err)
+	}
+	logger.Debug("disabled")
+	logger.Info("json", "name", "edsrv-1")
+
+	entry := map[string]any{}
+	if err := json.Unmarshal(jsonBuf.Bytes(), &entry); err != nil {
+		t.Fatalf("json output %q: %v", jsonBuf.String(), err)
+	}
+	if entry["addr"] != "127.0.0.1:8928" || entry["msg"] != "json" {
+		t.Errorf("json output = %v, expected the attributes", entry)
+	}
+	group, _ := entry["file"].(map[string]any)
+	if group["size"] != float64(3) || group["name"] != "edsrv-1" {
+		t.Errorf("json output = %v, expected the group attributes", entry)
+	}
+}
+
+func TestJournal(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	server, err := net.ListenUnixgram("unixgram",
+		&net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("unable to listen on %q: %v", socket, err)
+	}
+	defer server.Close()
+
+	var fallback bytes.Buffer
+	j, err := NewJournal(socket, "edsrv", slog.LevelInfo,
+		NewFormatHandler(&fallback, FormatText, slog.LevelInfo))
+	if err != nil {
+		t.Fatalf("NewJournal() error = %v", err)
+	}
+	defer j.Close()
+
+	logger := slog.New(j).With("tmp-dir", "/tmp").WithGroup("file")
+	logger.Debug("disabled")
+	logger.Warn("multi\nline", "size", 3)
+
+	buf := make([]byte, 4096)
+	_ = server.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := server.Read(buf)
+	if err != nil {
+		t.Fatalf("unable to read datagram: %v", err)
+	}
+
+	var message bytes.Buffer
+	message.WriteString("MESSAGE\n")
+	_ = binary.Write(&message, binary.LittleEndian, uint64(len("multi\nline")))
+	message.WriteString("multi\nline\n")
+	want := message.String() + "PRIORITY=4\nSYSLOG_IDENTIFIER=edsrv\n" +
+		"TMP_DIR=/tmp\nFILE_SIZE=3\n"
+	if string(buf[:n]) != want {
+		t.Errorf("datagram = %q, expected %q", buf[:n], want)
+	}
+
+	// once the journal is gone the records are handled by the fallback
+	server.Close()
+	os.Remove(socket)
+	logger.Info("fallback")
+	if !strings.Contains(fallback.String(), "tmp-dir=/tmp msg=fallback") &&
+		!strings.Contains(fallback.String(), "msg=fallback tmp-dir=/tmp") {
+		t.Errorf("fallback output = %q, expected the record", fallback.String())
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "logs", "edsrv.log")
+	// files sharing the log file prefix are not rotated files
+	unrelated := name + ".md"
+	if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+		t.Fatalf("unable to create log directory: %v", err)
+	}
+	if err := os.WriteFile(unrelated, []byte("notes"), 0o600); err != nil {
+		t.Fatalf("unable to create unrelated file: %v", err)
+	}
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	if err := os.Chtimes(unrelated, old, old); err != nil {
+		t.Fatalf("unable to change unrelated file times: %v", err)
+	}
+	f, err := NewRotatingFile(name, 10, time.Hour, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	defer f.Close()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+	f.started = now
+
+	write := func(s string) {
+		t.Helper()
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatalf("RotatingFile.Write() error = %v", err)
+		}
+	}
+	write("12345\n")
+	write("12345\n") // exceeds the size
+	now = now.Add(time.Second)
+	write("1\n") // within the size
+	now = now.Add(2 * time.Hour)
+	write("2\n") // expired
+	now = now.Add(2 * time.Hour)
+	write("3\n") // expired, the oldest rotated file is removed
+
+	backups, err := f.backups()
+	if err != nil {
+		t.Fatalf("RotatingFile.backups() error = %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("RotatingFile.backups() = %v, expected 2 files", backups)
+	}
+	current, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("unable to read log file: %v", err)
+	}
+	if string(current) != "3\n" {
+		t.Errorf("log file = %q, expected %q", current, "3\n")
+	}
+	last, err := os.ReadFile(backups[1])
+	if err != nil {
+		t.Fatalf("unable to read rotated file: %v", err)
+	}
+	if string(last) != "2\n" {
+		t.Errorf("rotated file = %q, expected %q", last, "2\n")
+	}
+	if _, err = os.Stat(unrelated); err != nil {
+		t.Errorf("unrelated file is removed: %v", err)
+	}
+}
+
+func TestRotatingFile_RotateError(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "edsrv.log")
+	f, err := NewRotatingFile(name, 10, 0, 0)
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	defer f.Close()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+
+	// the rotated file name is taken by a non-empty directory, renaming fails
+	backup := name + "." + now.Format(backupLayout)
+	if err = os.MkdirAll(filepath.Join(backup, "taken"), 0o700); err != nil {
+		t.Fatalf("unable to create directory: %v", err)
+	}
+	if _, err = f.Write([]byte("12345\n")); err != nil {
+		t.Fatalf("RotatingFile.Write() error = %v", err)
+	}
+	if _, err = f.Write([]byte("12345\n")); err == nil {
+		t.Errorf("RotatingFile.Write() expected rotation error")
+	}
+
+	// the file is open again, the following writes succeed
+	now = now.Add(time.Second)
+	if _, err = f.Write([]byte("1\n")); err != nil {
+		t.Fatalf("RotatingFile.Write() error = %v", err)
+	}
+	rotated, err := os.ReadFile(name + "." + now.Format(backupLayout))
+	if err != nil {
+		t.Fatalf("unable to read rotated file: %v", err)
+	}
+	if string(rotated) != "12345\n12345\n" {
+		t.Errorf("rotated file = %q, expected both entries", rotated)
+	}
+}
@@ -0,0 +1,163 @@
+package logging
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupLayout time layout of the rotated files suffix.
+const backupLayout = "20060102-150405.000"
+
+// RotatingFile log file rotated when exceeding the maximum size, or when older
+// than the maximum age. The rotated files are renamed with the rotation time
+// suffix, and the oldest are removed when exceeding the maximum backups, or
+// older than the maximum age. Zero values disable the respective limit.
+type RotatingFile struct {
+	name       string        // log file path
+	maxSize    int64         // maximum file size in bytes
+	maxAge     time.Duration // maximum file age
+	maxBackups int           // maximum rotated files kept
+	fh         *os.File      // current file handle
+	size       int64         // current file size
+	started    time.Time     // current file start
+	now        func() time.Time
+	m          sync.Mutex // protects the file
+}
+
+// open opens the log file for appending, the start of a existing file is its
+// last modification.
+func (f *RotatingFile) open() error {
+	fh, err := os.OpenFile(f.name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := fh.Stat()
+	if err != nil {
+		_ = fh.Close()
+		return err
+	}
+	f.fh, f.size, f.started = fh, info.Size(), f.now()
+	if info.Size() > 0 {
+		f.started = info.ModTime()
+	}
+	return nil
+}
+
+// backups returns the rotated files, oldest first. Only the files named with
+// the rotation time suffix are considered, other files sharing the log file
+// prefix are left alone.
+func (f *RotatingFile) backups() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(f.name))
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(f.name) + "."
+	matches := []string{}
+	for _, e := range entries {
+		suffix, ok := strings.CutPrefix(e.Name(), prefix)
+		if !ok || !e.Type().IsRegular() {
+			continue
+		}
+		if _, err = time.Parse(backupLayout, suffix); err != nil {
+			continue
+		}
+		matches = append(matches, filepath.Join(filepath.Dir(f.name), e.Name()))
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// prune removes the rotated files exceeding the maximum backups, or older than
+// the maximum age.
+func (f *RotatingFile) prune() error {
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+	for i, name := range backups {
+		remove := f.maxBackups > 0 && len(backups)-i > f.maxBackups
+		if !remove && f.maxAge > 0 {
+			if info, err := os.Stat(name); err == nil {
+				remove = f.now().Sub(info.ModTime()) > f.maxAge
+			}
+		}
+		if remove {
+			if err = os.Remove(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rotate renames the current file with the rotation time suffix, and opens a
+// new one. When the rotation fails the current file is opened again, so the
+// following writes are not lost.
+func (f *RotatingFile) rotate() error {
+	if err := f.fh.Close(); err != nil {
+		return errors.Join(err, f.open())
+	}
+	backup := f.name + "." + f.now().Format(backupLayout)
+	if err := os.Rename(f.name, backup); err != nil {
+		return errors.Join(err, f.open())
+	}
+	if err := f.open(); err != nil {
+		return errors.Join(err, os.Rename(backup, f.name), f.open())
+	}
+	return f.prune()
+}
+
+// Write writes the log entry, rotating the file before when the entry exceeds
+// the maximum size, or the file is older than the maximum age.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	exceeds := f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize
+	expired := f.maxAge > 0 && f.size > 0 && f.now().Sub(f.started) > f.maxAge
+	var rotateErr error
+	if exceeds || expired {
+		// on failure the entry is still written on the current file
+		if rotateErr = f.rotate(); rotateErr != nil {
+			f.started = f.now()
+		}
+	}
+	n, err := f.fh.Write(p)
+	f.size += int64(n)
+	return n, errors.Join(rotateErr, err)
+}
+
+// Close closes the current file.
+func (f *RotatingFile) Close() error {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.fh.Close()
+}
+
+// NewRotatingFile opens the log file, creating the parent directory when
+// missing.
+func NewRotatingFile(
+	name string,
+	maxSize int64,
+	maxAge time.Duration,
+	maxBackups int,
+) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+		return nil, err
+	}
+	f := &RotatingFile{
+		name:       name,
+		maxSize:    maxSize,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+		now:        time.Now,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, f.prune()
+}
@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// Sink holds the handler in use, configured once the command-line flags are
+// parsed. The loggers instantiated before share the sink, and their attributes
+// and groups are applied on the handler in use for each record.
+type Sink struct {
+	handler slog.Handler // handler in use
+	closer  io.Closer    // closes the handler output, optional
+	m       sync.RWMutex // protects the handler
+}
+
+// current returns the handler in use.
+func (s *Sink) current() slog.Handler {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.handler
+}
+
+// Set replaces the handler in use, closing the previous output.
+func (s *Sink) Set(h slog.Handler, closer io.Closer) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	previous := s.closer
+	s.handler, s.closer = h, closer
+	if previous != nil {
+		return previous.Close()
+	}
+	return nil
+}
+
+// Close closes the handler output.
+func (s *Sink) Close() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.closer == nil {
+		return nil
+	}
+	err := s.closer.Close()
+	s.closer = nil
+	return err
+}
+
+// Handler returns a handler writing on the sink.
+func (s *Sink) Handler() slog.Handler {
+	return &sinkHandler{sink: s}
+}
+
+// NewSink instantiates the Sink with the initial handler.
+func NewSink(h slog.Handler) *Sink {
+	return &Sink{handler: h}
+}
+
+// sinkOp attributes or group applied on the handler in use.
+type sinkOp struct {
+	group string      // group name, when not empty
+	attrs []slog.Attr // attributes
+}
+
+// sinkHandler handler writing on the sink, it records the attributes and groups
+// to apply them on the handler in use.
+type sinkHandler struct {
+	sink *Sink    // shared sink
+	ops  []sinkOp // attributes and groups, in order
+}
+
+var _ slog.Handler = &sinkHandler{}
+
+// resolve applies the attributes and groups on the handler in use.
+func (h *sinkHandler) resolve() slog.Handler {
+	handler := h.sink.current()
+	for _, op := range h.ops {
+		if op.group != "" {
+			handler = handler.WithGroup(op.group)
+		} else {
+			handler = handler.WithAttrs(op.attrs)
+		}
+	}
+	return handler
+}
+
+// Enabled asserts the level is enabled on the handler in use.
+func (h *sinkHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.sink.current().Enabled(ctx, level)
+}
+
+// Handle handles the record on the handler in use.
+func (h *sinkHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.resolve().Handle(ctx, r)
+}
+
+// with returns a copy of the handler with the operation appended.
+func (h *sinkHandler) with(op sinkOp) *sinkHandler {
+	ops := make([]sinkOp, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &sinkHandler{sink: h.sink, ops: append(ops, op)}
+}
+
+// WithAttrs records the attributes.
+func (h *sinkHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.with(sinkOp{attrs: attrs})
+}
+
+// WithGroup records the group.
+func (h *sinkHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(sinkOp{group: name})
+}
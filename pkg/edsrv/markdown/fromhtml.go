@@ -0,0 +1,307 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skippedElements elements removed with their contents.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Head:     true,
+	atom.Template: true,
+	atom.Noscript: true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Embed:    true,
+}
+
+// blockElements elements rendered as Markdown blocks.
+var blockElements = map[atom.Atom]bool{
+	atom.Address:    true,
+	atom.Article:    true,
+	atom.Aside:      true,
+	atom.Blockquote: true,
+	atom.Body:       true,
+	atom.Div:        true,
+	atom.Dl:         true,
+	atom.Figure:     true,
+	atom.Footer:     true,
+	atom.Form:       true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Header:     true,
+	atom.Hr:         true,
+	atom.Html:       true,
+	atom.Li:         true,
+	atom.Main:       true,
+	atom.Nav:        true,
+	atom.Ol:         true,
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Section:    true,
+	atom.Table:      true,
+	atom.Tbody:      true,
+	atom.Thead:      true,
+	atom.Tr:         true,
+	atom.Ul:         true,
+}
+
+// headings heading elements and the respective level.
+var headings = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+var (
+	// whitespaceRe sequences of whitespace, collapsed on HTML text.
+	whitespaceRe = regexp.MustCompile(`\s+`)
+	// escapeRe Markdown characters escaped on HTML text.
+	escapeRe = regexp.MustCompile("([\\\\`*_\\[\\]<>])")
+	// lineStartEscapeRe Markdown block markers at the start of a line.
+	lineStartEscapeRe = regexp.MustCompile(`(?m)^(\s*)([#>+-])(\s)`)
+	// orderedEscapeRe ordered list marker at the start of a line, the delimiter
+	// is escaped.
+	orderedEscapeRe = regexp.MustCompile(`(?m)^(\s*\d+)([.)])(\s)`)
+	// destinationReplacer characters not allowed on a angle brackets link
+	// destination.
+	destinationReplacer = strings.NewReplacer(" ", "%20", "<", "%3C", ">", "%3E")
+)
+
+// destination returns the link destination, URLs with parentheses or spaces
+// are enclosed in angle brackets.
+func destination(url string) string {
+	if !strings.ContainsAny(url, " ()<>") {
+		return url
+	}
+	return "<" + destinationReplacer.Replace(url) + ">"
+}
+
+// attr returns the named attribute value.
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// isBlock asserts the node is rendered as a block.
+func isBlock(n *html.Node) bool {
+	return n.Type == html.ElementNode && blockElements[n.DataAtom]
+}
+
+// textContent returns the raw text of the node and its children.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Br {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// wrap wraps the inline content with the informed marker, keeping surrounding
+// whitespace outside.
+func wrap(inner, marker string) string {
+	trimmed := strings.TrimSpace(inner)
+	if trimmed == "" {
+		return inner
+	}
+	lead := inner[:strings.Index(inner, trimmed)]
+	trail := inner[len(lead)+len(trimmed):]
+	return lead + marker + trimmed + marker + trail
+}
+
+// inlineChildren renders the children of the node as inline Markdown.
+func inlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(inline(c))
+	}
+	return b.String()
+}
+
+// inline renders the node as inline Markdown.
+func inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return escapeRe.ReplaceAllString(whitespaceRe.ReplaceAllString(n.Data, " "), `\$1`)
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if skippedElements[n.DataAtom] {
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return "\\\n"
+	case atom.Strong, atom.B:
+		return wrap(inlineChildren(n), "**")
+	case atom.Em, atom.I:
+		return wrap(inlineChildren(n), "*")
+	case atom.Del, atom.S, atom.Strike:
+		return wrap(inlineChildren(n), "~~")
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		code := textContent(n)
+		fence := "`"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		return fence + code + fence
+	case atom.A:
+		text := strings.TrimSpace(inlineChildren(n))
+		href := attr(n, "href")
+		if href == "" {
+			return text
+		}
+		href = destination(href)
+		if title := attr(n, "title"); title != "" {
+			return fmt.Sprintf("[%s](%s %q)", text, href, title)
+		}
+		return fmt.Sprintf("[%s](%s)", text, href)
+	case atom.Img:
+		return fmt.Sprintf("![%s](%s)", attr(n, "alt"), destination(attr(n, "src")))
+	default:
+		return inlineChildren(n)
+	}
+}
+
+// indent prefixes every non-empty line with the informed prefix.
+func indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// list renders the list items, nested blocks are indented to the item content.
+func list(n *html.Node, ordered bool) string {
+	items := []string{}
+	i := 1
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", i)
+			i++
+		}
+		content := strings.Join(blocks(c), "\n")
+		content = indent(content, strings.Repeat(" ", len(marker)))
+		items = append(items, marker+strings.TrimLeft(content, " "))
+	}
+	return strings.Join(items, "\n")
+}
+
+// codeBlock renders a preformatted element as a fenced code block.
+func codeBlock(n *html.Node) string {
+	lang := ""
+	for _, el := range []*html.Node{n, n.FirstChild} {
+		if el == nil || el.Type != html.ElementNode {
+			continue
+		}
+		for _, class := range strings.Fields(attr(el, "class")) {
+			if after, ok := strings.CutPrefix(class, "language-"); ok {
+				lang = after
+			}
+		}
+	}
+	code := strings.TrimSuffix(textContent(n), "\n")
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fmt.Sprintf("%s%s\n%s\n%s", fence, lang, code, fence)
+}
+
+// block renders a block element as one or more Markdown blocks.
+func block(n *html.Node) []string {
+	switch n.DataAtom {
+	case atom.Hr:
+		return []string{"---"}
+	case atom.Pre:
+		return []string{codeBlock(n)}
+	case atom.Ul:
+		return []string{list(n, false)}
+	case atom.Ol:
+		return []string{list(n, true)}
+	case atom.Blockquote:
+		return []string{indent(strings.Join(blocks(n), "\n\n"), "> ")}
+	}
+	if level, ok := headings[n.DataAtom]; ok {
+		return []string{
+			strings.Repeat("#", level) + " " + strings.TrimSpace(inlineChildren(n)),
+		}
+	}
+	return blocks(n)
+}
+
+// blocks renders the children of the node as Markdown blocks, consecutive inline
+// nodes are grouped as a paragraph.
+func blocks(n *html.Node) []string {
+	out := []string{}
+	var paragraph strings.Builder
+	flush := func() {
+		text := strings.TrimSpace(paragraph.String())
+		text = strings.ReplaceAll(text, "\\\n ", "\\\n")
+		if text != "" {
+			text = lineStartEscapeRe.ReplaceAllString(text, `$1\$2$3`)
+			out = append(out, orderedEscapeRe.ReplaceAllString(text, `$1\$2$3`))
+		}
+		paragraph.Reset()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && skippedElements[c.DataAtom] {
+			continue
+		}
+		if !isBlock(c) {
+			paragraph.WriteString(inline(c))
+			continue
+		}
+		flush()
+		out = append(out, block(c)...)
+	}
+	flush()
+	return out
+}
+
+// FromHTML converts the HTML fragment to Markdown, scripts, styles and other
+// non-content elements are removed.
+func FromHTML(src []byte) ([]byte, error) {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(bytes.NewReader(src), body)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+	md := strings.Join(blocks(body), "\n\n")
+	if md == "" {
+		return []byte{}, nil
+	}
+	return []byte(md + "\n"), nil
+}
@@ -0,0 +1,122 @@
+package markdown
+
+import "testing"
+
+func TestFromHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{{
+		name: "emphasis and links",
+		html: `<p>Hello <b>bold</b>, <em>it</em> and <a href="https://x.io">link</a></p>`,
+		want: "Hello **bold**, *it* and [link](https://x.io)\n",
+	}, {
+		name: "scripts are removed",
+		html: `<p>text</p><script>alert(1)</script>`,
+		want: "text\n",
+	}, {
+		name: "nested lists",
+		html: `<ul><li>one</li><li>two<ol><li>nested</li></ol></li></ul>`,
+		want: "- one\n- two\n  1. nested\n",
+	}, {
+		name: "code block",
+		html: `<pre><code class="language-go">if a &lt; b {
+}</code></pre>`,
+		want: "```go\nif a < b {\n}\n```\n",
+	}, {
+		name: "markdown characters are escaped",
+		html: `<div>- snake_case *</div>`,
+		want: "\\- snake\\_case \\*\n",
+	}, {
+		name: "ordered list marker is escaped",
+		html: `<p>1. not a list</p>`,
+		want: "1\\. not a list\n",
+	}, {
+		name: "link destination with parentheses",
+		html: `<a href="https://en.wikipedia.org/wiki/Go_(language)">Go</a>`,
+		want: "[Go](<https://en.wikipedia.org/wiki/Go_(language)>)\n",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromHTML([]byte(tt.html))
+			if err != nil {
+				t.Fatalf("FromHTML() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("FromHTML() = %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{{
+		name: "emphasis and links",
+		md:   "Hello **bold**, *it* and [link](https://x.io)\n",
+		want: `<p>Hello <strong>bold</strong>, <em>it</em> and <a href="https://x.io">link</a></p>`,
+	}, {
+		name: "lists",
+		md:   "- one\n- two\n  1. nested\n",
+		want: "<ul>\n<li>one</li>\n<li>two\n<ol>\n<li>nested</li>\n</ol></li>\n</ul>",
+	}, {
+		name: "code block",
+		md:   "```go\nif a < b {\n}\n```\n",
+		want: "<pre><code class=\"language-go\">if a &lt; b {\n}\n</code></pre>",
+	}, {
+		name: "scripts, event handlers and unsafe links are stripped",
+		md:   "<script>alert(1)</script><img src=x onerror=alert(1)> [a](javascript:void)",
+		want: `<p><img src="x"> <a>a</a></p>`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToHTML([]byte(tt.md))
+			if err != nil {
+				t.Fatalf("ToHTML() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ToHTML() = %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+	}{{
+		name: "link destination with parentheses",
+		html: `<p><a href="https://en.wikipedia.org/wiki/Go_(language)">Go</a> and ` +
+			`<img src="a%20(1).png" alt="a"></p>`,
+	}, {
+		name: "paragraph starting with a ordered list marker",
+		html: `<p>1. not a list</p>`,
+	}, {
+		name: "paragraph starting with block markers",
+		html: "<p># not a heading<br>\n- not a list<br>\n2) not a list</p>",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md, err := FromHTML([]byte(tt.html))
+			if err != nil {
+				t.Fatalf("FromHTML() error = %v", err)
+			}
+			got, err := ToHTML(md)
+			if err != nil {
+				t.Fatalf("ToHTML() error = %v", err)
+			}
+			if string(got) != tt.html {
+				t.Errorf("ToHTML(FromHTML()) = %q, expected %q (markdown %q)",
+					got, tt.html, md)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package markdown
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedElements elements kept by the sanitizer, and their allowed attributes.
+var allowedElements = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.B:          {},
+	atom.Blockquote: {},
+	atom.Br:         {},
+	atom.Code:       {"class"},
+	atom.Del:        {},
+	atom.Div:        {},
+	atom.Em:         {},
+	atom.H1:         {},
+	atom.H2:         {},
+	atom.H3:         {},
+	atom.H4:         {},
+	atom.H5:         {},
+	atom.H6:         {},
+	atom.Hr:         {},
+	atom.I:          {},
+	atom.Img:        {"src", "alt", "title"},
+	atom.Kbd:        {},
+	atom.Li:         {},
+	atom.Mark:       {},
+	atom.Ol:         {"start"},
+	atom.P:          {},
+	atom.Pre:        {"class"},
+	atom.S:          {},
+	atom.Span:       {},
+	atom.Strong:     {},
+	atom.Sub:        {},
+	atom.Sup:        {},
+	atom.Table:      {},
+	atom.Tbody:      {},
+	atom.Td:         {"colspan", "rowspan"},
+	atom.Th:         {"colspan", "rowspan"},
+	atom.Thead:      {},
+	atom.Tr:         {},
+	atom.U:          {},
+	atom.Ul:         {},
+}
+
+// urlAttributes attributes carrying URLs, only safe schemes are kept.
+var urlAttributes = map[string]bool{"href": true, "src": true}
+
+// safeSchemes URL schemes allowed on links and images, relative URLs are
+// allowed as well.
+var safeSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// isSafeURL asserts the URL is relative or uses a safe scheme.
+func isSafeURL(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || safeSchemes[strings.ToLower(u.Scheme)]
+}
+
+// sanitizeAttrs keeps only the allowed attributes with safe values.
+func sanitizeAttrs(attrs []html.Attribute, allowed []string) []html.Attribute {
+	out := []html.Attribute{}
+	for _, a := range attrs {
+		key := strings.ToLower(a.Key)
+		if a.Namespace != "" || !slices.Contains(allowed, key) {
+			continue
+		}
+		if urlAttributes[key] && !isSafeURL(a.Val) {
+			continue
+		}
+		out = append(out, html.Attribute{Key: key, Val: a.Val})
+	}
+	return out
+}
+
+// Sanitize keeps only the allowed elements and attributes, the contents of
+// scripts, styles and other non-content elements are removed, event handlers
+// and unsafe URLs are stripped, and disallowed elements are replaced by their
+// text.
+func Sanitize(src []byte) ([]byte, error) {
+	var out bytes.Buffer
+	z := html.NewTokenizer(bytes.NewReader(src))
+	skipDepth := 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if errors.Is(z.Err(), io.EOF) {
+				return out.Bytes(), nil
+			}
+			return nil, z.Err()
+		}
+
+		t := z.Token()
+		switch tt {
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			if skippedElements[t.DataAtom] {
+				if tt == html.StartTagToken {
+					skipDepth++
+				} else if tt == html.EndTagToken && skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			allowed, ok := allowedElements[t.DataAtom]
+			if skipDepth > 0 || !ok {
+				continue
+			}
+			t.Attr = sanitizeAttrs(t.Attr, allowed)
+			out.WriteString(t.String())
+		case html.TextToken:
+			if skipDepth == 0 {
+				out.WriteString(html.EscapeString(t.Data))
+			}
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// fenceRe fenced code block opening, and optional language.
+	fenceRe = regexp.MustCompile("^(`{3,}|~{3,})\\s*([\\w+-]*)")
+	// headingRe ATX heading.
+	headingRe = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	// hrRe thematic break.
+	hrRe = regexp.MustCompile(`^\s{0,3}((-\s*){3,}|(\*\s*){3,}|(_\s*){3,})$`)
+	// listItemRe list item marker, unordered or ordered.
+	listItemRe = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])(\s+|$)`)
+	// quoteRe blockquote line prefix.
+	quoteRe = regexp.MustCompile(`^\s{0,3}>\s?`)
+	// rawTagRe raw inline HTML tag, passed through to the sanitizer.
+	rawTagRe = regexp.MustCompile(`^</?[a-zA-Z][a-zA-Z0-9-]*(\s+[^<>]*)?/?>`)
+	// linkRe inline link or image, the destination either in angle brackets or
+	// bare, with optional title.
+	linkRe = regexp.MustCompile(
+		`^(!?)\[((?:\\.|[^\]\\])*)\]\(\s*(?:<([^<>\n]*)>|(\S*?))(?:\s+"([^"]*)")?\s*\)`)
+	// autolinkRe angle brackets autolink.
+	autolinkRe = regexp.MustCompile(`^<((?:https?|mailto):[^\s<>]+)>`)
+	// backslashEscapeRe backslash escaped character.
+	backslashEscapeRe = regexp.MustCompile(`\\(.)`)
+)
+
+// isBlank asserts the line contains only whitespace.
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// findClosing finds the closing delimiter position, skipping escaped characters.
+func findClosing(s, delim string) int {
+	for i := 0; i+len(delim) <= len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:], delim) && i > 0 && s[i-1] != ' ' {
+			return i
+		}
+	}
+	return -1
+}
+
+// renderInline renders inline Markdown, code spans, links, images, emphasis,
+// strikethrough, hard line breaks and raw tags.
+func renderInline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		switch {
+		case strings.HasPrefix(rest, "\\\n"):
+			b.WriteString("<br>\n")
+			i += 2
+		case strings.HasPrefix(rest, "  \n"):
+			b.WriteString("<br>\n")
+			i += 3
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune(
+			"\\`*_{}[]()#+-.!<>~|\"", rune(rest[1])):
+			b.WriteString(html.EscapeString(rest[1:2]))
+			i += 2
+		case rest[0] == '`':
+			n := len(rest) - len(strings.TrimLeft(rest, "`"))
+			fence := rest[:n]
+			end := strings.Index(rest[n:], fence)
+			if end < 0 {
+				b.WriteString(html.EscapeString(fence))
+				i += n
+				continue
+			}
+			code := strings.TrimSpace(rest[n : n+end])
+			fmt.Fprintf(&b, "<code>%s</code>", html.EscapeString(code))
+			i += 2*n + end
+		case rest[0] == '[' || strings.HasPrefix(rest, "!["):
+			m := linkRe.FindStringSubmatch(rest)
+			if m == nil {
+				b.WriteString(html.EscapeString(rest[:1]))
+				i++
+				continue
+			}
+			dest := m[3] + m[4]
+			title := ""
+			if m[5] != "" {
+				title = fmt.Sprintf(` title="%s"`, html.EscapeString(m[5]))
+			}
+			if m[1] == "!" {
+				fmt.Fprintf(&b, `<img src="%s" alt="%s"%s>`,
+					html.EscapeString(dest), html.EscapeString(unescape(m[2])), title)
+			} else {
+				fmt.Fprintf(&b, `<a href="%s"%s>%s</a>`,
+					html.EscapeString(dest), title, renderInline(m[2]))
+			}
+			i += len(m[0])
+		case rest[0] == '<':
+			if m := autolinkRe.FindStringSubmatch(rest); m != nil {
+				fmt.Fprintf(&b, `<a href="%s">%s</a>`,
+					html.EscapeString(m[1]), html.EscapeString(m[1]))
+				i += len(m[0])
+				continue
+			}
+			if m := rawTagRe.FindString(rest); m != "" {
+				b.WriteString(m)
+				i += len(m)
+				continue
+			}
+			b.WriteString("&lt;")
+			i++
+		case rest[0] == '_' && i > 0 && isWordChar(s[i-1]):
+			// intraword underscores, i.e. "snake_case", are not emphasis
+			b.WriteString("_")
+			i++
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			i += emphasis(&b, rest, rest[:2], "strong")
+		case strings.HasPrefix(rest, "~~"):
+			i += emphasis(&b, rest, "~~", "del")
+		case rest[0] == '*' || rest[0] == '_':
+			i += emphasis(&b, rest, rest[:1], "em")
+		default:
+			b.WriteString(html.EscapeString(rest[:1]))
+			i++
+		}
+	}
+	return b.String()
+}
+
+// emphasis renders the delimited text with the informed tag, when the closing
+// delimiter is not found the delimiter is rendered as text. Returns the amount
+// of consumed bytes.
+func emphasis(b *strings.Builder, s, delim, tag string) int {
+	inner := s[len(delim):]
+	end := findClosing(inner, delim)
+	if end <= 0 || inner[0] == ' ' {
+		b.WriteString(html.EscapeString(delim))
+		return len(delim)
+	}
+	fmt.Fprintf(b, "<%s>%s</%s>", tag, renderInline(inner[:end]), tag)
+	return 2*len(delim) + end
+}
+
+// isWordChar asserts the byte is a ASCII letter or digit.
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// unescape removes Markdown backslash escapes.
+func unescape(s string) string {
+	return backslashEscapeRe.ReplaceAllString(s, "$1")
+}
+
+// renderList renders the list starting on the first line, returns the HTML and
+// the amount of consumed lines.
+func renderList(lines []string) (string, int) {
+	m := listItemRe.FindStringSubmatch(lines[0])
+	baseIndent := len(m[1])
+	tag := listTag(m[2])
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%s>\n", tag)
+	i := 0
+	for i < len(lines) {
+		m = listItemRe.FindStringSubmatch(lines[i])
+		if m == nil || len(m[1]) != baseIndent || listTag(m[2]) != tag {
+			break
+		}
+		contentIndent := len(m[0])
+		item := []string{lines[i][contentIndent:]}
+		i++
+		// item continuation lines, indented beyond the marker
+		for i < len(lines) {
+			line := lines[i]
+			if isBlank(line) {
+				if i+1 < len(lines) && indentOf(lines[i+1]) > baseIndent {
+					item = append(item, "")
+					i++
+					continue
+				}
+				break
+			}
+			if indentOf(line) <= baseIndent {
+				break
+			}
+			item = append(item, strings.TrimPrefix(line,
+				strings.Repeat(" ", min(indentOf(line), contentIndent))))
+			i++
+		}
+		fmt.Fprintf(&b, "<li>%s</li>\n", renderItem(item))
+		// blank lines between items of the same list
+		for i < len(lines) && isBlank(lines[i]) && i+1 < len(lines) &&
+			listItemRe.MatchString(lines[i+1]) &&
+			indentOf(lines[i+1]) == baseIndent {
+			i++
+		}
+	}
+	fmt.Fprintf(&b, "</%s>", tag)
+	return b.String(), i
+}
+
+// listTag returns the list element for the item marker.
+func listTag(marker string) string {
+	if strings.ContainsAny(marker, "-*+") {
+		return "ul"
+	}
+	return "ol"
+}
+
+// renderItem renders the list item content, a single paragraph is rendered as
+// inline content.
+func renderItem(lines []string) string {
+	rendered := renderBlocks(lines)
+	if trimmed, ok := strings.CutPrefix(rendered, "<p>"); ok &&
+		strings.Count(rendered, "<p>") == 1 {
+		return strings.Replace(trimmed, "</p>", "", 1)
+	}
+	return rendered
+}
+
+// indentOf counts the leading spaces.
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// startsBlock asserts the line starts a new block, interrupting a paragraph.
+func startsBlock(line string) bool {
+	return fenceRe.MatchString(line) || headingRe.MatchString(line) ||
+		hrRe.MatchString(line) || quoteRe.MatchString(line) ||
+		listItemRe.MatchString(line)
+}
+
+// renderBlocks renders the Markdown lines as HTML blocks.
+func renderBlocks(lines []string) string {
+	out := []string{}
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+		case fenceRe.MatchString(line):
+			m := fenceRe.FindStringSubmatch(line)
+			code := []string{}
+			i++
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]) {
+				code = append(code, lines[i])
+				i++
+			}
+			i++
+			class := ""
+			if m[2] != "" {
+				class = fmt.Sprintf(` class="language-%s"`, html.EscapeString(m[2]))
+			}
+			out = append(out, fmt.Sprintf("<pre><code%s>%s\n</code></pre>",
+				class, html.EscapeString(strings.Join(code, "\n"))))
+		case hrRe.MatchString(line):
+			out = append(out, "<hr>")
+			i++
+		case headingRe.MatchString(line):
+			m := headingRe.FindStringSubmatch(line)
+			out = append(out, fmt.Sprintf("<h%d>%s</h%d>",
+				len(m[1]), renderInline(m[2]), len(m[1])))
+			i++
+		case quoteRe.MatchString(line):
+			quoted := []string{}
+			for i < len(lines) && !isBlank(lines[i]) {
+				quoted = append(quoted, quoteRe.ReplaceAllString(lines[i], ""))
+				i++
+			}
+			out = append(out, fmt.Sprintf("<blockquote>\n%s\n</blockquote>",
+				renderBlocks(quoted)))
+		case listItemRe.MatchString(line):
+			list, n := renderList(lines[i:])
+			out = append(out, list)
+			i += n
+		default:
+			paragraph := []string{line}
+			i++
+			for i < len(lines) && !isBlank(lines[i]) && !startsBlock(lines[i]) {
+				paragraph = append(paragraph, lines[i])
+				i++
+			}
+			text := strings.TrimSpace(strings.Join(paragraph, "\n"))
+			out = append(out, fmt.Sprintf("<p>%s</p>", renderInline(text)))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// ToHTML renders the Markdown as HTML, the result is sanitized removing scripts,
+// event handlers and unsafe URLs.
+func ToHTML(md []byte) ([]byte, error) {
+	text := strings.ReplaceAll(string(md), "\r\n", "\n")
+	rendered := renderBlocks(strings.Split(text, "\n"))
+	return Sanitize([]byte(rendered))
+}
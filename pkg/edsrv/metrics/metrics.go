@@ -0,0 +1,312 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType Prometheus text exposition format content-type.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// metric represents a metric family written on the text exposition format.
+type metric interface {
+	// write writes the metric family samples.
+	write(w io.Writer) error
+}
+
+// Registry holds the metric families, written in registration order.
+type Registry struct {
+	metrics []metric   // registered metric families
+	m       sync.Mutex // protects the metrics
+}
+
+// register appends the metric family.
+func (r *Registry) register(m metric) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// Write writes all metric families on the text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for _, m := range r.metrics {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewRegistry instantiates a empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: []metric{}}
+}
+
+// labelEscaper escapes label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatFloat formats the sample value.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// formatLabels formats the label pairs, empty without labels.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, labelEscaper.Replace(values[i]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// writeHeader writes the metric family help and type.
+func writeHeader(w io.Writer, name, help, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	return err
+}
+
+// labelKey joins the label values as the series key.
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// series holds the label values of each series, sorted by key.
+type series[T any] struct {
+	labels []string            // label names
+	values map[string]T        // series by label values key
+	keys   map[string][]string // label values by key
+}
+
+// get returns the series for the label values, created with init when absent.
+func (s *series[T]) get(values []string, init func() T) T {
+	if len(values) != len(s.labels) {
+		panic(fmt.Sprintf("metrics: expected %d label values, got %d",
+			len(s.labels), len(values)))
+	}
+	key := labelKey(values)
+	v, ok := s.values[key]
+	if !ok {
+		v = init()
+		s.values[key] = v
+		s.keys[key] = append([]string{}, values...)
+	}
+	return v
+}
+
+// sorted returns the series keys sorted.
+func (s *series[T]) sorted() []string {
+	keys := make([]string, 0, len(s.values))
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// newSeries instantiates the series for the label names.
+func newSeries[T any](labels []string) series[T] {
+	return series[T]{
+		labels: labels,
+		values: map[string]T{},
+		keys:   map[string][]string{},
+	}
+}
+
+// Counter monotonically increasing metric, partitioned by labels.
+type Counter struct {
+	name   string           // metric name
+	help   string           // metric description
+	series series[*float64] // counter values
+	m      sync.Mutex       // protects the series
+}
+
+// Add adds the value on the series for the label values.
+func (c *Counter) Add(v float64, values ...string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	*c.series.get(values, func() *float64 { return new(float64) }) += v
+}
+
+// Inc increments the series for the label values.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Value returns the series value for the label values.
+func (c *Counter) Value(values ...string) float64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return *c.series.get(values, func() *float64 { return new(float64) })
+}
+
+func (c *Counter) write(w io.Writer) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if err := writeHeader(w, c.name, c.help, "counter"); err != nil {
+		return err
+	}
+	for _, k := range c.series.sorted() {
+		_, err := fmt.Fprintf(w, "%s%s %s\n", c.name,
+			formatLabels(c.series.labels, c.series.keys[k]),
+			formatFloat(*c.series.values[k]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewCounter registers a new Counter with the label names.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{name: name, help: help, series: newSeries[*float64](labels)}
+	r.register(c)
+	return c
+}
+
+// Gauge metric which can go up and down.
+type Gauge struct {
+	name  string     // metric name
+	help  string     // metric description
+	value float64    // current value
+	m     sync.Mutex // protects the value
+}
+
+// Add adds the value, negative values decrease the gauge.
+func (g *Gauge) Add(v float64) {
+	g.m.Lock()
+	defer g.m.Unlock()
+	g.value += v
+}
+
+// Inc increments the gauge.
+func (g *Gauge) Inc() {
+	g.Add(1)
+}
+
+// Dec decrements the gauge.
+func (g *Gauge) Dec() {
+	g.Add(-1)
+}
+
+// Value returns the current value.
+func (g *Gauge) Value() float64 {
+	g.m.Lock()
+	defer g.m.Unlock()
+	return g.value
+}
+
+func (g *Gauge) write(w io.Writer) error {
+	g.m.Lock()
+	defer g.m.Unlock()
+	if err := writeHeader(w, g.name, g.help, "gauge"); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.value))
+	return err
+}
+
+// NewGauge registers a new Gauge.
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	g := &Gauge{name: name, help: help}
+	r.register(g)
+	return g
+}
+
+// histogram holds the observations of a single series.
+type histogram struct {
+	counts []uint64 // observations per bucket, not cumulative
+	count  uint64   // total observations
+	sum    float64  // sum of observations
+}
+
+// Histogram samples observations on buckets, partitioned by labels.
+type Histogram struct {
+	name    string             // metric name
+	help    string             // metric description
+	buckets []float64          // sorted bucket upper bounds
+	series  series[*histogram] // observations
+	m       sync.Mutex         // protects the series
+}
+
+// Observe records the observation on the series for the label values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.m.Lock()
+	defer h.m.Unlock()
+	s := h.series.get(values, func() *histogram {
+		return &histogram{counts: make([]uint64, len(h.buckets))}
+	})
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.m.Lock()
+	defer h.m.Unlock()
+	if err := writeHeader(w, h.name, h.help, "histogram"); err != nil {
+		return err
+	}
+	labels := append(append([]string{}, h.series.labels...), "le")
+	for _, k := range h.series.sorted() {
+		s, values := h.series.values[k], h.series.keys[k]
+		cumulative := uint64(0)
+		for i, le := range append(append([]float64{}, h.buckets...), math.Inf(1)) {
+			if i < len(s.counts) {
+				cumulative += s.counts[i]
+			} else {
+				cumulative = s.count
+			}
+			_, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name,
+				formatLabels(labels, append(append([]string{}, values...),
+					formatFloat(le))), cumulative)
+			if err != nil {
+				return err
+			}
+		}
+		suffix := formatLabels(h.series.labels, values)
+		_, err := fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n",
+			h.name, suffix, formatFloat(s.sum), h.name, suffix, s.count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewHistogram registers a new Histogram with the bucket upper bounds and label
+// names, the "+Inf" bucket is implicit.
+func (r *Registry) NewHistogram(
+	name, help string,
+	buckets []float64,
+	labels ...string,
+) *Histogram {
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	h := &Histogram{
+		name:    name,
+		help:    help,
+		buckets: buckets,
+		series:  newSeries[*histogram](labels),
+	}
+	r.register(h)
+	return h
+}
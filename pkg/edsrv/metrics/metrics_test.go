@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("requests_total", "Total requests.", "route", "code")
+	g := r.NewGauge("active", "Active sessions.")
+	h := r.NewHistogram("size_bytes", "Payload size.", []float64{10, 100})
+
+	c.Inc("/", "200")
+	c.Inc("/", "200")
+	c.Inc("/status", "500")
+	c.Inc(`a"b`, "200")
+	g.Inc()
+	g.Inc()
+	g.Dec()
+	h.Observe(5)
+	h.Observe(50)
+	h.Observe(500)
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("Registry.Write() error = %v", err)
+	}
+	want := `# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{route="/status",code="500"} 1
+requests_total{route="/",code="200"} 2
+requests_total{route="a\"b",code="200"} 1
+# HELP active Active sessions.
+# TYPE active gauge
+active 1
+# HELP size_bytes Payload size.
+# TYPE size_bytes histogram
+size_bytes_bucket{le="10"} 1
+size_bytes_bucket{le="100"} 2
+size_bytes_bucket{le="+Inf"} 3
+size_bytes_sum 555
+size_bytes_count 3
+`
+	if buf.String() != want {
+		t.Errorf("Registry.Write() =\n%s\nexpected\n%s", buf.String(), want)
+	}
+}
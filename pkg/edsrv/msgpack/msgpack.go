@@ -0,0 +1,336 @@
+package msgpack
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Ext represents a msgpack extension type, i.e. Neovim buffer, window and
+// tabpage handles.
+type Ext struct {
+	Type int8   // extension type
+	Data []byte // raw extension data
+}
+
+// ErrUnsupportedType the value type is not supported by the encoder.
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// ErrInvalidFormat the decoded stream contains an unknown format byte.
+var ErrInvalidFormat = errors.New("invalid msgpack format")
+
+// Encoder writes msgpack encoded values.
+type Encoder struct {
+	w *bufio.Writer // buffered writer
+}
+
+// write writes the informed byte slices on the buffer.
+func (e *Encoder) write(parts ...[]byte) error {
+	for _, p := range parts {
+		if _, err := e.w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHeader writes the format header, choosing the smallest representation
+// for the informed length.
+func (e *Encoder) writeHeader(n int, fix, fixMax byte, f8, f16, f32 byte) error {
+	switch {
+	case fix != 0 && n <= int(fixMax):
+		return e.write([]byte{fix | byte(n)})
+	case f8 != 0 && n <= math.MaxUint8:
+		return e.write([]byte{f8, byte(n)})
+	case n <= math.MaxUint16:
+		return e.write([]byte{f16}, binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		return e.write([]byte{f32}, binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+// encodeInt encodes a signed integer.
+func (e *Encoder) encodeInt(i int64) error {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		return e.write([]byte{byte(i)})
+	case i < 0 && i >= -32:
+		return e.write([]byte{byte(i)})
+	case i >= 0:
+		return e.write([]byte{0xcf}, binary.BigEndian.AppendUint64(nil, uint64(i)))
+	default:
+		return e.write([]byte{0xd3}, binary.BigEndian.AppendUint64(nil, uint64(i)))
+	}
+}
+
+// encode encodes the informed value on the buffer.
+func (e *Encoder) encode(v any) error {
+	switch t := v.(type) {
+	case nil:
+		return e.write([]byte{0xc0})
+	case bool:
+		if t {
+			return e.write([]byte{0xc3})
+		}
+		return e.write([]byte{0xc2})
+	case int:
+		return e.encodeInt(int64(t))
+	case int64:
+		return e.encodeInt(t)
+	case uint64:
+		return e.write([]byte{0xcf}, binary.BigEndian.AppendUint64(nil, t))
+	case float64:
+		return e.write(
+			[]byte{0xcb},
+			binary.BigEndian.AppendUint64(nil, math.Float64bits(t)),
+		)
+	case string:
+		if err := e.writeHeader(len(t), 0xa0, 31, 0xd9, 0xda, 0xdb); err != nil {
+			return err
+		}
+		return e.write([]byte(t))
+	case []byte:
+		if err := e.writeHeader(len(t), 0, 0, 0xc4, 0xc5, 0xc6); err != nil {
+			return err
+		}
+		return e.write(t)
+	case []string:
+		s := make([]any, 0, len(t))
+		for _, item := range t {
+			s = append(s, item)
+		}
+		return e.encode(s)
+	case []any:
+		if err := e.writeHeader(len(t), 0x90, 15, 0, 0xdc, 0xdd); err != nil {
+			return err
+		}
+		for _, item := range t {
+			if err := e.encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]any:
+		if err := e.writeHeader(len(t), 0x80, 15, 0, 0xde, 0xdf); err != nil {
+			return err
+		}
+		for k, item := range t {
+			if err := e.encode(k); err != nil {
+				return err
+			}
+			if err := e.encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+}
+
+// Encode writes the msgpack representation of the informed value, and flushes
+// the buffer.
+func (e *Encoder) Encode(v any) error {
+	if err := e.encode(v); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+// NewEncoder instantiates the Encoder for the informed writer.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// Decoder reads msgpack encoded values.
+type Decoder struct {
+	r *bufio.Reader // buffered reader
+}
+
+// maxPrealloc maximum number of items allocated upfront, the lengths come from
+// the stream and can't be trusted before the content is read.
+const maxPrealloc = 1024
+
+// unexpectedEOF replaces io.EOF, the stream ends in the middle of a value.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// read reads exactly n bytes, the buffer grows as the content is read.
+func (d *Decoder) read(n int) ([]byte, error) {
+	var b bytes.Buffer
+	b.Grow(min(n, maxPrealloc))
+	if _, err := io.CopyN(&b, d.r, int64(n)); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return b.Bytes(), nil
+}
+
+// next decodes the next value inside an array or map.
+func (d *Decoder) next() (any, error) {
+	v, err := d.Decode()
+	return v, unexpectedEOF(err)
+}
+
+// readUint reads a big-endian unsigned integer of the informed size.
+func (d *Decoder) readUint(size int) (uint64, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+// readLen reads the length header of the informed size.
+func (d *Decoder) readLen(size int) (int, error) {
+	u, err := d.readUint(size)
+	return int(u), err
+}
+
+// decodeArray decodes n array items.
+func (d *Decoder) decodeArray(n int) ([]any, error) {
+	s := make([]any, 0, min(n, maxPrealloc))
+	for i := 0; i < n; i++ {
+		v, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, v)
+	}
+	return s, nil
+}
+
+// decodeMap decodes n map entries, keys are represented as strings.
+func (d *Decoder) decodeMap(n int) (map[string]any, error) {
+	m := make(map[string]any, min(n, maxPrealloc))
+	for i := 0; i < n; i++ {
+		k, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		m[fmt.Sprint(k)] = v
+	}
+	return m, nil
+}
+
+// decodeExt decodes a extension type with n bytes of data.
+func (d *Decoder) decodeExt(n int) (*Ext, error) {
+	b, err := d.read(n + 1)
+	if err != nil {
+		return nil, err
+	}
+	return &Ext{Type: int8(b[0]), Data: b[1:]}, nil
+}
+
+// decodeSized decodes the formats carrying a length header, strings, binary,
+// arrays, maps and extensions.
+func (d *Decoder) decodeSized(format byte, n int) (any, error) {
+	switch format {
+	case 0xd9, 0xda, 0xdb:
+		b, err := d.read(n)
+		return string(b), err
+	case 0xc4, 0xc5, 0xc6:
+		return d.read(n)
+	case 0xdc, 0xdd:
+		return d.decodeArray(n)
+	case 0xde, 0xdf:
+		return d.decodeMap(n)
+	default:
+		return d.decodeExt(n)
+	}
+}
+
+// Decode reads the next value from the stream, integers are represented as
+// int64 (or uint64 when needed), strings as string, binary as []byte, arrays as
+// []any, maps as map[string]any and extensions as *Ext.
+func (d *Decoder) Decode() (any, error) {
+	format, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case format <= 0x7f:
+		return int64(format), nil
+	case format >= 0xe0:
+		return int64(int8(format)), nil
+	case format&0xe0 == 0xa0:
+		b, err := d.read(int(format & 0x1f))
+		return string(b), err
+	case format&0xf0 == 0x90:
+		return d.decodeArray(int(format & 0x0f))
+	case format&0xf0 == 0x80:
+		return d.decodeMap(int(format & 0x0f))
+	}
+
+	switch format {
+	case 0xc0:
+		return nil, nil //nolint:nilnil // msgpack nil value
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce:
+		u, err := d.readUint(1 << (format - 0xcc))
+		return int64(u), err
+	case 0xcf:
+		u, err := d.readUint(8)
+		if err == nil && u <= math.MaxInt64 {
+			return int64(u), nil
+		}
+		return u, err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (format - 0xd0)
+		u, err := d.readUint(size)
+		shift := 64 - 8*size
+		return int64(u<<shift) >> shift, err
+	case 0xca:
+		u, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.readUint(8)
+		return math.Float64frombits(u), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.decodeExt(1 << (format - 0xd4))
+	case 0xc4, 0xd9, 0xc7:
+		n, err := d.readLen(1)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeSized(format, n)
+	case 0xc5, 0xda, 0xdc, 0xde, 0xc8:
+		n, err := d.readLen(2)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeSized(format, n)
+	case 0xc6, 0xdb, 0xdd, 0xdf, 0xc9:
+		n, err := d.readLen(4)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeSized(format, n)
+	default:
+		return nil, fmt.Errorf("%w: 0x%x", ErrInvalidFormat, format)
+	}
+}
+
+// NewDecoder instantiates the Decoder for the informed reader.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
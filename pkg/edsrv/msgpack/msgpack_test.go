@@ -0,0 +1,246 @@
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEncoder_Encode(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  any
+		prefix []byte
+		want   any
+	}{
+		{"nil", nil, []byte{0xc0}, nil},
+		{"false", false, []byte{0xc2}, false},
+		{"true", true, []byte{0xc3}, true},
+		{"positive fixint zero", 0, []byte{0x00}, int64(0)},
+		{"positive fixint max", 127, []byte{0x7f}, int64(127)},
+		{
+			"positive fixint overflow",
+			128,
+			[]byte{0xcf, 0, 0, 0, 0, 0, 0, 0, 0x80},
+			int64(128),
+		},
+		{"negative fixint max", -1, []byte{0xff}, int64(-1)},
+		{"negative fixint min", -32, []byte{0xe0}, int64(-32)},
+		{
+			"negative fixint overflow",
+			int64(-33),
+			[]byte{0xd3, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xdf},
+			int64(-33),
+		},
+		{"uint64 above int64", uint64(1 << 63), []byte{0xcf, 0x80}, uint64(1 << 63)},
+		{"float64", 1.5, []byte{0xcb, 0x3f, 0xf8}, 1.5},
+		{"fixstr empty", "", []byte{0xa0}, ""},
+		{
+			"fixstr max",
+			strings.Repeat("a", 31),
+			[]byte{0xbf, 'a'},
+			strings.Repeat("a", 31),
+		},
+		{
+			"str8 min",
+			strings.Repeat("a", 32),
+			[]byte{0xd9, 32, 'a'},
+			strings.Repeat("a", 32),
+		},
+		{
+			"str8 max",
+			strings.Repeat("a", 255),
+			[]byte{0xd9, 0xff},
+			strings.Repeat("a", 255),
+		},
+		{
+			"str16 min",
+			strings.Repeat("a", 256),
+			[]byte{0xda, 0x01, 0x00},
+			strings.Repeat("a", 256),
+		},
+		{
+			"str16 max",
+			strings.Repeat("a", 65535),
+			[]byte{0xda, 0xff, 0xff},
+			strings.Repeat("a", 65535),
+		},
+		{
+			"str32 min",
+			strings.Repeat("a", 65536),
+			[]byte{0xdb, 0x00, 0x01, 0x00, 0x00},
+			strings.Repeat("a", 65536),
+		},
+		{"bin8 empty", []byte{}, []byte{0xc4, 0x00}, []byte{}},
+		{
+			"bin8 max",
+			bytes.Repeat([]byte{1}, 255),
+			[]byte{0xc4, 0xff},
+			bytes.Repeat([]byte{1}, 255),
+		},
+		{
+			"bin16 min",
+			bytes.Repeat([]byte{1}, 256),
+			[]byte{0xc5, 0x01, 0x00},
+			bytes.Repeat([]byte{1}, 256),
+		},
+		{
+			"bin32 min",
+			bytes.Repeat([]byte{1}, 65536),
+			[]byte{0xc6, 0x00, 0x01, 0x00, 0x00},
+			bytes.Repeat([]byte{1}, 65536),
+		},
+		{"fixarray empty", []any{}, []byte{0x90}, []any{}},
+		{"fixarray max", make([]any, 15), []byte{0x9f, 0xc0}, make([]any, 15)},
+		{"array16 min", make([]any, 16), []byte{0xdc, 0x00, 0x10, 0xc0}, make([]any, 16)},
+		{
+			"array32 min",
+			make([]any, 65536),
+			[]byte{0xdd, 0x00, 0x01, 0x00, 0x00},
+			make([]any, 65536),
+		},
+		{"string slice", []string{"a", "b"}, []byte{0x92, 0xa1, 'a'}, []any{"a", "b"}},
+		{"fixmap empty", map[string]any{}, []byte{0x80}, map[string]any{}},
+		{"fixmap max", sizedMap(15), []byte{0x8f}, sizedMap(15)},
+		{"map16 min", sizedMap(16), []byte{0xde, 0x00, 0x10}, sizedMap(16)},
+		{
+			"map32 min",
+			sizedMap(65536),
+			[]byte{0xdf, 0x00, 0x01, 0x00, 0x00},
+			sizedMap(65536),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := NewEncoder(&buf).Encode(tt.value); err != nil {
+				t.Fatalf("Encoder.Encode() error = %v", err)
+			}
+			if !bytes.HasPrefix(buf.Bytes(), tt.prefix) {
+				t.Errorf("Encoder.Encode() = % x..., expected prefix % x",
+					buf.Bytes()[:min(buf.Len(), 16)], tt.prefix)
+			}
+
+			d := NewDecoder(&buf)
+			got, err := d.Decode()
+			if err != nil {
+				t.Fatalf("Decoder.Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decoder.Decode() = %v, expected %v", got, tt.want)
+			}
+			if _, err = d.Decode(); !errors.Is(err, io.EOF) {
+				t.Errorf("Decoder.Decode() error = %v, expected %v", err, io.EOF)
+			}
+		})
+	}
+}
+
+// sizedMap returns a map with n nil entries.
+func sizedMap(n int) map[string]any {
+	m := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		m[strconv.Itoa(i)] = nil
+	}
+	return m
+}
+
+func TestEncoder_EncodeUnsupported(t *testing.T) {
+	err := NewEncoder(io.Discard).Encode(struct{}{})
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Encoder.Encode() error = %v, expected %v", err, ErrUnsupportedType)
+	}
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  any
+	}{
+		{"uint8", []byte{0xcc, 0xff}, int64(255)},
+		{"uint16", []byte{0xcd, 0xff, 0xff}, int64(65535)},
+		{"uint32", []byte{0xce, 0xff, 0xff, 0xff, 0xff}, int64(1<<32 - 1)},
+		{"int8", []byte{0xd0, 0x80}, int64(-128)},
+		{"int16", []byte{0xd1, 0x80, 0x00}, int64(-32768)},
+		{"int32", []byte{0xd2, 0xff, 0xff, 0xff, 0xfe}, int64(-2)},
+		{"float32", []byte{0xca, 0x3f, 0xc0, 0x00, 0x00}, 1.5},
+		{"fixext1", []byte{0xd4, 0x01, 0x02}, &Ext{Type: 1, Data: []byte{2}}},
+		{
+			"ext8",
+			[]byte{0xc7, 0x02, 0xff, 0x01, 0x02},
+			&Ext{Type: -1, Data: []byte{1, 2}},
+		},
+		{"map integer keys", []byte{0x81, 0x01, 0xc3}, map[string]any{"1": true}},
+		{
+			"nested",
+			[]byte{0x92, 0x81, 0xa1, 'k', 0x90, 0xc0},
+			[]any{map[string]any{"k": []any{}}, nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDecoder(bytes.NewReader(tt.input)).Decode()
+			if err != nil {
+				t.Fatalf("Decoder.Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decoder.Decode() = %#v, expected %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_DecodeInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []byte
+		wantErr error
+	}{
+		{"empty", []byte{}, io.EOF},
+		{"reserved format", []byte{0xc1}, ErrInvalidFormat},
+		{"invalid nested format", []byte{0x91, 0xc1}, ErrInvalidFormat},
+		{"truncated uint16", []byte{0xcd, 0x01}, io.ErrUnexpectedEOF},
+		{"truncated int64", []byte{0xd3}, io.ErrUnexpectedEOF},
+		{"truncated float64", []byte{0xcb, 0x3f, 0xf8}, io.ErrUnexpectedEOF},
+		{"truncated fixstr", []byte{0xa3, 'a'}, io.ErrUnexpectedEOF},
+		{"truncated str8 length", []byte{0xd9}, io.ErrUnexpectedEOF},
+		{"truncated str16 length", []byte{0xda, 0x01}, io.ErrUnexpectedEOF},
+		{
+			"truncated str32",
+			[]byte{0xdb, 0xff, 0xff, 0xff, 0xff, 'a'},
+			io.ErrUnexpectedEOF,
+		},
+		{"truncated bin32", []byte{0xc6, 0xff, 0xff, 0xff, 0xff}, io.ErrUnexpectedEOF},
+		{"truncated fixarray", []byte{0x92, 0xc0}, io.ErrUnexpectedEOF},
+		{
+			"truncated array32",
+			[]byte{0xdd, 0xff, 0xff, 0xff, 0xff, 0xc0},
+			io.ErrUnexpectedEOF,
+		},
+		{"truncated fixmap value", []byte{0x81, 0xa1, 'k'}, io.ErrUnexpectedEOF},
+		{"truncated map32", []byte{0xdf, 0xff, 0xff, 0xff, 0xff}, io.ErrUnexpectedEOF},
+		{"truncated fixext", []byte{0xd5, 0x01, 0x02}, io.ErrUnexpectedEOF},
+		{
+			"truncated ext32",
+			[]byte{0xc9, 0xff, 0xff, 0xff, 0xff, 0x01},
+			io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDecoder(bytes.NewReader(tt.input)).Decode()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decoder.Decode() = %v, %v, expected error %v",
+					got, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/audit"
+
+	"github.com/valyala/fasthttp"
+)
+
+// newAuditRecord instantiates the audit record with the request attributes.
+func newAuditRecord(
+	ctx *fasthttp.RequestCtx,
+	event string,
+	started time.Time,
+) *audit.Record {
+	return &audit.Record{
+		Time:      started,
+		Event:     event,
+		Origin:    string(ctx.Request.Header.Peek(fasthttp.HeaderOrigin)),
+		URL:       string(ctx.Request.Header.Peek(URLHeader)),
+		UserAgent: string(ctx.Request.Header.UserAgent()),
+		Remote:    ctx.RemoteAddr().String(),
+	}
+}
+
+// writeAudit completes the audit record with the response status, result and
+// duration, and appends it on the audit log, when enabled.
+func (s *Service) writeAudit(
+	logger *slog.Logger,
+	ctx *fasthttp.RequestCtx,
+	rec *audit.Record,
+) {
+	if s.audit == nil {
+		return
+	}
+	rec.DurationMs = time.Since(rec.Time).Milliseconds()
+	rec.Status = ctx.Response.StatusCode()
+	rec.Result = string(ctx.Response.Header.Peek(ResultHeader))
+	if err := s.audit.Write(rec); err != nil {
+		logger.Error("unable to write audit log", "err", err.Error())
+	}
+}
+
+// reject accounts the request rejected before reaching the router.
+func (s *Service) reject(ctx *fasthttp.RequestCtx, reason string) {
+	s.metrics.rejected.Inc(reason)
+	rec := newAuditRecord(ctx, audit.EventRejected, time.Now())
+	rec.Reason = reason
+	s.writeAudit(s.logger, ctx, rec)
+}
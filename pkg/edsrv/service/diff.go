@@ -0,0 +1,49 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/diff"
+
+	"github.com/valyala/fasthttp"
+)
+
+// DiffResponse the diff response body, the edited text and the changes compared
+// to the original payload.
+type DiffResponse struct {
+	Text    string       `json:"text"`    // edited text
+	Diff    string       `json:"diff"`    // unified diff
+	Ranges  []diff.Range `json:"ranges"`  // changes in UTF-16 offsets
+	Added   int          `json:"added"`   // lines added
+	Removed int          `json:"removed"` // lines removed
+}
+
+// NewDiffResponse compares the original and edited texts.
+func NewDiffResponse(original, edited []byte) *DiffResponse {
+	lines := diff.NewLines(original, edited)
+	added, removed := lines.Stat()
+	return &DiffResponse{
+		Text:    string(edited),
+		Diff:    string(lines.Unified("original", "edited")),
+		Ranges:  lines.Ranges(),
+		Added:   added,
+		Removed: removed,
+	}
+}
+
+// respondDiff writes the diff response as JSON, with the summary header.
+func respondDiff(ctx *fasthttp.RequestCtx, original, edited []byte) error {
+	res := NewDiffResponse(original, edited)
+	body, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	ctx.Response.Header.Set(DiffSummaryHeader,
+		fmt.Sprintf("+%d -%d", res.Added, res.Removed))
+	ctx.SetContentType("application/json; charset=utf-8")
+	ctx.SetBody(body)
+	ctx.SetStatusCode(http.StatusOK)
+	return nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"strconv"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// URLHeader page URL header, informed by the browser extension.
+	URLHeader = "X-Url"
+	// IDHeader field ID header, informed by the browser extension.
+	IDHeader = "X-Id"
+	// TitleHeader page title header, informed by the browser extension.
+	TitleHeader = "X-Title"
+	// FormatHeader payload format hint header, "html" or "text".
+	FormatHeader = "X-Edsrv-Format"
+	// ProfileHeader settings profile name header.
+	ProfileHeader = "X-Edsrv-Profile"
+	// TemplateHeader template name hint header, used for empty fields.
+	TemplateHeader = "X-Edsrv-Template"
+	// ExtHeader file name extension hint header, i.e. "md", helping the editor
+	// to detect the file type.
+	ExtHeader = "X-Edsrv-Ext"
+	// FilterErrorHeader response header describing a failed filter pipeline, the
+	// unfiltered text is returned instead.
+	FilterErrorHeader = "X-Edsrv-Filter-Error"
+	// DiffHeader asks for the diff response, with the edited text and the
+	// changes compared to the original payload.
+	DiffHeader = "X-Edsrv-Diff"
+	// DiffSummaryHeader response header with the number of lines added and
+	// removed, i.e. "+3 -1".
+	DiffSummaryHeader = "X-Edsrv-Diff-Summary"
+	// ResultHeader response header with the edit result.
+	ResultHeader = "X-Edsrv-Result"
+
+	// ResultChanged the edited text is changed, "200 OK".
+	ResultChanged = "changed"
+	// ResultUnchanged the editor didn't change the text, "204 No Content".
+	ResultUnchanged = "unchanged"
+	// ResultAborted the user aborted the edit, the editor finished with a abort
+	// exit code, "409 Conflict".
+	ResultAborted = "aborted"
+	// ResultFailed the edit failed, "500 Internal Server Error", or "400 Bad
+	// Request" for invalid payloads.
+	ResultFailed = "failed"
+)
+
+// metadataFromRequest extracts the edit request metadata from the headers.
+func metadataFromRequest(ctx *fasthttp.RequestCtx) *config.Metadata {
+	return &config.Metadata{
+		URL:     string(ctx.Request.Header.Peek(URLHeader)),
+		ID:      string(ctx.Request.Header.Peek(IDHeader)),
+		Title:   string(ctx.Request.Header.Peek(TitleHeader)),
+		Format:  string(ctx.Request.Header.Peek(FormatHeader)),
+		Profile: string(ctx.Request.Header.Peek(ProfileHeader)),
+
+		Template:  string(ctx.Request.Header.Peek(TemplateHeader)),
+		Extension: string(ctx.Request.Header.Peek(ExtHeader)),
+	}
+}
+
+// diffRequested asserts the client asks for the diff response.
+func diffRequested(ctx *fasthttp.RequestCtx) bool {
+	v, _ := strconv.ParseBool(string(ctx.Request.Header.Peek(DiffHeader)))
+	return v
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/metrics"
+
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+// MetricsPath metrics path, served on the metrics listen address.
+const MetricsPath = "/metrics"
+
+// routeOther route label for requests not matching the application endpoints.
+const routeOther = "other"
+
+// durationBuckets edit duration buckets, in seconds, editing takes from a few
+// seconds up to hours.
+var durationBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600}
+
+// sizeBuckets payload size buckets, in bytes.
+var sizeBuckets = []float64{
+	64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20,
+}
+
+// serviceMetrics the service metrics, exposed on Prometheus text format.
+type serviceMetrics struct {
+	registry      *metrics.Registry  // metrics registry
+	edits         *metrics.Counter   // edit requests by result
+	duration      *metrics.Histogram // edit duration by result
+	requestSize   *metrics.Histogram // edit request payload size
+	responseSize  *metrics.Histogram // edit response payload size
+	active        *metrics.Gauge     // active edit sessions
+	spawnFailures *metrics.Counter   // editor failures without exit status
+	rejected      *metrics.Counter   // requests rejected by origin, limits or parsing
+	requests      *metrics.Counter   // HTTP requests by route and status code
+}
+
+// route returns the route label for the request path.
+func route(ctx *fasthttp.RequestCtx) string {
+	switch path := string(ctx.Path()); path {
+	case RootPath, StatusPath, RecoverPath:
+		return path
+	default:
+		return routeOther
+	}
+}
+
+// observeRequest records the HTTP request route and status code.
+func (m *serviceMetrics) observeRequest(ctx *fasthttp.RequestCtx) {
+	m.requests.Inc(route(ctx), strconv.Itoa(ctx.Response.StatusCode()))
+}
+
+// observeEdit records the edit result, duration and response size, the result
+// is taken from the response header.
+func (m *serviceMetrics) observeEdit(ctx *fasthttp.RequestCtx, started time.Time) {
+	result := string(ctx.Response.Header.Peek(ResultHeader))
+	m.edits.Inc(result)
+	m.duration.Observe(time.Since(started).Seconds(), result)
+	m.responseSize.Observe(float64(len(ctx.Response.Body())))
+}
+
+// handler serves the metrics on Prometheus text format.
+func (m *serviceMetrics) handler(ctx *fasthttp.RequestCtx) {
+	var buf bytes.Buffer
+	if err := m.registry.Write(&buf); err != nil {
+		ctx.Error(err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ctx.SetContentType(metrics.ContentType)
+	ctx.SetBody(buf.Bytes())
+	ctx.SetStatusCode(http.StatusOK)
+}
+
+// newServiceMetrics registers the service metrics.
+func newServiceMetrics() *serviceMetrics {
+	r := metrics.NewRegistry()
+	return &serviceMetrics{
+		registry: r,
+		edits: r.NewCounter("edsrv_edits_total",
+			"Edit requests by result.", "result"),
+		duration: r.NewHistogram("edsrv_edit_duration_seconds",
+			"Edit duration in seconds, by result.", durationBuckets, "result"),
+		requestSize: r.NewHistogram("edsrv_edit_request_size_bytes",
+			"Edit request payload size in bytes.", sizeBuckets),
+		responseSize: r.NewHistogram("edsrv_edit_response_size_bytes",
+			"Edit response payload size in bytes.", sizeBuckets),
+		active: r.NewGauge("edsrv_active_sessions",
+			"Edit sessions waiting for the editor."),
+		spawnFailures: r.NewCounter("edsrv_editor_spawn_failures_total",
+			"Editor failures without exit status, not found or unable to launch."),
+		rejected: r.NewCounter("edsrv_requests_rejected_total",
+			"Requests rejected before routing, disallowed origin, size limits, "+
+				"timeout or malformed, by reason.", "reason"),
+		requests: r.NewCounter("edsrv_http_requests_total",
+			"HTTP requests by route and status code.", "route", "code"),
+	}
+}
+
+// MetricsHandler instantiates the request router for the metrics endpoint.
+func (s *Service) MetricsHandler() fasthttp.RequestHandler {
+	r := router.New()
+	r.GET(MetricsPath, s.metrics.handler)
+	return r.Handler
+}
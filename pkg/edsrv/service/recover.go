@@ -0,0 +1,182 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/history"
+
+	"github.com/valyala/fasthttp"
+)
+
+// RecoverPath undelivered edits path.
+const RecoverPath = "/recover"
+
+// hookTimeout maximum execution time of the recovery notification hook.
+const hookTimeout = 30 * time.Second
+
+// Recovered represents a undelivered edit on the "/recover" endpoint response.
+type Recovered struct {
+	*history.Entry
+	Text string `json:"text"` // edited text
+}
+
+// deliver writes the response on the hijacked connection, the undelivered
+// function is called when the client is gone. The headers and body are flushed
+// separately, a closed connection answers the first write with a reset, failing
+// the second.
+func deliver(ctx *fasthttp.RequestCtx, undelivered func()) {
+	ctx.Response.Header.SetConnectionClose()
+	ctx.Response.Header.SetContentLength(len(ctx.Response.Body()))
+	header := bytes.Clone(ctx.Response.Header.Header())
+	body := bytes.Clone(ctx.Response.Body())
+
+	ctx.HijackSetNoResponse(true)
+	ctx.Hijack(func(conn net.Conn) {
+		w := bufio.NewWriter(conn)
+		for _, b := range [][]byte{header, body} {
+			if _, err := w.Write(b); err != nil {
+				undelivered()
+				return
+			}
+			if err := w.Flush(); err != nil {
+				undelivered()
+				return
+			}
+		}
+	})
+}
+
+// notify runs the recovery hook in the background, the entry details are
+// informed as environment variables.
+func (s *Service) notify(logger *slog.Logger, e *history.Entry) {
+	if s.cfg.RecoverHook == "" {
+		return
+	}
+	file, err := s.recovery.Path(e.ID, history.Edited)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, "sh", "-c", s.cfg.RecoverHook) //nolint:gosec
+		cmd.Env = append(os.Environ(),
+			"EDSRV_RECOVER_ID="+e.ID,
+			"EDSRV_RECOVER_FILE="+file,
+			"EDSRV_URL="+e.URL,
+			"EDSRV_TITLE="+e.Title,
+			"EDSRV_ID="+e.FieldID,
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			logger.Error("recover hook failed",
+				"err", err.Error(), "output", string(output))
+			return
+		}
+		logger.Debug("recover hook notified")
+	}()
+}
+
+// recover moves the undelivered edit to the recovery area, and notifies the
+// hook.
+func (s *Service) recover(
+	logger *slog.Logger,
+	meta *config.Metadata,
+	started time.Time,
+	original, edited []byte,
+) {
+	e := &history.Entry{
+		URL:      meta.URL,
+		Title:    meta.Title,
+		FieldID:  meta.ID,
+		Editor:   s.ed.GetCommand(),
+		Started:  started,
+		Finished: time.Now(),
+	}
+	if err := s.recovery.Save(e, original, edited); err != nil {
+		logger.Error("unable to save undelivered edit", "err", err.Error())
+		return
+	}
+	logger = logger.With("recover", e.ID)
+	logger.Warn("client is gone, edit moved to the recovery area")
+	s.notify(logger, e)
+}
+
+// isLoopbackHost asserts the request host header names the loopback interface,
+// a DNS rebinding page sends its own host name.
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// recovered handles the requests for the "/recover" endpoint, lists the
+// undelivered edits with their text for the page and field informed by the
+// "url" and "id" query arguments. Only loopback host names are accepted.
+func (s *Service) recovered(ctx *fasthttp.RequestCtx) {
+	logger := s.logger.With("endpoint", RecoverPath)
+	if !isLoopbackHost(string(ctx.Host())) {
+		logger.Warn("rejecting non-loopback host", "host", string(ctx.Host()))
+		ctx.Error("host is not allowed", http.StatusForbidden)
+		return
+	}
+	args := ctx.QueryArgs()
+	pageURL, id := string(args.Peek("url")), string(args.Peek("id"))
+	if pageURL == "" || id == "" {
+		ctx.Error(`query arguments "url" and "id" are required`,
+			http.StatusBadRequest)
+		return
+	}
+
+	entries, err := s.recovery.List()
+	if err != nil {
+		logger.Error(err.Error())
+		ctx.Error(err.Error(), http.StatusInternalServerError)
+		return
+	}
+	items := []*Recovered{}
+	for _, e := range entries {
+		if e.Err != nil {
+			logger.Error(e.Err.Error())
+			continue
+		}
+		if e.URL != pageURL || e.FieldID != id {
+			continue
+		}
+		text, err := s.recovery.Read(e.ID, history.Edited)
+		if err != nil {
+			logger.Error(err.Error())
+			continue
+		}
+		items = append(items, &Recovered{Entry: e, Text: string(text)})
+	}
+
+	body, err := json.Marshal(items)
+	if err != nil {
+		logger.Error(err.Error())
+		ctx.Error(err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+	ctx.SetStatusCode(http.StatusOK)
+	logger.Debug("listing undelivered edits", "items", len(items))
+}
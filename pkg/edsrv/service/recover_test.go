@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/editor"
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
+
+	"github.com/valyala/fasthttp"
+
+	. "github.com/onsi/gomega"
+)
+
+// slowEditor takes a while to edit, giving time for the client to go away.
+type slowEditor struct {
+	*editor.FakeEditor
+}
+
+func (e *slowEditor) Edit(
+	ctx context.Context,
+	payload []byte,
+	opts file.Options,
+) (file.Interface, error) {
+	time.Sleep(200 * time.Millisecond)
+	return e.FakeEditor.Edit(ctx, payload, opts)
+}
+
+func TestService_recover(t *testing.T) {
+	g := NewWithT(t)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cfg := config.NewConfig()
+	cfg.StateDir = t.TempDir()
+	cfg.RecoverHook = fmt.Sprintf("touch %s/notified", cfg.StateDir)
+	cfg.RecoverEndpoint = true
+
+	ed := &slowEditor{FakeEditor: editor.NewFakeEditor([]byte("unsent text"))}
+	srv := NewService(logger, cfg, ed, nil)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).To(Succeed())
+	defer ln.Close()
+	go func() {
+		_ = fasthttp.Serve(ln, srv.RequestHandler())
+	}()
+
+	// the client sends the edit request and goes away before the response
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	g.Expect(err).To(Succeed())
+	_, err = fmt.Fprintf(conn, "POST / HTTP/1.1\r\nHost: edsrv\r\n%s: %s\r\n%s: %s\r\n"+
+		"Content-Length: 4\r\n\r\ntext", URLHeader, "https://example.com", IDHeader, "body")
+	g.Expect(err).To(Succeed())
+	g.Expect(conn.Close()).To(Succeed())
+
+	c := &fasthttp.HostClient{Addr: ln.Addr().String()}
+	base := "http://" + ln.Addr().String()
+	recovered := []*Recovered{}
+	g.Eventually(func() []*Recovered {
+		_, body, err := c.Get(nil, base+RecoverPath+"?url=https://example.com&id=body")
+		if err != nil {
+			return nil
+		}
+		g.Expect(json.Unmarshal(body, &recovered)).To(Succeed())
+		return recovered
+	}, 2*time.Second, 50*time.Millisecond).Should(HaveLen(1))
+	g.Expect(recovered[0].Text).To(Equal("unsent text"))
+
+	// the delivered edits are not recovered
+	req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(res)
+	req.SetRequestURI("http://edsrv" + RootPath)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.Set(URLHeader, "https://example.org")
+	req.SetBodyString("text")
+	g.Expect(c.Do(req, res)).To(Succeed())
+	g.Expect(string(res.Body())).To(Equal("unsent text"))
+	_, body, err := c.Get(nil, base+RecoverPath+"?url=https://example.org&id=body")
+	g.Expect(err).To(Succeed())
+	g.Expect(string(body)).To(Equal("[]"))
+
+	// the page and field are required, and only loopback hosts are accepted
+	code, _, err := c.Get(nil, base+RecoverPath+"?url=https://example.com")
+	g.Expect(err).To(Succeed())
+	g.Expect(code).To(Equal(http.StatusBadRequest))
+	code, _, err = c.Get(nil, "http://rebind.example.com"+RecoverPath+
+		"?url=https://example.com&id=body")
+	g.Expect(err).To(Succeed())
+	g.Expect(code).To(Equal(http.StatusForbidden))
+
+	g.Eventually(func() error {
+		_, err := os.Stat(filepath.Join(cfg.StateDir, "notified"))
+		return err
+	}, 2*time.Second, 50*time.Millisecond).Should(Succeed())
+}
+
+func TestService_recoverDisabled(t *testing.T) {
+	g := NewWithT(t)
+
+	cfg := config.NewConfig()
+	cfg.StateDir = t.TempDir()
+	srv := NewService(slog.New(slog.NewTextHandler(os.Stdout, nil)), cfg,
+		editor.NewFakeEditor(nil), nil)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI(RecoverPath + "?url=https://example.com&id=body")
+	ctx.Request.SetHost("127.0.0.1:8928")
+	srv.RequestHandler()(ctx)
+	g.Expect(ctx.Response.StatusCode()).To(Equal(http.StatusNotFound))
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+)
+
+// errorHandler handles the errors on reading the request, before it reaches the
+// router, replying with a clear message for requests exceeding the limits.
+func (s *Service) errorHandler(ctx *fasthttp.RequestCtx, err error) {
+	var smallBuffer *fasthttp.ErrSmallBuffer
+	var netErr interface{ Timeout() bool }
+	switch {
+	case errors.Is(err, fasthttp.ErrBodyTooLarge):
+		s.logger.Warn("request body is too large",
+			"remote", ctx.RemoteAddr().String(), "limit", s.cfg.MaxBodySize)
+		ctx.Error(fmt.Sprintf("request body is too large, limit is %d bytes",
+			s.cfg.MaxBodySize), http.StatusRequestEntityTooLarge)
+		s.reject(ctx, "body_too_large")
+	case errors.As(err, &smallBuffer):
+		s.logger.Warn("request header is too large",
+			"remote", ctx.RemoteAddr().String(), "limit", s.cfg.MaxHeaderSize)
+		ctx.Error(fmt.Sprintf("request header is too large, limit is %d bytes",
+			s.cfg.MaxHeaderSize), http.StatusRequestHeaderFieldsTooLarge)
+		s.reject(ctx, "header_too_large")
+	case errors.As(err, &netErr) && netErr.Timeout():
+		ctx.Error("request timeout", http.StatusRequestTimeout)
+		s.reject(ctx, "timeout")
+	default:
+		ctx.Error(fmt.Sprintf("error parsing request: %s", err),
+			http.StatusBadRequest)
+		s.reject(ctx, "bad_request")
+	}
+}
+
+// Server instantiates the HTTP server with the request handler, and the request
+// size limits and timeouts from the configuration.
+func (s *Service) Server() *fasthttp.Server {
+	return &fasthttp.Server{
+		Handler:            s.RequestHandler(),
+		ErrorHandler:       s.errorHandler,
+		Name:               "edsrv",
+		MaxRequestBodySize: s.cfg.MaxBodySize,
+		ReadBufferSize:     s.cfg.MaxHeaderSize,
+		ReadTimeout:        s.cfg.ReadTimeout,
+		WriteTimeout:       s.cfg.WriteTimeout,
+		IdleTimeout:        s.cfg.IdleTimeout,
+	}
+}
+
+// MetricsServer instantiates the HTTP server for the metrics endpoint, with the
+// same timeouts.
+func (s *Service) MetricsServer() *fasthttp.Server {
+	return &fasthttp.Server{
+		Handler:      s.MetricsHandler(),
+		Name:         "edsrv",
+		ReadTimeout:  s.cfg.ReadTimeout,
+		WriteTimeout: s.cfg.WriteTimeout,
+		IdleTimeout:  s.cfg.IdleTimeout,
+	}
+}
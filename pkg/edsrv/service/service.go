@@ -1,11 +1,25 @@
 package service
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/otaviof/edsrv/pkg/edsrv/audit"
+	"github.com/otaviof/edsrv/pkg/edsrv/codec"
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
+	"github.com/otaviof/edsrv/pkg/edsrv/crypt"
 	"github.com/otaviof/edsrv/pkg/edsrv/editor"
+	"github.com/otaviof/edsrv/pkg/edsrv/file"
+	"github.com/otaviof/edsrv/pkg/edsrv/filter"
+	"github.com/otaviof/edsrv/pkg/edsrv/history"
+	"github.com/otaviof/edsrv/pkg/edsrv/markdown"
+	"github.com/otaviof/edsrv/pkg/edsrv/templates"
+	"github.com/otaviof/edsrv/pkg/edsrv/tracing"
 
 	"github.com/fasthttp/router"
 	"github.com/valyala/fasthttp"
@@ -15,7 +29,19 @@ import (
 // the supported endpoints effectively exposing the application features.
 type Service struct {
 	logger *slog.Logger     // shared logger instance
+	cfg    *config.Config   // shared configuration
 	ed     editor.Interface // editor instance
+	codec  *codec.Codec     // payload encoding and line ending codec
+	tmpls  *templates.Store // templates for empty fields
+	hist   *history.Store   // edit history, optional
+
+	recovery *history.Store  // undelivered edits
+	metrics  *serviceMetrics // prometheus metrics
+	audit    *audit.Log      // audit log, optional
+	tracer   *tracing.Tracer // request tracing, optional
+
+	started   time.Time // service start, for the uptime
+	encrypted bool      // the stored content is encrypted
 }
 
 const (
@@ -26,44 +52,221 @@ const (
 
 	// textPlain text-plain header.
 	textPlain = "text/plain"
+	// applicationJSON JSON content-type header.
+	applicationJSON = "application/json"
 )
 
 // status handles the requests for the "/status" endpoint, the response is based
-// on local service configuration attributes.
+// on local service configuration attributes. The response is JSON when the client
+// accepts it, otherwise plain text.
 func (s *Service) status(ctx *fasthttp.RequestCtx) {
+	st := s.newStatusResponse()
+	if acceptsJSON(ctx) {
+		body, err := json.Marshal(st)
+		if err != nil {
+			ctx.Error(err.Error(), http.StatusInternalServerError)
+			return
+		}
+		ctx.SetContentType(applicationJSON)
+		ctx.SetBody(body)
+		ctx.SetStatusCode(http.StatusOK)
+		s.logger.Debug("edit-server is running!", "endpoint", StatusPath)
+		return
+	}
 	ctx.SetContentType(textPlain)
 	ctx.SetBodyString(fmt.Sprintf(
-		"editor='%s', tmpDir='%s'", s.ed.GetCommand(), s.ed.GetTmpDir(),
+		"version='%s', uptime='%s', editor='%s', tmpDir='%s', "+
+			"maxBodySize='%d', maxHeaderSize='%d', "+
+			"readTimeout='%s', writeTimeout='%s', idleTimeout='%s'",
+		st.Version.Version, st.Uptime, st.Editor.Command, st.TmpDir,
+		s.cfg.MaxBodySize, s.cfg.MaxHeaderSize,
+		s.cfg.ReadTimeout, s.cfg.WriteTimeout, s.cfg.IdleTimeout,
 	))
 	ctx.SetStatusCode(http.StatusOK)
 
 	s.logger.Debug("edit-server is running!", "endpoint", StatusPath)
 }
 
+// isHTMLPayload asserts the payload is HTML, based on the format setting or the
+// request content-type.
+func isHTMLPayload(conv *codec.Conventions, settings *config.Settings) bool {
+	switch settings.Format {
+	case config.FormatHTML:
+		return true
+	case config.FormatText:
+		return false
+	default:
+		return conv.MediaType == codec.TextHTML
+	}
+}
+
+// template renders the template for a empty field, selected by the settings or
+// named after the field ID. Returns nil when no template applies.
+func (s *Service) template(
+	logger *slog.Logger,
+	meta *config.Metadata,
+	settings *config.Settings,
+) []byte {
+	name := settings.Template
+	if name == "" && s.tmpls.Exists(meta.ID) {
+		name = meta.ID
+	}
+	if name == "" {
+		return nil
+	}
+	logger = logger.With("template", name)
+	payload, err := s.tmpls.Render(name, templates.NewVars(meta.URL, meta.Title, meta.ID))
+	if err != nil {
+		logger.Error(err.Error())
+		return nil
+	}
+	logger.Debug("rendered template for empty field")
+	return payload
+}
+
+// record records the edit on the history, when enabled. The edited text is nil
+// when the edit failed or is aborted.
+func (s *Service) record(
+	logger *slog.Logger,
+	meta *config.Metadata,
+	started time.Time,
+	result string,
+	original, edited []byte,
+	editErr error,
+) {
+	if s.hist == nil {
+		return
+	}
+	e := &history.Entry{
+		URL:        meta.URL,
+		Title:      meta.Title,
+		FieldID:    meta.ID,
+		Editor:     s.ed.GetCommand(),
+		ExitStatus: editor.ExitStatus(editErr),
+		Result:     result,
+		Started:    started,
+		Finished:   time.Now(),
+	}
+	if editErr != nil {
+		e.Error = editErr.Error()
+	}
+	if err := s.hist.Save(e, original, edited); err != nil {
+		logger.Error("unable to record history", "err", err.Error())
+		return
+	}
+	logger.Debug("edit recorded on history", "history", e.ID)
+}
+
+// editError responds the edit error message with the result header.
+func editError(
+	ctx *fasthttp.RequestCtx,
+	result string,
+	err error,
+	statusCode int,
+) {
+	ctx.Error(err.Error(), statusCode)
+	ctx.Response.Header.Set(ResultHeader, result)
+}
+
 // edit handles the requests for the "/" endpoint, the response is based on the
 // Editor outcomes. The request body is informed for new file content, while the
 // response body uses the final edited file payload.
 func (s *Service) edit(ctx *fasthttp.RequestCtx) {
+	started := time.Now()
 	body := ctx.Request.Body()
-	logger := s.logger.With("endpoint", RootPath, "length", len(body))
+	meta := metadataFromRequest(ctx)
+	s.metrics.active.Inc()
+	s.metrics.requestSize.Observe(float64(len(body)))
+	logger := s.logger.With(
+		"endpoint", RootPath, "length", len(body), "url", meta.URL, "id", meta.ID,
+	)
+	// the audit log only records the payload hashes, never the content
+	rec := newAuditRecord(ctx, audit.EventEdit, started)
+	rec.OriginalHash = audit.Hash(body)
+	defer func() {
+		s.metrics.active.Dec()
+		s.metrics.observeEdit(ctx, started)
+		s.writeAudit(logger, ctx, rec)
+	}()
+	settings, err := s.cfg.SettingsFor(meta)
+	if err != nil {
+		logger.Error(err.Error())
+		editError(ctx, ResultFailed, err, http.StatusBadRequest)
+		return
+	}
+	if settings.Profile != "" {
+		logger = logger.With("profile", settings.Profile)
+	}
+	rec.Profile = settings.Profile
 
-	f, err := s.ed.Edit(body)
+	contentType := string(ctx.Request.Header.ContentType())
+	conv, body, err := s.codec.Decode(contentType, body)
 	if err != nil {
 		logger.Error(err.Error())
-		ctx.Error(err.Error(), http.StatusInternalServerError)
+		editError(ctx, ResultFailed, err, http.StatusBadRequest)
 		return
 	}
+	logger = logger.With(
+		"charset", conv.Charset, "bom", conv.BOM, "line-ending", conv.LineEnding,
+	)
+	// the client's text, compared with the edited text for the diff response
+	original := body
 
-	logger = f.LoggerWith(logger)
-	payload, err := f.Read()
+	// rich-text payload is edited as markdown, and rendered back as html
+	isHTML := isHTMLPayload(conv, settings)
+	if isHTML {
+		conv.MediaType = codec.TextHTML
+		if body, err = markdown.FromHTML(body); err != nil {
+			logger.Error(err.Error())
+			editError(ctx, ResultFailed, err, http.StatusBadRequest)
+			return
+		}
+		logger = logger.With("format", config.FormatHTML)
+	}
+	// the client's text on the edited format, before templates and filters
+	sent := body
+
+	if len(body) == 0 {
+		if payload := s.template(logger, meta, settings); payload != nil {
+			body = payload
+		}
+	}
+
+	// on filter failure the unfiltered text is used, and the client is informed
+	filterErrs := []string{}
+	tctx := traceContext(ctx)
+	filtered, err := filter.Run(tctx, logger,
+		settings.PreFilters, s.cfg.FilterTimeout, body)
+	if err != nil {
+		filterErrs = append(filterErrs, err.Error())
+	} else {
+		body = filtered
+	}
+
+	// the editor exit status tells apart the aborted from the failed edits
+	f, err := s.ed.Edit(tctx, body, file.Options{
+		Storage:   settings.Storage,
+		Extension: settings.Extension,
+	})
 	if err != nil {
+		status := editor.ExitStatus(err)
+		if status < 0 {
+			s.metrics.spawnFailures.Inc()
+		}
+		if s.cfg.IsAbortCode(status) {
+			s.record(logger, meta, started, ResultAborted, sent, nil, err)
+			logger.Info("edit aborted", "exit-status", status)
+			editError(ctx, ResultAborted, err, http.StatusConflict)
+			return
+		}
+		s.record(logger, meta, started, ResultFailed, sent, nil, err)
 		logger.Error(err.Error())
-		ctx.Error(err.Error(), http.StatusInternalServerError)
+		editError(ctx, ResultFailed, err, http.StatusInternalServerError)
 		return
 	}
-	logger = logger.With("written", len(payload))
-	logger.Debug("reading edited file")
 
+	logger = f.LoggerWith(logger)
+	rec.File = f.Name()
 	defer func() {
 		if err := f.Remove(); err != nil {
 			logger.Error(err.Error())
@@ -72,23 +275,148 @@ func (s *Service) edit(ctx *fasthttp.RequestCtx) {
 		logger.Debug("temporary file removed")
 	}()
 
+	changed, err := f.Changed()
+	if err != nil {
+		logger.Error(err.Error())
+		editError(ctx, ResultFailed, err, http.StatusInternalServerError)
+		return
+	}
+	// templates and pre-filters change the client's text, saving without edits
+	// still returns it
+	if !changed && bytes.Equal(body, sent) {
+		s.record(logger, meta, started, ResultUnchanged, sent, body, nil)
+		rec.EditedHash = rec.OriginalHash
+		logger.Info("edit unchanged")
+		ctx.Response.Header.Set(ResultHeader, ResultUnchanged)
+		ctx.SetStatusCode(http.StatusNoContent)
+		return
+	}
+
+	_, span := tracing.Start(tctx, "read edited file")
+	payload, err := f.Read()
+	span.SetAttr("file.size", len(payload))
+	span.RecordError(err)
+	span.End()
+	if err != nil {
+		logger.Error(err.Error())
+		editError(ctx, ResultFailed, err, http.StatusInternalServerError)
+		return
+	}
+	logger = logger.With("written", len(payload))
+	logger.Debug("reading edited file")
+
+	filtered, err = filter.Run(tctx, logger,
+		settings.PostFilters, s.cfg.FilterTimeout, payload)
+	if err != nil {
+		filterErrs = append(filterErrs, err.Error())
+	} else {
+		payload = filtered
+	}
+
+	payload = codec.ApplyWhitespace(sent, payload,
+		settings.TrailingNewline, settings.TrimTrailingWhitespace)
+	s.record(logger, meta, started, ResultChanged, sent, payload, nil)
+
+	if isHTML {
+		if payload, err = markdown.ToHTML(payload); err != nil {
+			logger.Error(err.Error())
+			editError(ctx, ResultFailed, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// the editor may take long, when the client is gone the edit is recovered
+	edited := payload
+	undelivered := func() {
+		s.recover(logger, meta, started, sent, edited)
+	}
+
+	ctx.Response.Header.Set(ResultHeader, ResultChanged)
+	if len(filterErrs) > 0 {
+		ctx.Response.Header.Set(FilterErrorHeader,
+			strings.Join(strings.Fields(strings.Join(filterErrs, "; ")), " "))
+	}
+	if diffRequested(ctx) {
+		restored := s.codec.Restore(conv, payload)
+		rec.EditedHash = audit.Hash(restored)
+		err = respondDiff(ctx, s.codec.Restore(conv, original), restored)
+		if err != nil {
+			logger.Error(err.Error())
+			editError(ctx, ResultFailed, err, http.StatusInternalServerError)
+			return
+		}
+		deliver(ctx, undelivered)
+		logger.Debug("all done, diff response!")
+		return
+	}
+
+	payload, contentType = s.codec.Encode(conv, payload)
+	rec.EditedHash = audit.Hash(payload)
+	ctx.SetContentType(contentType)
 	ctx.SetBody(payload)
 	ctx.SetStatusCode(http.StatusOK)
+	deliver(ctx, undelivered)
 	logger.Debug("all done!")
 }
 
+// allowOrigin asserts the request origin is allowed, otherwise the request is
+// rejected with forbidden status.
+func (s *Service) allowOrigin(ctx *fasthttp.RequestCtx) bool {
+	origin := string(ctx.Request.Header.Peek(fasthttp.HeaderOrigin))
+	if s.cfg.OriginAllowed(origin) {
+		return true
+	}
+	s.logger.Warn("rejecting request origin",
+		"origin", origin, "remote", ctx.RemoteAddr().String())
+	ctx.Error("request origin is not allowed", http.StatusForbidden)
+	s.reject(ctx, "origin")
+	return false
+}
+
 // RequestHandler instantiates the request router for the application endpoints.
 func (s *Service) RequestHandler() fasthttp.RequestHandler {
 	r := router.New()
 	r.GET(StatusPath, s.status)
+	if s.cfg.RecoverEndpoint {
+		r.GET(RecoverPath, s.recovered)
+	}
 	r.POST(RootPath, s.edit)
-	return r.Handler
+	return func(ctx *fasthttp.RequestCtx) {
+		span := s.startSpan(ctx)
+		if s.allowOrigin(ctx) {
+			r.Handler(ctx)
+		}
+		s.metrics.observeRequest(ctx)
+		endSpan(ctx, span)
+	}
 }
 
-// NewService returns a new service using a shared logger and editor instances.
-func NewService(logger *slog.Logger, ed editor.Interface) *Service {
-	return &Service{
+// NewService returns a new service using a shared logger, configuration and
+// editor instances. When the cipher is set the stored content is encrypted.
+func NewService(
+	logger *slog.Logger,
+	cfg *config.Config,
+	ed editor.Interface,
+	c *crypt.Cipher,
+) *Service {
+	s := &Service{
 		logger: logger,
+		cfg:    cfg,
 		ed:     ed,
+		codec:  codec.NewCodec(cfg.LineEnding, cfg.NFC),
+		tmpls:  templates.NewStore(cfg.TemplatesDir),
+
+		recovery: history.NewStore(cfg.RecoverDir(), cfg.RecoverRetention(), c),
+		metrics:  newServiceMetrics(),
+
+		started:   time.Now(),
+		encrypted: c != nil,
+	}
+	if cfg.History {
+		s.hist = history.NewStore(cfg.HistoryDir(), cfg.HistoryRetention(), c)
+	}
+	if cfg.Audit {
+		s.audit = audit.NewLog(logger, cfg.AuditFile())
 	}
+	return s
 }
@@ -1,13 +1,24 @@
 package service
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/otaviof/edsrv/pkg/edsrv/audit"
+	"github.com/otaviof/edsrv/pkg/edsrv/config"
 	"github.com/otaviof/edsrv/pkg/edsrv/editor"
+	"github.com/otaviof/edsrv/pkg/edsrv/history"
+	"github.com/otaviof/edsrv/pkg/edsrv/tracing"
+	"github.com/otaviof/edsrv/pkg/edsrv/version"
 	"github.com/otaviof/edsrv/test/helper"
 
 	"github.com/valyala/fasthttp"
@@ -26,12 +37,16 @@ func TestService(t *testing.T) {
 
 	payload := []byte("edited payload")
 	ed := editor.NewFakeEditor(payload)
-	srv := NewService(logger, ed)
+	cfg := config.NewConfig()
+	cfg.StateDir = t.TempDir()
+	cfg.MaxBodySize = 1024
+	cfg.Audit = true
+	srv := NewService(logger, cfg, ed, nil)
 
 	ln := fasthttputil.NewInmemoryListener()
 	ch := make(chan struct{})
 	go func() {
-		if err := fasthttp.Serve(ln, srv.RequestHandler()); err != nil {
+		if err := srv.Server().Serve(ln); err != nil {
 			t.Errorf(err.Error())
 		}
 		close(ch)
@@ -45,12 +60,25 @@ func TestService(t *testing.T) {
 	}
 
 	t.Run(StatusPath, func(_ *testing.T) {
-		resBody, err := StatusRequest(logger, c)
+		resBody, err := StatusRequest(logger, c, "")
 		g.Expect(err).To(Succeed())
 		g.Expect(string(resBody)).To(ContainSubstring(ed.GetCommand()))
 		g.Expect(string(resBody)).To(ContainSubstring(ed.GetTmpDir()))
+		g.Expect(string(resBody)).To(ContainSubstring("maxBodySize='1024'"))
 
 		t.Logf("status request body %q", resBody)
+
+		resBody, err = StatusRequest(logger, c, applicationJSON)
+		g.Expect(err).To(Succeed())
+		st := &StatusResponse{}
+		g.Expect(json.Unmarshal(resBody, st)).To(Succeed())
+		g.Expect(st.Version.Version).To(Equal(version.Version))
+		g.Expect(st.Editor.Command).To(Equal(ed.GetCommand()))
+		g.Expect(st.TmpDir).To(Equal(ed.GetTmpDir()))
+		g.Expect(st.Listeners.Addr).To(Equal(cfg.Addr))
+		g.Expect(st.ActiveSessions).To(BeZero())
+		g.Expect(st.Features).To(HaveKeyWithValue("audit", true))
+		g.Expect(st.Features).To(HaveKeyWithValue("history", false))
 	})
 
 	t.Run(RootPath, func(_ *testing.T) {
@@ -59,6 +87,60 @@ func TestService(t *testing.T) {
 		g.Expect(resBody).To(Equal(payload))
 	})
 
+	t.Run("diff response", func(_ *testing.T) {
+		req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseRequest(req)
+		defer fasthttp.ReleaseResponse(res)
+		req.SetRequestURI("http://" + c.Addr)
+		req.Header.SetMethod(fasthttp.MethodPost)
+		req.Header.Set(DiffHeader, "true")
+		req.SetBody([]byte("initial input..."))
+
+		g.Expect(c.DoTimeout(req, res, 5*time.Second)).To(Succeed())
+		g.Expect(res.StatusCode()).To(Equal(http.StatusOK))
+		g.Expect(string(res.Header.Peek(DiffSummaryHeader))).To(Equal("+1 -1"))
+
+		diffRes := &DiffResponse{}
+		g.Expect(json.Unmarshal(res.Body(), diffRes)).To(Succeed())
+		g.Expect(diffRes.Text).To(Equal(string(payload)))
+		g.Expect(diffRes.Diff).To(ContainSubstring("+edited payload"))
+		g.Expect(diffRes.Ranges).ToNot(BeEmpty())
+	})
+
+	t.Run("invalid extension hint", func(_ *testing.T) {
+		req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseRequest(req)
+		defer fasthttp.ReleaseResponse(res)
+		req.SetRequestURI("http://" + c.Addr)
+		req.Header.SetMethod(fasthttp.MethodPost)
+		req.Header.Set(ExtHeader, "../md")
+		req.SetBody([]byte("initial input..."))
+
+		g.Expect(c.DoTimeout(req, res, 5*time.Second)).To(Succeed())
+		g.Expect(res.StatusCode()).To(Equal(http.StatusBadRequest))
+		g.Expect(string(res.Header.Peek(ResultHeader))).To(Equal(ResultFailed))
+	})
+
+	t.Run("request body too large", func(_ *testing.T) {
+		req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseRequest(req)
+		defer fasthttp.ReleaseResponse(res)
+		req.SetRequestURI("http://" + c.Addr)
+		req.Header.SetMethod(fasthttp.MethodPost)
+		req.SetBody(bytes.Repeat([]byte("a"), 2048))
+
+		g.Expect(c.DoTimeout(req, res, 5*time.Second)).To(Succeed())
+		g.Expect(res.StatusCode()).To(Equal(http.StatusRequestEntityTooLarge))
+		g.Expect(string(res.Body())).To(ContainSubstring("limit is 1024 bytes"))
+
+		records, err := srv.audit.Tail(&audit.Filter{}, 1)
+		g.Expect(err).To(Succeed())
+		g.Expect(records).To(HaveLen(1))
+		g.Expect(records[0].Event).To(Equal(audit.EventRejected))
+		g.Expect(records[0].Reason).To(Equal("body_too_large"))
+		g.Expect(records[0].Status).To(Equal(http.StatusRequestEntityTooLarge))
+	})
+
 	ln.Close()
 	select {
 	case <-ch:
@@ -66,3 +148,247 @@ func TestService(t *testing.T) {
 		t.Fatalf("server still running")
 	}
 }
+
+func TestService_Results(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(
+		os.Stdout,
+		&slog.HandlerOptions{Level: slog.LevelDebug},
+	))
+
+	tests := []struct {
+		name           string
+		ed             *editor.FakeEditor
+		body           string
+		wantStatusCode int
+		wantResult     string
+	}{{
+		name:           "changed",
+		ed:             editor.NewFakeEditor([]byte("edited")),
+		body:           "original",
+		wantStatusCode: http.StatusOK,
+		wantResult:     ResultChanged,
+	}, {
+		name:           "unchanged",
+		ed:             editor.NewFakeEditor([]byte("original")),
+		body:           "original",
+		wantStatusCode: http.StatusNoContent,
+		wantResult:     ResultUnchanged,
+	}, {
+		name: "aborted",
+		ed: editor.NewFakeEditor(nil).
+			WithError(&editor.ExitError{Status: 1}),
+		body:           "original",
+		wantStatusCode: http.StatusConflict,
+		wantResult:     ResultAborted,
+	}, {
+		name: "failed",
+		ed: editor.NewFakeEditor(nil).
+			WithError(&editor.ExitError{Status: 2}),
+		body:           "original",
+		wantStatusCode: http.StatusInternalServerError,
+		wantResult:     ResultFailed,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cfg := config.NewConfig()
+			cfg.StateDir = t.TempDir()
+			cfg.History = true
+			cfg.Audit = true
+			srv := NewService(logger, cfg, tt.ed, nil)
+
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+			ctx.Request.Header.Set(fasthttp.HeaderOrigin, "moz-extension://edsrv")
+			ctx.Request.SetRequestURI(RootPath)
+			ctx.Request.SetBodyString(tt.body)
+			srv.RequestHandler()(ctx)
+
+			g.Expect(ctx.Response.StatusCode()).To(Equal(tt.wantStatusCode))
+			g.Expect(string(ctx.Response.Header.Peek(ResultHeader))).
+				To(Equal(tt.wantResult))
+
+			entries, err := srv.hist.List()
+			g.Expect(err).To(Succeed())
+			g.Expect(entries).To(HaveLen(1))
+			g.Expect(entries[0].Result).To(Equal(tt.wantResult))
+
+			records, err := srv.audit.Tail(&audit.Filter{}, 10)
+			g.Expect(err).To(Succeed())
+			g.Expect(records).To(HaveLen(1))
+			g.Expect(records[0].Event).To(Equal(audit.EventEdit))
+			g.Expect(records[0].Origin).To(Equal("moz-extension://edsrv"))
+			g.Expect(records[0].Status).To(Equal(tt.wantStatusCode))
+			g.Expect(records[0].Result).To(Equal(tt.wantResult))
+			g.Expect(records[0].OriginalHash).
+				To(Equal(audit.Hash([]byte(tt.body))))
+
+			metricsCtx := &fasthttp.RequestCtx{}
+			metricsCtx.Request.SetRequestURI(MetricsPath)
+			srv.MetricsHandler()(metricsCtx)
+			g.Expect(metricsCtx.Response.StatusCode()).To(Equal(http.StatusOK))
+			g.Expect(string(metricsCtx.Response.Body())).To(ContainSubstring(
+				fmt.Sprintf("edsrv_edits_total{result=%q} 1", tt.wantResult)))
+			g.Expect(string(metricsCtx.Response.Body())).To(ContainSubstring(
+				fmt.Sprintf("edsrv_http_requests_total{route=\"/\",code=\"%d\"} 1",
+					tt.wantStatusCode)))
+			g.Expect(srv.metrics.active.Value()).To(BeZero())
+		})
+	}
+}
+
+func TestService_Whitespace(t *testing.T) {
+	g := NewWithT(t)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cfg := config.NewConfig()
+	cfg.StateDir = t.TempDir()
+	cfg.History = true
+	// the pre-filter adds the trailing newline the client didn't send
+	cfg.PreFilters = []string{"awk 1"}
+	srv := NewService(logger, cfg, editor.NewFakeEditor([]byte("edited\n")), nil)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.SetRequestURI(RootPath)
+	ctx.Request.SetBodyString("original")
+	srv.RequestHandler()(ctx)
+	g.Expect(ctx.Response.StatusCode()).To(Equal(http.StatusOK))
+	g.Expect(string(ctx.Response.Body())).To(Equal("edited"))
+
+	entries, err := srv.hist.List()
+	g.Expect(err).To(Succeed())
+	g.Expect(entries).To(HaveLen(1))
+	original, err := srv.hist.Read(entries[0].ID, history.Original)
+	g.Expect(err).To(Succeed())
+	g.Expect(string(original)).To(Equal("original"))
+}
+
+func TestService_Unchanged(t *testing.T) {
+	g := NewWithT(t)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cfg := config.NewConfig()
+	cfg.StateDir = t.TempDir()
+	cfg.PreFilters = []string{"tr a-z A-Z"}
+	// the editor saves the pre-filtered text without changes
+	srv := NewService(logger, cfg, editor.NewFakeEditor([]byte("HELLO")), nil)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.SetRequestURI(RootPath)
+	ctx.Request.SetBodyString("hello")
+	srv.RequestHandler()(ctx)
+	g.Expect(ctx.Response.StatusCode()).To(Equal(http.StatusOK))
+	g.Expect(string(ctx.Response.Header.Peek(ResultHeader))).
+		To(Equal(ResultChanged))
+	g.Expect(string(ctx.Response.Body())).To(Equal("HELLO"))
+}
+
+func TestService_Origin(t *testing.T) {
+	g := NewWithT(t)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cfg := config.NewConfig()
+	cfg.StateDir = t.TempDir()
+	cfg.Audit = true
+	cfg.AllowedOrigins = []string{"moz-extension://*"}
+	srv := NewService(logger, cfg, editor.NewFakeEditor([]byte("edited")), nil)
+
+	origins := []string{"https://evil.example", "moz-extension://edsrv", ""}
+	for _, origin := range origins {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+		if origin != "" {
+			ctx.Request.Header.Set(fasthttp.HeaderOrigin, origin)
+		}
+		ctx.Request.SetRequestURI(RootPath)
+		ctx.Request.SetBodyString("original")
+		srv.RequestHandler()(ctx)
+
+		want := http.StatusOK
+		if origin == "https://evil.example" {
+			want = http.StatusForbidden
+		}
+		g.Expect(ctx.Response.StatusCode()).To(Equal(want), origin)
+	}
+
+	records, err := srv.audit.Tail(&audit.Filter{}, 10)
+	g.Expect(err).To(Succeed())
+	g.Expect(records).To(HaveLen(3))
+	g.Expect(records[0].Event).To(Equal(audit.EventRejected))
+	g.Expect(records[0].Reason).To(Equal("origin"))
+	g.Expect(records[0].Origin).To(Equal("https://evil.example"))
+	g.Expect(records[0].Status).To(Equal(http.StatusForbidden))
+	g.Expect(records[1].Event).To(Equal(audit.EventEdit))
+
+	metricsCtx := &fasthttp.RequestCtx{}
+	metricsCtx.Request.SetRequestURI(MetricsPath)
+	srv.MetricsHandler()(metricsCtx)
+	g.Expect(string(metricsCtx.Response.Body())).To(ContainSubstring(
+		`edsrv_requests_rejected_total{reason="origin"} 1`))
+}
+
+func TestService_Tracing(t *testing.T) {
+	g := NewWithT(t)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	// collector stand-in, keeps the exported span names
+	var m sync.Mutex
+	spans := map[string]map[string]any{}
+	collector := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		req := struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []map[string]any `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}{}
+		g.Expect(json.NewDecoder(r.Body).Decode(&req)).To(Succeed())
+		m.Lock()
+		defer m.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					spans[s["name"].(string)] = s
+				}
+			}
+		}
+	}))
+	defer collector.Close()
+
+	url, err := tracing.TracesURL(collector.URL)
+	g.Expect(err).To(Succeed())
+	exporter := tracing.NewOTLPExporter(logger, url)
+
+	cfg := config.NewConfig()
+	cfg.StateDir = t.TempDir()
+	cfg.PreFilters = []string{"cat"}
+	srv := NewService(logger, cfg, editor.NewFakeEditor([]byte("edited")), nil).
+		WithTracer(tracing.NewTracer(exporter))
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.Header.Set(tracing.TraceparentHeader, traceparent)
+	ctx.Request.SetRequestURI(RootPath)
+	ctx.Request.SetBodyString("original")
+	srv.RequestHandler()(ctx)
+	g.Expect(ctx.Response.StatusCode()).To(Equal(http.StatusOK))
+
+	exporter.Shutdown()
+
+	m.Lock()
+	defer m.Unlock()
+	g.Expect(spans).To(HaveKey("POST /"))
+	g.Expect(spans).To(HaveKey("filter"))
+	g.Expect(spans).To(HaveKey("read edited file"))
+	root := spans["POST /"]
+	g.Expect(root["traceId"]).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+	g.Expect(root["parentSpanId"]).To(Equal("00f067aa0ba902b7"))
+	g.Expect(spans["filter"]["parentSpanId"]).To(Equal(root["spanId"]))
+	g.Expect(spans["read edited file"]["traceId"]).To(Equal(root["traceId"]))
+}
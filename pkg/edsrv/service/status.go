@@ -6,18 +6,86 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
+	"github.com/otaviof/edsrv/pkg/edsrv/version"
+
 	"github.com/valyala/fasthttp"
 )
 
 // ErrNonSuccessfulStatusCode any other status-code than 200.
 var ErrNonSuccessfulStatusCode = errors.New("non-successful status-code")
 
+// StatusResponse represents the "/status" JSON response.
+type StatusResponse struct {
+	Version        version.Info    `json:"version"`        // build information
+	Started        time.Time       `json:"started"`        // service start
+	Uptime         string          `json:"uptime"`         // time since start
+	UptimeSeconds  int64           `json:"uptimeSeconds"`  // time since start
+	Editor         StatusEditor    `json:"editor"`         // editor in use
+	TmpDir         string          `json:"tmpDir"`         // temporary directory
+	Listeners      StatusListeners `json:"listeners"`      // listen addresses
+	ActiveSessions int             `json:"activeSessions"` // edits in progress
+	Features       map[string]bool `json:"features"`       // optional features
+}
+
+// StatusEditor the editor command and its resolved path.
+type StatusEditor struct {
+	Command string `json:"command"`        // editor command
+	Path    string `json:"path,omitempty"` // resolved executable path
+}
+
+// StatusListeners the service listen addresses.
+type StatusListeners struct {
+	Addr        string `json:"addr"`                  // edit endpoint
+	MetricsAddr string `json:"metricsAddr,omitempty"` // metrics endpoint
+}
+
+// newStatusResponse instantiates the status response for the current state.
+func (s *Service) newStatusResponse() *StatusResponse {
+	uptime := time.Since(s.started).Round(time.Second)
+	st := &StatusResponse{
+		Version:        version.Get(),
+		Started:        s.started,
+		Uptime:         uptime.String(),
+		UptimeSeconds:  int64(uptime.Seconds()),
+		Editor:         StatusEditor{Command: s.ed.GetCommand()},
+		TmpDir:         s.ed.GetTmpDir(),
+		Listeners:      StatusListeners{Addr: s.cfg.Addr, MetricsAddr: s.cfg.MetricsAddr},
+		ActiveSessions: int(s.metrics.active.Value()),
+		Features: map[string]bool{
+			"history":     s.hist != nil,
+			"audit":       s.audit != nil,
+			"encryption":  s.encrypted,
+			"metrics":     s.cfg.MetricsAddr != "",
+			"tracing":     s.tracer != nil,
+			"nvim":        s.cfg.Nvim,
+			"recoverHook": s.cfg.RecoverHook != "",
+			"recover":     s.cfg.RecoverEndpoint,
+		},
+	}
+	if p, ok := s.ed.(interface{ GetPath() string }); ok {
+		st.Editor.Path = p.GetPath()
+	}
+	return st
+}
+
+// acceptsJSON asserts the client accepts JSON responses.
+func acceptsJSON(ctx *fasthttp.RequestCtx) bool {
+	return strings.Contains(string(ctx.Request.Header.Peek(fasthttp.HeaderAccept)),
+		applicationJSON)
+}
+
 // StatusRequest executes a GET request on the edit-server status path, using the
-// informed client address ("Addr" attribute) to create the request URI. Returns
-// the response body and error when applicable.
-func StatusRequest(logger *slog.Logger, c *fasthttp.HostClient) ([]byte, error) {
+// informed client address ("Addr" attribute) to create the request URI. The
+// accept header informs the response format. Returns the response body and
+// error when applicable.
+func StatusRequest(
+	logger *slog.Logger,
+	c *fasthttp.HostClient,
+	accept string,
+) ([]byte, error) {
 	statusURI, err := url.JoinPath("http://", c.Addr, StatusPath)
 	if err != nil {
 		return nil, err
@@ -27,6 +95,9 @@ func StatusRequest(logger *slog.Logger, c *fasthttp.HostClient) ([]byte, error)
 	req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
 	req.SetRequestURI(statusURI)
 	req.Header.SetMethod(fasthttp.MethodGet)
+	if accept != "" {
+		req.Header.Set(fasthttp.HeaderAccept, accept)
+	}
 
 	err = c.DoTimeout(req, res, 5*time.Second)
 	fasthttp.ReleaseRequest(req)
@@ -38,5 +109,5 @@ func StatusRequest(logger *slog.Logger, c *fasthttp.HostClient) ([]byte, error)
 		return nil, fmt.Errorf("%w: %d",
 			ErrNonSuccessfulStatusCode, res.StatusCode())
 	}
-	return res.Body(), nil
+	return append([]byte{}, res.Body()...), nil
 }
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/otaviof/edsrv/pkg/edsrv/tracing"
+
+	"github.com/valyala/fasthttp"
+)
+
+// traceContextKey request user value key for the tracing context.
+const traceContextKey = "edsrv-trace-context"
+
+// startSpan starts the request span, child of the "traceparent" header when
+// informed, and keeps the tracing context on the request.
+func (s *Service) startSpan(ctx *fasthttp.RequestCtx) *tracing.Span {
+	tctx := context.Background()
+	if sc, ok := tracing.ParseTraceparent(
+		string(ctx.Request.Header.Peek(tracing.TraceparentHeader)),
+	); ok {
+		tctx = tracing.ContextWithRemoteParent(tctx, sc)
+	}
+	tctx, span := s.tracer.Start(tctx,
+		string(ctx.Method())+" "+route(ctx), tracing.SpanKindServer)
+	span.SetAttr("http.request.method", string(ctx.Method()))
+	span.SetAttr("http.route", route(ctx))
+	span.SetAttr("url.path", string(ctx.Path()))
+	span.SetAttr("user_agent.original", string(ctx.UserAgent()))
+	ctx.SetUserValue(traceContextKey, tctx)
+	return span
+}
+
+// endSpan ends the request span with the response attributes, server errors
+// are the span error status.
+func endSpan(ctx *fasthttp.RequestCtx, span *tracing.Span) {
+	code := ctx.Response.StatusCode()
+	span.SetAttr("http.response.status_code", code)
+	if result := ctx.Response.Header.Peek(ResultHeader); len(result) > 0 {
+		span.SetAttr("edsrv.result", string(result))
+	}
+	if code >= http.StatusInternalServerError {
+		span.RecordError(statusError(code))
+	}
+	span.End()
+}
+
+// statusError the HTTP status code as error.
+type statusError int
+
+func (e statusError) Error() string {
+	return http.StatusText(int(e))
+}
+
+// traceContext returns the request tracing context.
+func traceContext(ctx *fasthttp.RequestCtx) context.Context {
+	if tctx, ok := ctx.UserValue(traceContextKey).(context.Context); ok {
+		return tctx
+	}
+	return context.Background()
+}
+
+// WithTracer traces the requests with the informed tracer.
+func (s *Service) WithTracer(t *tracing.Tracer) *Service {
+	s.tracer = t
+	return s
+}
@@ -0,0 +1,158 @@
+package templates
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Vars represents the variables available to the templates.
+type Vars struct {
+	URL      string    // page URL
+	Host     string    // page URL host
+	Title    string    // page title
+	ID       string    // field ID
+	Date     time.Time // current date and time
+	GitUser  string    // git "user.name"
+	GitEmail string    // git "user.email"
+}
+
+// Store represents the directory holding the templates, each template is a
+// "text/template" file named after the template with the ".tmpl" extension.
+type Store struct {
+	dir string // templates directory
+}
+
+// Extension templates file extension.
+const Extension = ".tmpl"
+
+// gitTimeout maximum time to read the git configuration.
+const gitTimeout = 2 * time.Second
+
+var (
+	// ErrTemplateNotFound the informed template is not found.
+	ErrTemplateNotFound = errors.New("template not found")
+	// ErrTemplateInvalid the template can't be parsed or executed.
+	ErrTemplateInvalid = errors.New("invalid template")
+)
+
+// GetDir exposes the templates directory.
+func (s *Store) GetDir() string {
+	return s.dir
+}
+
+// path returns the template file path, the name must not contain path elements.
+func (s *Store) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("%w: %q", ErrTemplateNotFound, name)
+	}
+	return filepath.Join(s.dir, name+Extension), nil
+}
+
+// Exists asserts the named template exists.
+func (s *Store) Exists(name string) bool {
+	p, err := s.path(name)
+	if err != nil {
+		return false
+	}
+	stat, err := os.Stat(p)
+	return err == nil && stat.Mode().IsRegular()
+}
+
+// List returns the template names, sorted. A missing directory has no templates.
+func (s *Store) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	names := []string{}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), Extension)
+		if !ok || e.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Render renders the named template with the informed variables.
+func (s *Store) Render(name string, vars *Vars) ([]byte, error) {
+	p, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %q", ErrTemplateNotFound, name)
+		}
+		return nil, err
+	}
+	t, err := template.New(name).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTemplateInvalid, err)
+	}
+	var b bytes.Buffer
+	if err = t.Execute(&b, vars); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTemplateInvalid, err)
+	}
+	return b.Bytes(), nil
+}
+
+// gitConfig returns the git configuration value, empty when not available.
+func gitConfig(key string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "git", "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// NewVars instantiates the template variables for the page URL, title and field
+// ID, using the current date and the git user configuration.
+func NewVars(pageURL, title, id string) *Vars {
+	v := &Vars{
+		URL:      pageURL,
+		Title:    title,
+		ID:       id,
+		Date:     time.Now(),
+		GitUser:  gitConfig("user.name"),
+		GitEmail: gitConfig("user.email"),
+	}
+	if u, err := url.Parse(pageURL); err == nil {
+		v.Host = u.Hostname()
+	}
+	return v
+}
+
+// DefaultDir returns the default templates directory, under the user
+// configuration directory ("$XDG_CONFIG_HOME").
+func DefaultDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "edsrv", "templates")
+}
+
+// NewStore instantiates the templates store on the informed directory.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
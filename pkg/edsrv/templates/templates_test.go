@@ -0,0 +1,75 @@
+package templates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"bug" + Extension:     "# {{ .Title }}\n\nReported on {{ .Host }} by {{ .GitUser }}, {{ .Date.Format \"2006-01-02\" }}\n",
+		"broken" + Extension:  "{{ .Missing }}",
+		"notes.txt":           "ignored",
+		".hidden" + Extension: "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("unable to write template: %v", err)
+		}
+	}
+	s := NewStore(dir)
+
+	names, err := s.List()
+	if err != nil {
+		t.Fatalf("Store.List() error = %v", err)
+	}
+	if want := []string{"broken", "bug"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Store.List() = %v, expected %v", names, want)
+	}
+
+	vars := &Vars{
+		Title:   "Crash",
+		Host:    "github.com",
+		GitUser: "Jane",
+		Date:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr error
+	}{{
+		name: "render",
+		tmpl: "bug",
+		want: "# Crash\n\nReported on github.com by Jane, 2024-05-01\n",
+	}, {
+		name:    "missing variable",
+		tmpl:    "broken",
+		wantErr: ErrTemplateInvalid,
+	}, {
+		name:    "not found",
+		tmpl:    "unknown",
+		wantErr: ErrTemplateNotFound,
+	}, {
+		name:    "path elements are refused",
+		tmpl:    "../bug",
+		wantErr: ErrTemplateNotFound,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Render(tt.tmpl, vars)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Store.Render() error = %v, expected %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Store.Render() = %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// TracesPath OTLP/HTTP traces path, appended to endpoints without path.
+	TracesPath = "/v1/traces"
+
+	// exportQueueSize spans waiting for export, newer spans are dropped when full.
+	exportQueueSize = 2048
+	// exportBatchSize maximum spans on a single export request.
+	exportBatchSize = 512
+	// exportInterval maximum delay for exporting the spans.
+	exportInterval = 5 * time.Second
+	// exportTimeout export request timeout.
+	exportTimeout = 10 * time.Second
+)
+
+// TracesURL returns the OTLP/HTTP traces URL for the endpoint, the traces path
+// is appended when the endpoint has no path.
+func TracesURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return "", fmt.Errorf("endpoint %q must be a http or https URL", endpoint)
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		u.Path = TracesPath
+	}
+	return u.String(), nil
+}
+
+// OTLPExporter exports the spans in batches over OTLP/HTTP, JSON encoded.
+type OTLPExporter struct {
+	logger   *slog.Logger     // shared logger instance
+	url      string           // traces URL
+	client   *fasthttp.Client // HTTP client
+	resource []Attr           // resource attributes, service name
+	spans    chan *Span       // spans waiting for export
+	done     chan struct{}    // closed on shutdown
+	wg       sync.WaitGroup   // export loop
+	once     sync.Once        // shutdown once
+}
+
+var _ Exporter = &OTLPExporter{}
+
+// Export queues the span, dropped when the queue is full.
+func (e *OTLPExporter) Export(s *Span) {
+	select {
+	case e.spans <- s:
+	default:
+		e.logger.Warn("tracing queue is full, dropping span", "span", s.name)
+	}
+}
+
+// loop exports the spans when the batch is full, on the interval, and the
+// remaining spans on shutdown.
+func (e *OTLPExporter) loop() {
+	defer e.wg.Done()
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, exportBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.send(batch); err != nil {
+			e.logger.Error("unable to export spans", "spans", len(batch),
+				"err", err.Error())
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case s := <-e.spans:
+			if batch = append(batch, s); len(batch) == exportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.done:
+			for {
+				select {
+				case s := <-e.spans:
+					if batch = append(batch, s); len(batch) == exportBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// send posts the spans as a OTLP export request.
+func (e *OTLPExporter) send(spans []*Span) error {
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return err
+	}
+	req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(res)
+	req.SetRequestURI(e.url)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.SetContentType("application/json")
+	req.SetBody(body)
+	if err = e.client.DoTimeout(req, res, exportTimeout); err != nil {
+		return err
+	}
+	if code := res.StatusCode(); code < 200 || code > 299 {
+		return fmt.Errorf("collector replied status code %d: %s", code, res.Body())
+	}
+	e.logger.Debug("spans exported", "spans", len(spans))
+	return nil
+}
+
+// request the OTLP export request, on the OTLP/JSON encoding.
+func (e *OTLPExporter) request(spans []*Span) map[string]any {
+	encoded := make([]map[string]any, 0, len(spans))
+	for _, s := range spans {
+		encoded = append(encoded, encodeSpan(s))
+	}
+	return map[string]any{
+		"resourceSpans": []map[string]any{{
+			"resource": map[string]any{"attributes": encodeAttrs(e.resource)},
+			"scopeSpans": []map[string]any{{
+				"scope": map[string]any{"name": "edsrv"},
+				"spans": encoded,
+			}},
+		}},
+	}
+}
+
+// encodeSpan encodes the span on OTLP/JSON, IDs are hex and timestamps are
+// nanoseconds as strings.
+func encodeSpan(s *Span) map[string]any {
+	s.m.Lock()
+	defer s.m.Unlock()
+	span := map[string]any{
+		"traceId":           s.sc.TraceID.String(),
+		"spanId":            s.sc.SpanID.String(),
+		"name":              s.name,
+		"kind":              s.kind,
+		"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+		"attributes":        encodeAttrs(s.attrs),
+	}
+	if s.parent.IsValid() {
+		span["parentSpanId"] = s.parent.String()
+	}
+	if s.err != "" {
+		span["status"] = map[string]any{"code": 2, "message": s.err}
+	}
+	return span
+}
+
+// encodeAttrs encodes the attributes as OTLP key-value list.
+func encodeAttrs(attrs []Attr) []map[string]any {
+	encoded := make([]map[string]any, 0, len(attrs))
+	for _, a := range attrs {
+		var value map[string]any
+		switch v := a.Value.(type) {
+		case string:
+			value = map[string]any{"stringValue": v}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		case int:
+			value = map[string]any{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]any{"doubleValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		encoded = append(encoded, map[string]any{"key": a.Key, "value": value})
+	}
+	return encoded
+}
+
+// Shutdown exports the queued spans and stops the exporter.
+func (e *OTLPExporter) Shutdown() {
+	e.once.Do(func() {
+		close(e.done)
+		e.wg.Wait()
+	})
+}
+
+// NewOTLPExporter instantiates the exporter for the traces URL, with the resource
+// attributes, and starts the export loop. See TracesURL.
+func NewOTLPExporter(logger *slog.Logger, url string, resource ...Attr) *OTLPExporter {
+	e := &OTLPExporter{
+		logger:   logger,
+		url:      url,
+		client:   &fasthttp.Client{Name: "edsrv"},
+		resource: resource,
+		spans:    make(chan *Span, exportQueueSize),
+		done:     make(chan struct{}),
+	}
+	e.wg.Add(1)
+	go e.loop()
+	return e
+}
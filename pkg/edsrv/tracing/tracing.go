@@ -0,0 +1,253 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader W3C trace context header.
+const TraceparentHeader = "traceparent"
+
+// TraceID trace identifier.
+type TraceID [16]byte
+
+// String shows the trace ID as hex.
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// IsValid asserts the trace ID is not all zeros.
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// SpanID span identifier.
+type SpanID [8]byte
+
+// String shows the span ID as hex.
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// IsValid asserts the span ID is not all zeros.
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// SpanContext represents the span identity propagated to the children, and
+// across processes with the "traceparent" header.
+type SpanContext struct {
+	TraceID TraceID // trace identifier
+	SpanID  SpanID  // span identifier
+	Sampled bool    // the trace is recorded
+}
+
+// IsValid asserts the trace and span IDs are informed.
+func (c SpanContext) IsValid() bool {
+	return c.TraceID.IsValid() && c.SpanID.IsValid()
+}
+
+// Traceparent formats the span context as "traceparent" header value.
+func (c SpanContext) Traceparent() string {
+	flags := "00"
+	if c.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", c.TraceID, c.SpanID, flags)
+}
+
+// ParseTraceparent parses the "traceparent" header value, version 00 and the
+// future versions with the same prefix are supported.
+func ParseTraceparent(v string) (SpanContext, bool) {
+	var c SpanContext
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		(parts[0] == "00" && len(parts) != 4) {
+		return c, false
+	}
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || len(version) != 1 {
+		return c, false
+	}
+	if !decodeHex(c.TraceID[:], parts[1]) || !decodeHex(c.SpanID[:], parts[2]) {
+		return c, false
+	}
+	var flags [1]byte
+	if !decodeHex(flags[:], parts[3]) {
+		return c, false
+	}
+	c.Sampled = flags[0]&0x01 == 0x01
+	return c, c.IsValid()
+}
+
+// decodeHex decodes the lowercase hex string on the informed buffer, the string
+// must fill the buffer.
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// SpanKind the span role in the trace, OTLP values.
+type SpanKind int
+
+const (
+	// SpanKindInternal internal operation.
+	SpanKindInternal SpanKind = 1
+	// SpanKindServer handles a remote request.
+	SpanKindServer SpanKind = 2
+)
+
+// Attr span attribute.
+type Attr struct {
+	Key   string // attribute name
+	Value any    // string, bool, int, int64 or float64
+}
+
+// Span represents a traced operation, a nil span is a no-op, so tracing is
+// disabled with a nil tracer.
+type Span struct {
+	tracer *Tracer     // span exporter
+	name   string      // operation name
+	kind   SpanKind    // span role
+	sc     SpanContext // span identity
+	parent SpanID      // parent span, zero for root spans
+	start  time.Time   // operation start
+	end    time.Time   // operation end
+	m      sync.Mutex  // serializes attributes and status
+	attrs  []Attr      // span attributes
+	err    string      // error status message
+	ended  bool        // the span is exported
+}
+
+// Context shows the span identity.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetAttr sets the span attribute.
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.attrs = append(s.attrs, Attr{Key: key, Value: value})
+}
+
+// RecordError sets the span error status, nil errors are ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.err = err.Error()
+}
+
+// End ends the span, exporting it when sampled. Only the first call is honored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.m.Lock()
+	if s.ended {
+		s.m.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.m.Unlock()
+	if s.sc.Sampled {
+		s.tracer.exporter.Export(s)
+	}
+}
+
+// Exporter receives the ended spans.
+type Exporter interface {
+	// Export queues the span for exporting, it must not block.
+	Export(*Span)
+}
+
+// Tracer instantiates the spans, a nil tracer creates nil spans.
+type Tracer struct {
+	exporter Exporter // ended spans destination
+}
+
+// spanKey context key for the current span.
+type spanKey struct{}
+
+// remoteKey context key for the remote parent span context.
+type remoteKey struct{}
+
+// ContextWithRemoteParent returns the context with the remote span context, the
+// parent for the next span, as informed by the "traceparent" header.
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanFromContext returns the current span, nil when not tracing.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// ContextWithSpan returns the context with the current span.
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	if s == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// Start starts the span as child of the current span on the context, or the
+// remote parent, otherwise as a new trace. Returns the context with the span.
+func (t *Tracer) Start(
+	ctx context.Context,
+	name string,
+	kind SpanKind,
+) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	s := &Span{tracer: t, name: name, kind: kind, start: time.Now()}
+	parent, ok := ctx.Value(remoteKey{}).(SpanContext)
+	if p := SpanFromContext(ctx); p != nil {
+		parent, ok = p.sc, true
+	}
+	if ok && parent.IsValid() {
+		s.sc.TraceID = parent.TraceID
+		s.sc.Sampled = parent.Sampled
+		s.parent = parent.SpanID
+	} else {
+		_, _ = rand.Read(s.sc.TraceID[:])
+		s.sc.Sampled = true
+	}
+	_, _ = rand.Read(s.sc.SpanID[:])
+	return ContextWithSpan(ctx, s), s
+}
+
+// Start starts a internal span as child of the current span on the context, a
+// no-op when the context carries no span.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.Start(ctx, name, SpanKindInternal)
+}
+
+// NewTracer instantiates the tracer exporting the spans on the exporter.
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
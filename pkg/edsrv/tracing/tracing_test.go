@@ -0,0 +1,150 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		wantOK      bool
+		wantSampled bool
+	}{{
+		name:        "sampled",
+		traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		wantOK:      true,
+		wantSampled: true,
+	}, {
+		name:        "not sampled",
+		traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+		wantOK:      true,
+	}, {
+		name:        "future version",
+		traceparent: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		wantOK:      true,
+		wantSampled: true,
+	}, {
+		name:        "invalid version",
+		traceparent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}, {
+		name:        "zero trace ID",
+		traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+	}, {
+		name:        "uppercase",
+		traceparent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+	}, {
+		name:        "short span ID",
+		traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa-01",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := ParseTraceparent(tt.traceparent)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseTraceparent() ok = %v, expected %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if sc.Sampled != tt.wantSampled {
+				t.Errorf("ParseTraceparent() sampled = %v, expected %v",
+					sc.Sampled, tt.wantSampled)
+			}
+			if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+				t.Errorf("ParseTraceparent() trace ID = %s", sc.TraceID)
+			}
+		})
+	}
+}
+
+func TestTracesURL(t *testing.T) {
+	for endpoint, want := range map[string]string{
+		"http://localhost:4318":            "http://localhost:4318/v1/traces",
+		"http://localhost:4318/":           "http://localhost:4318/v1/traces",
+		"https://otel.example/custom/path": "https://otel.example/custom/path",
+	} {
+		got, err := TracesURL(endpoint)
+		if err != nil || got != want {
+			t.Errorf("TracesURL(%q) = %q, %v, expected %q", endpoint, got, err, want)
+		}
+	}
+	if _, err := TracesURL("localhost:4318"); err == nil {
+		t.Errorf("TracesURL() expected error for URL without scheme")
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var m sync.Mutex
+	var requests []map[string]any
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := map[string]any{}
+		if r.URL.Path != TracesPath || json.Unmarshal(body, &req) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		m.Lock()
+		requests = append(requests, req)
+		m.Unlock()
+	}))
+	defer collector.Close()
+
+	url, err := TracesURL(collector.URL)
+	if err != nil {
+		t.Fatalf("TracesURL() error = %v", err)
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	e := NewOTLPExporter(logger, url, Attr{Key: "service.name", Value: "edsrv"})
+	tracer := NewTracer(e)
+
+	remote, _ := ParseTraceparent(
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithRemoteParent(context.Background(), remote)
+	ctx, root := tracer.Start(ctx, "POST /", SpanKindServer)
+	_, child := Start(ctx, "editor")
+	child.SetAttr("exit-status", 1)
+	child.RecordError(errors.New("editor failed"))
+	child.End()
+	root.End()
+	root.End()
+
+	// without a span on the context nothing is traced
+	if _, s := Start(context.Background(), "none"); s != nil {
+		t.Errorf("Start() = %v, expected nil span", s)
+	}
+
+	e.Shutdown()
+
+	m.Lock()
+	defer m.Unlock()
+	if len(requests) != 1 {
+		t.Fatalf("collector received %d requests, expected 1", len(requests))
+	}
+	rs := requests[0]["resourceSpans"].([]any)[0].(map[string]any)
+	spans := rs["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)
+	if len(spans) != 2 {
+		t.Fatalf("collector received %d spans, expected 2", len(spans))
+	}
+	got := spans[0].(map[string]any)
+	if got["name"] != "editor" || got["traceId"] != remote.TraceID.String() ||
+		got["parentSpanId"] != root.Context().SpanID.String() {
+		t.Errorf("child span = %v, expected on the remote trace", got)
+	}
+	if got["status"].(map[string]any)["message"] != "editor failed" {
+		t.Errorf("child span status = %v, expected the error", got["status"])
+	}
+	got = spans[1].(map[string]any)
+	if got["parentSpanId"] != remote.SpanID.String() || got["kind"] != float64(2) {
+		t.Errorf("root span = %v, expected child of the remote parent", got)
+	}
+}
@@ -0,0 +1,63 @@
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build attributes, set on release builds with ldflags, for instance:
+//
+//	-X github.com/otaviof/edsrv/pkg/edsrv/version.Version=1.0.0
+var (
+	// Version application version.
+	Version = "dev"
+	// Commit source revision.
+	Commit = ""
+	// Date build date.
+	Date = ""
+)
+
+// Info represents the build information.
+type Info struct {
+	Version   string `json:"version"`   // application version
+	Commit    string `json:"commit"`    // source revision
+	Date      string `json:"date"`      // build date
+	GoVersion string `json:"goVersion"` // go toolchain version
+	Platform  string `json:"platform"`  // operating system and architecture
+}
+
+// String shows the build information in a single line.
+func (i Info) String() string {
+	return fmt.Sprintf("%s (commit %s, built %s, %s %s)",
+		i.Version, i.Commit, i.Date, i.GoVersion, i.Platform)
+}
+
+// Get returns the build information, without ldflags the commit and date are
+// taken from the version control information embedded by the go toolchain.
+func Get() Info {
+	i := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && i.Commit == "":
+				i.Commit = s.Value
+			case s.Key == "vcs.time" && i.Date == "":
+				i.Date = s.Value
+			}
+		}
+	}
+	if i.Commit == "" {
+		i.Commit = "unknown"
+	}
+	if i.Date == "" {
+		i.Date = "unknown"
+	}
+	return i
+}